@@ -0,0 +1,12 @@
+// Command verify-signed-message verifies Bitcoin signed messages, run `verify-signed-message help` for its usage.
+package main
+
+import (
+	"os"
+
+	"github.com/bitonicnl/verify-signed-message/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,277 @@
+// Package addresshint explains why an address could not be decoded, so users can find their typos.
+package addresshint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+)
+
+// Values taken from BIP-173 and BIP-350.
+const (
+	bech32Charset        = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Const          = 1
+	bech32mConst         = 0x2bc830a3
+	bech32ChecksumLength = 6
+	bech32MaxLength      = 90
+	bech32Separator      = '1'
+)
+
+// Encoding is the checksum variant of a bech32 string.
+type Encoding string
+
+const (
+	// EncodingBech32 is the checksum specified by BIP-173, used for witness version 0.
+	EncodingBech32 Encoding = "bech32"
+	// EncodingBech32m is the checksum specified by BIP-350, used for witness version 1 and higher.
+	EncodingBech32m Encoding = "bech32m"
+)
+
+// Hint describes what is probably wrong with a bech32 encoded address.
+type Hint struct {
+	// Issues are human-readable descriptions of everything that was found.
+	Issues []string
+	// Positions are the zero-based indexes of the characters that are probably wrong.
+	Positions []int
+}
+
+// String joins the issues, it is empty when nothing was found.
+func (h Hint) String() string {
+	return strings.Join(h.Issues, ", ")
+}
+
+// Bech32 inspects an address that failed to decode and reports what is probably wrong with it, assuming it was meant to be a segwit address with the expected human-readable part.
+//
+// Like LocateErrors of the reference implementation (https://github.com/bitcoin/bitcoin/blob/v27.0/src/bech32.cpp), up to two substituted characters are located.
+// Positions are only reported when the correction is unambiguous, the correction itself is never suggested as it could result in a valid address of someone else.
+// The second return value is false when the address does not look like a bech32 string at all.
+func Bech32(address string, expectedHRP string) (Hint, bool) {
+	hint := Hint{Issues: []string{}, Positions: []int{}}
+
+	separator := strings.LastIndexByte(address, bech32Separator)
+	if separator < 1 || len(address) > bech32MaxLength || len(address)-separator-1 < bech32ChecksumLength {
+		return hint, false
+	}
+
+	lowered := strings.ToLower(address)
+	hrp := lowered[:separator]
+	if !isLikelyHRP(hrp, expectedHRP) {
+		return hint, false
+	}
+
+	if lowered != address && strings.ToUpper(address) != address {
+		hint.Issues = append(hint.Issues, "address mixes upper and lower case")
+	}
+
+	// Report invalid characters, they can not be part of the checksum
+	data := make([]byte, 0, len(lowered)-separator-1)
+	for i := separator + 1; i < len(lowered); i++ {
+		value := strings.IndexByte(bech32Charset, lowered[i])
+		if value < 0 {
+			hint.Issues = append(hint.Issues, fmt.Sprintf("character '%c' at position %d is not allowed", address[i], i+1))
+			hint.Positions = append(hint.Positions, i)
+
+			continue
+		}
+
+		data = append(data, byte(value))
+	}
+	if len(hint.Positions) > 0 {
+		return hint, true
+	}
+
+	// A different network is reported as is, an unknown human-readable part is probably a typo of the expected one
+	checksumHRP := hrp
+	if hrp != expectedHRP {
+		if chaincfg.IsBech32SegwitPrefix(hrp + string(bech32Separator)) {
+			hint.Issues = append(hint.Issues, fmt.Sprintf("prefix '%s' belongs to a different network, expected '%s'", hrp, expectedHRP))
+		} else {
+			hint.Issues = append(hint.Issues, fmt.Sprintf("prefix '%s' should probably be '%s'", hrp, expectedHRP))
+			checksumHRP = expectedHRP
+		}
+	}
+
+	values := append(hrpExpand(checksumHRP), data...)
+	residue := polymod(values)
+	version := int(data[0])
+
+	switch residue {
+	case bech32Const:
+		if version != 0 {
+			hint.Issues = append(hint.Issues, fmt.Sprintf("witness version %d requires a %s checksum, but %s is used", version, EncodingBech32m, EncodingBech32))
+		}
+	case bech32mConst:
+		if version == 0 {
+			hint.Issues = append(hint.Issues, fmt.Sprintf("witness version %d requires a %s checksum, but %s is used", version, EncodingBech32, EncodingBech32m))
+		}
+	default:
+		positions, found := locateErrors(values, len(values)-len(data), residue)
+		if !found {
+			hint.Issues = append(hint.Issues, "checksum is invalid, more than two characters are probably wrong")
+
+			break
+		}
+
+		for _, position := range positions {
+			hint.Positions = append(hint.Positions, separator+1+position)
+		}
+		hint.Issues = append(hint.Issues, describePositions(address, hint.Positions))
+	}
+
+	return hint, true
+}
+
+// isLikelyHRP reports whether the human-readable part is one of a known network, or a single typo away from the expected one.
+// This prevents base58 addresses that happen to contain a '1' from being treated as bech32 strings.
+func isLikelyHRP(hrp string, expectedHRP string) bool {
+	if hrp == expectedHRP || chaincfg.IsBech32SegwitPrefix(hrp+string(bech32Separator)) {
+		return true
+	}
+
+	if len(hrp) != len(expectedHRP) {
+		return false
+	}
+
+	differences := 0
+	for i := range len(hrp) {
+		if hrp[i] != expectedHRP[i] {
+			differences++
+		}
+	}
+
+	return differences == 1
+}
+
+// substitution is a single substituted symbol in the data part.
+type substitution struct {
+	position int
+	value    byte
+	syndrome uint32
+}
+
+// locateErrors locates up to two substituted symbols in the data part, which starts at offset.
+//
+// The checksum is linear: the residue of a string with substitutions is the residue of the original string XOR the syndromes of each substitution.
+// Instead of solving this with discrete logarithms in GF(1024), as the reference implementation does, the syndromes of all possible single substitutions are looked up.
+// Both checksum constants are tried, the outcome has to match the encoding required by the (corrected) witness version.
+func locateErrors(values []byte, offset int, residue uint32) ([]int, bool) {
+	substitutions := singleSubstitutions(len(values), offset)
+	bySyndrome := lo.GroupBy(substitutions, func(item substitution) uint32 { return item.syndrome })
+
+	var candidates [][]substitution
+	for _, constant := range []uint32{bech32Const, bech32mConst} {
+		syndrome := residue ^ constant
+
+		found := lo.Map(bySyndrome[syndrome], func(item substitution, _ int) []substitution { return []substitution{item} })
+		for _, first := range substitutions {
+			for _, second := range bySyndrome[syndrome^first.syndrome] {
+				if second.position > first.position {
+					found = append(found, []substitution{first, second})
+				}
+			}
+		}
+
+		// The witness version decides which checksum should have been used
+		for _, candidate := range found {
+			version := values[offset]
+			if candidate[0].position == 0 {
+				version ^= candidate[0].value
+			}
+
+			if (version == 0) == (constant == bech32Const) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	// Prefer the fewest substitutions, like the reference implementation, but only if that is unambiguous
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	fewest := lo.MinBy(candidates, func(a, b []substitution) bool { return len(a) < len(b) })
+	if lo.CountBy(candidates, func(candidate []substitution) bool { return len(candidate) == len(fewest) }) != 1 {
+		return nil, false
+	}
+
+	return lo.Map(fewest, func(item substitution, _ int) int { return item.position }), true
+}
+
+// singleSubstitutions returns every possible substitution of a single symbol in the data part, with its syndrome.
+func singleSubstitutions(length int, offset int) []substitution {
+	zero := polymod(make([]byte, length))
+
+	// The syndrome of each bit of each symbol, all others follow by XOR-ing them
+	bits := make([][5]uint32, length-offset)
+	for position := range bits {
+		for bit := range 5 {
+			values := make([]byte, length)
+			values[offset+position] = 1 << bit
+			bits[position][bit] = polymod(values) ^ zero
+		}
+	}
+
+	substitutions := make([]substitution, 0, len(bits)*(len(bech32Charset)-1))
+	for position := range bits {
+		for value := byte(1); value < byte(len(bech32Charset)); value++ {
+			syndrome := uint32(0)
+			for bit := range 5 {
+				if value&(1<<bit) != 0 {
+					syndrome ^= bits[position][bit]
+				}
+			}
+
+			substitutions = append(substitutions, substitution{position: position, value: value, syndrome: syndrome})
+		}
+	}
+
+	return substitutions
+}
+
+// describePositions describes the characters that are probably wrong, using one-based positions.
+func describePositions(address string, positions []int) string {
+	descriptions := lo.Map(positions, func(position int, _ int) string {
+		return fmt.Sprintf("'%c' at position %d", address[position], position+1)
+	})
+
+	if len(descriptions) == 1 {
+		return "character " + descriptions[0] + " is probably wrong"
+	}
+
+	return "characters " + strings.Join(descriptions, " and ") + " are probably wrong"
+}
+
+// hrpExpand expands the human-readable part for use in the checksum, as specified by BIP-173.
+func hrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := range len(hrp) {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+
+	expanded = append(expanded, 0)
+	for i := range len(hrp) {
+		expanded = append(expanded, hrp[i]&31)
+	}
+
+	return expanded
+}
+
+// polymod computes the bech32 checksum residue, as specified by BIP-173.
+func polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := range 5 {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+
+	return checksum
+}
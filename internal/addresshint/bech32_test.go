@@ -0,0 +1,102 @@
+package addresshint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/addresshint"
+)
+
+type Bech32TestSuite struct {
+	suite.Suite
+}
+
+func TestBech32TestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(Bech32TestSuite))
+}
+
+func (s *Bech32TestSuite) TestBech32() {
+	tests := map[string]struct {
+		address  string
+		expected addresshint.Hint
+	}{
+		"valid": {
+			address:  "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			expected: addresshint.Hint{Issues: []string{}, Positions: []int{}},
+		},
+		"valid - upper case": {
+			address:  "BC1Q9VZA2E8X573NCZRLZMS0WVX3GSQJX7VAVGKX0L",
+			expected: addresshint.Hint{Issues: []string{}, Positions: []int{}},
+		},
+		"single substitution": {
+			address:  "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0q",
+			expected: addresshint.Hint{Issues: []string{"character 'q' at position 42 is probably wrong"}, Positions: []int{41}},
+		},
+		"single substitution - witness version": {
+			address:  "bc1p9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			expected: addresshint.Hint{Issues: []string{"character 'p' at position 4 is probably wrong"}, Positions: []int{3}},
+		},
+		"two substitutions": {
+			address:  "bc1q9vza2e8x573nczrlzms0xvx3gsqjx7vavgkz0l",
+			expected: addresshint.Hint{Issues: []string{"characters 'x' at position 25 and 'z' at position 40 are probably wrong"}, Positions: []int{24, 39}},
+		},
+		"single substitution - taproot - checksum": {
+			address:  "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8ll3",
+			expected: addresshint.Hint{Issues: []string{"character 'l' at position 61 is probably wrong"}, Positions: []int{60}},
+		},
+		"invalid character": {
+			address:  "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkxol",
+			expected: addresshint.Hint{Issues: []string{"character 'o' at position 41 is not allowed"}, Positions: []int{40}},
+		},
+		"mixed case": {
+			address:  "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkX0l",
+			expected: addresshint.Hint{Issues: []string{"address mixes upper and lower case"}, Positions: []int{}},
+		},
+		"prefix typo": {
+			address:  "bd1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			expected: addresshint.Hint{Issues: []string{"prefix 'bd' should probably be 'bc'"}, Positions: []int{}},
+		},
+		"other network": {
+			address:  "tb1qnzwefk7wzphlc4xeawf8p4yqtcwzdgsvukwma8",
+			expected: addresshint.Hint{Issues: []string{"prefix 'tb' belongs to a different network, expected 'bc'"}, Positions: []int{}},
+		},
+		"bech32 for witness version 1": {
+			address:  "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5snuhnwn",
+			expected: addresshint.Hint{Issues: []string{"witness version 1 requires a bech32m checksum, but bech32 is used"}, Positions: []int{}},
+		},
+		"bech32m for witness version 0": {
+			address:  "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vae5x22a",
+			expected: addresshint.Hint{Issues: []string{"witness version 0 requires a bech32 checksum, but bech32m is used"}, Positions: []int{}},
+		},
+		"too many substitutions": {
+			address:  "bc1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq",
+			expected: addresshint.Hint{Issues: []string{"checksum is invalid, more than two characters are probably wrong"}, Positions: []int{}},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			hint, ok := addresshint.Bech32(tt.address, "bc")
+			s.Require().True(ok)
+			s.Require().Equal(tt.expected, hint)
+		})
+	}
+}
+
+func (s *Bech32TestSuite) TestBech32NotBech32() {
+	for _, address := range []string{"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "INVALID", "bc1qqqq"} {
+		s.Run(address, func() {
+			_, ok := addresshint.Bech32(address, "bc")
+			s.Require().False(ok)
+		})
+	}
+}
+
+func (s *Bech32TestSuite) TestHintString() {
+	hint := addresshint.Hint{Issues: []string{"address mixes upper and lower case", "character 'q' at position 42 is probably wrong"}, Positions: []int{41}}
+	s.Require().Equal("address mixes upper and lower case, character 'q' at position 42 is probably wrong", hint.String())
+}
@@ -0,0 +1,160 @@
+package bip322
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Minimum sizes of the parts of a serialized transaction, used to check the counts against the remaining bytes.
+const (
+	// minTxInSize is the outpoint (36 bytes), the length of the signature script (1 byte) and the sequence (4 bytes).
+	minTxInSize = 41
+	// minTxOutSize is the value (8 bytes) and the length of the public key script (1 byte).
+	minTxOutSize = 9
+	// minWitnessItemSize is the length of a witness item (1 byte).
+	minWitnessItemSize = 1
+	// witnessMarkerFlag is the flag that follows the zero marker of a transaction with witness data.
+	witnessMarkerFlag = 0x01
+)
+
+// Sizes of the fixed fields of a serialized transaction.
+const (
+	versionSize  = 4
+	outPointSize = 36
+	sequenceSize = 4
+	valueSize    = 8
+	lockTimeSize = 4
+)
+
+// checkTransactionLayout walks a serialized transaction without allocating, to ensure every count and length fits in the remaining bytes.
+// The wire package allocates based on the counts before reading the items, which would allow a small signature to allocate a lot of memory.
+//
+// Only counts and lengths that can not fit are reported, other errors are left to the wire package, so its errors are kept.
+func checkTransactionLayout(data []byte) error {
+	layout := transactionLayout{reader: bytes.NewReader(data)}
+	if err := layout.check(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	return nil
+}
+
+// transactionLayout reads the structure of a serialized transaction.
+type transactionLayout struct {
+	reader *bytes.Reader
+}
+
+// check walks the transaction, in the order of wire.MsgTx.BtcDecode.
+func (l transactionLayout) check() error {
+	if err := l.skip(versionSize); err != nil {
+		return err
+	}
+
+	inputs, err := l.count("inputs", minTxInSize)
+	if err != nil {
+		return err
+	}
+
+	// A zero input count is the marker of a transaction with witness data, which is followed by the flag and the actual input count
+	hasWitness := false
+	if inputs == 0 {
+		flag, err := l.reader.ReadByte()
+		if err != nil || flag != witnessMarkerFlag {
+			return io.ErrUnexpectedEOF
+		}
+
+		hasWitness = true
+		if inputs, err = l.count("inputs", minTxInSize); err != nil {
+			return err
+		}
+	}
+
+	for range inputs {
+		if err := l.skip(outPointSize); err != nil {
+			return err
+		}
+
+		if err := l.skipVarBytes("signature script"); err != nil {
+			return err
+		}
+
+		if err := l.skip(sequenceSize); err != nil {
+			return err
+		}
+	}
+
+	outputs, err := l.count("outputs", minTxOutSize)
+	if err != nil {
+		return err
+	}
+
+	for range outputs {
+		if err := l.skip(valueSize); err != nil {
+			return err
+		}
+
+		if err := l.skipVarBytes("public key script"); err != nil {
+			return err
+		}
+	}
+
+	if hasWitness {
+		for range inputs {
+			items, err := l.count("witness items", minWitnessItemSize)
+			if err != nil {
+				return err
+			}
+
+			for range items {
+				if err := l.skipVarBytes("witness item"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return l.skip(lockTimeSize)
+}
+
+// count reads a count and ensures the items, of at least the passed size each, fit in the remaining bytes.
+func (l transactionLayout) count(name string, minSize uint64) (uint64, error) {
+	count, err := wire.ReadVarInt(l.reader, 0)
+	if err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if remaining := uint64(l.reader.Len()); count > remaining/minSize {
+		return 0, fmt.Errorf("too many %s for the size of the transaction [count %d, remaining bytes %d]", name, count, remaining)
+	}
+
+	return count, nil
+}
+
+// skipVarBytes skips a length prefixed field, ensuring its length fits in the remaining bytes.
+func (l transactionLayout) skipVarBytes(name string) error {
+	length, err := wire.ReadVarInt(l.reader, 0)
+	if err != nil {
+		return io.ErrUnexpectedEOF
+	}
+
+	if remaining := uint64(l.reader.Len()); length > remaining {
+		return fmt.Errorf("%s is larger than the remaining bytes of the transaction [length %d, remaining bytes %d]", name, length, remaining)
+	}
+
+	return l.skip(length)
+}
+
+// skip skips the passed amount of bytes.
+func (l transactionLayout) skip(length uint64) error {
+	if length > uint64(l.reader.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+
+	_, err := l.reader.Seek(int64(length), io.SeekCurrent) //nolint:gosec // The length is at most the remaining bytes
+
+	return err
+}
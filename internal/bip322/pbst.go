@@ -15,8 +15,6 @@ const (
 	toSpendVersion = 0
 	// toSpendLockTime contains the transaction lock time.
 	toSpendLockTime = 0
-	// toSpendInputHash contains the dummy input hash.
-	toSpendInputHash = "0000000000000000000000000000000000000000000000000000000000000000"
 	// toSpendInputIndex contains the dummy input index.
 	toSpendInputIndex = 0xFFFFFFFF
 	// toSpendInputSeq contains the sequence number for the input.
@@ -41,26 +39,22 @@ const (
 //
 // For more details, refer: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#full
 func BuildToSpendTx(msg []byte, address btcutil.Address) (*wire.MsgTx, error) {
+	return BuildToSpendTxFromHash(internal.CreateMagicMessageBIP322(msg), address)
+}
+
+// BuildToSpendTxFromHash builds a toSpend transaction based on the BIP-322 spec. It requires the BIP-322 tagged hash of the message that is signed and the address that produced the signature.
+//
+// For more details, refer: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#full
+func BuildToSpendTxFromHash(messageHash [32]byte, address btcutil.Address) (*wire.MsgTx, error) {
 	// Create a new transaction
 	psbt := wire.NewMsgTx(toSpendVersion)
 	psbt.LockTime = toSpendLockTime
 
-	// Create an outpoint for the input
-	inputHash, err := chainhash.NewHashFromStr(toSpendInputHash)
-	if err != nil {
-		// This error indicates a programming error since the input hash is predefined
-		panic(err)
-	}
-	outPoint := wire.NewOutPoint(inputHash, toSpendInputIndex)
-
-	// Generate the signature script for the input
-	script, err := toSpendSignatureScript(msg)
-	if err != nil {
-		return nil, err
-	}
+	// Create an outpoint for the input, which spends the dummy (all zero) input hash
+	outPoint := wire.NewOutPoint(&chainhash.Hash{}, toSpendInputIndex)
 
 	// Create the input using the outpoint and signature script
-	input := wire.NewTxIn(outPoint, script, nil)
+	input := wire.NewTxIn(outPoint, toSpendSignatureScript(messageHash), nil)
 	input.Sequence = toSpendInputSeq
 
 	// Create the output paying to the provided address
@@ -107,45 +101,22 @@ func BuildToSignTx(toSpend *wire.MsgTx) *wire.MsgTx {
 }
 
 // toSpendSignatureScript creates the signature script for the input of the toSpend transaction. It follows the BIP-322 specification.
+// The script is always OP_0 followed by a push of the 32 byte message hash, so it is built directly instead of with a script builder.
 //
 // For more details, refer: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#full
-func toSpendSignatureScript(msg []byte) ([]byte, error) {
-	// Create a new script builder
-	builder := txscript.NewScriptBuilder()
+func toSpendSignatureScript(messageHash [32]byte) []byte {
+	script := make([]byte, 0, 2+len(messageHash))
 
-	// Add OP_0 to initialize the witness stack
-	builder.AddOp(txscript.OP_0)
+	// Add OP_0 to initialize the witness stack, followed by the magic message as specified in BIP-322
+	script = append(script, txscript.OP_0, txscript.OP_DATA_32)
 
-	// Create the magic message as specified in BIP-322
-	data := internal.CreateMagicMessageBIP322(msg)
-	builder.AddData(data[:])
-
-	// Generate the script
-	script, err := builder.Script()
-	if err != nil {
-		// Since this is based on the incoming message, this could happen
-		return nil, err
-	}
-
-	return script, nil
+	return append(script, messageHash[:]...)
 }
 
 // buildSignPkScript creates the public key script for the output of the toSign transaction. It follows the BIP-322 specification.
 //
 // For more details, refer: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#full
 func buildSignPkScript() []byte {
-	// Create a new script builder
-	builder := txscript.NewScriptBuilder()
-
 	// Add OP_RETURN opcode to mark the output as unspendable
-	builder.AddOp(txscript.OP_RETURN)
-
-	// Generate the script
-	script, err := builder.Script()
-	if err != nil {
-		// Since we are constructing the script, this error should not occur in practice
-		panic(err)
-	}
-
-	return script
+	return []byte{txscript.OP_RETURN}
 }
@@ -0,0 +1,224 @@
+package bip322
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// schnorrSignatureLength is the length of a schnorr signature that uses the default sighash type, which is not appended.
+const schnorrSignatureLength = 64
+
+// Step is the state of the script engine after executing a single opcode.
+type Step struct {
+	// ScriptIndex is the index of the script the opcode belongs to: 0 is the signature script, 1 the public key script and 2 the redeem or witness script.
+	ScriptIndex int
+	// OpcodeIndex is the index of the opcode in its script.
+	OpcodeIndex int
+	// Opcode is the disassembly of the opcode, including the data it pushes.
+	Opcode string
+	// Stack is the data stack after executing the opcode, the top of the stack last.
+	Stack [][]byte
+	// AltStack is the alternative stack after executing the opcode, the top of the stack last.
+	AltStack [][]byte
+}
+
+// Trace records how the script engine verified the toSign transaction, for debugging signatures that fail.
+type Trace struct {
+	// ToSpend is the toSpend transaction that was drafted for the address and message.
+	ToSpend *wire.MsgTx
+	// ToSign is the toSign transaction that spends it.
+	ToSign *wire.MsgTx
+	// SigHash is the hash the first signature of the witness commits to, it is nil when it can not be determined from the witness alone or the engine could not be created.
+	SigHash []byte
+	// SigHashType is the sighash type of the first signature.
+	SigHashType txscript.SigHashType
+	// Steps are the executed opcodes, in order.
+	Steps []Step
+	// Err is the error of the script execution, nil if it succeeded.
+	Err error
+}
+
+// TraceHash verifies a BIP-322 simple signature like VerifyHash, recording every step of the script engine.
+// An error is returned when the transactions could not be drafted, errors of the script execution are recorded in the trace.
+func TraceHash(address btcutil.Address, messageHash [32]byte, signatureDecoded []byte) (Trace, error) {
+	toSpend, toSign, err := buildSimple(address, messageHash, signatureDecoded)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	return trace(toSpend, toSign), nil
+}
+
+// TraceFull verifies a BIP-322 full signature like VerifyFull, recording every step of the script engine.
+// An error is returned when the transactions could not be drafted, errors of the script execution are recorded in the trace.
+func TraceFull(address btcutil.Address, messageHash [32]byte, toSign *wire.MsgTx) (Trace, error) {
+	toSpend, err := buildFull(address, messageHash, toSign)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	return trace(toSpend, toSign), nil
+}
+
+// trace runs the script of the first input of toSign opcode by opcode, in the same way as txscript.Engine.Execute does.
+func trace(toSpend *wire.MsgTx, toSign *wire.MsgTx) Trace {
+	result := Trace{ToSpend: toSpend, ToSign: toSign, SigHash: nil, SigHashType: txscript.SigHashDefault, Steps: []Step{}, Err: nil}
+
+	vm, sigHashes, inputFetcher, err := newEngine(toSpend, toSign)
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+
+	result.SigHash, result.SigHashType = sigHash(toSpend.TxOut[0].PkScript, toSign, sigHashes, inputFetcher)
+
+	for {
+		step, err := nextStep(vm)
+		if err != nil {
+			result.Err = fmt.Errorf("script execution failed: %w", err)
+
+			return result
+		}
+
+		done, err := vm.Step()
+		step.Stack, step.AltStack = cloneStack(vm.GetStack()), cloneStack(vm.GetAltStack())
+		result.Steps = append(result.Steps, step)
+
+		if err == nil && done {
+			err = vm.CheckErrorCondition(true)
+		}
+
+		if err != nil {
+			result.Err = fmt.Errorf("script execution failed: %w", err)
+
+			return result
+		}
+
+		if done {
+			return result
+		}
+	}
+}
+
+// nextStep returns the step of the opcode that the engine executes next, without its stacks.
+func nextStep(vm *txscript.Engine) (Step, error) {
+	disassembly, err := vm.DisasmPC()
+	if err != nil {
+		return Step{}, err
+	}
+
+	// The disassembly is prefixed with the script and opcode index, as 'ss:oooo: '
+	step := Step{ScriptIndex: 0, OpcodeIndex: 0, Opcode: "", Stack: nil, AltStack: nil}
+	if _, err := fmt.Sscanf(disassembly, "%x:%x:", &step.ScriptIndex, &step.OpcodeIndex); err != nil {
+		return Step{}, fmt.Errorf("could not parse disassembly '%s': %w", disassembly, err)
+	}
+
+	_, step.Opcode, _ = strings.Cut(disassembly, ": ")
+
+	return step, nil
+}
+
+// sigHash computes the hash the first signature of the witness commits to, and its sighash type.
+// Only scripts that place the signature at a known position are supported, for other scripts the hash is nil.
+func sigHash(pkScript []byte, toSign *wire.MsgTx, sigHashes *txscript.TxSigHashes, inputFetcher txscript.PrevOutputFetcher) ([]byte, txscript.SigHashType) {
+	input := toSign.TxIn[0]
+
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.WitnessV0PubKeyHashTy:
+		return witnessV0SigHash(pkScript, input.Witness, toSign, sigHashes)
+	case txscript.WitnessV0ScriptHashTy:
+		if len(input.Witness) == 0 {
+			return nil, txscript.SigHashDefault
+		}
+
+		// The witness script is the last item, the signature is the first item that is not empty, as multisig starts with a dummy item
+		for _, item := range input.Witness[:len(input.Witness)-1] {
+			if len(item) > 0 {
+				return witnessV0SigHash(input.Witness[len(input.Witness)-1], [][]byte{item}, toSign, sigHashes)
+			}
+		}
+	case txscript.WitnessV1TaprootTy:
+		// Only key path spends have a single item, the signature
+		if len(input.Witness) != 1 {
+			return nil, txscript.SigHashDefault
+		}
+
+		hashType := txscript.SigHashDefault
+		if len(input.Witness[0]) > schnorrSignatureLength {
+			hashType = txscript.SigHashType(input.Witness[0][schnorrSignatureLength])
+		}
+
+		if hash, err := txscript.CalcTaprootSignatureHash(sigHashes, hashType, toSign, 0, inputFetcher); err == nil {
+			return hash, hashType
+		}
+	case txscript.ScriptHashTy:
+		// Only nested P2WPKH is supported, the redeem script is the only push of the signature script
+		if redeemScript := lastScriptPush(input.SignatureScript); txscript.IsPayToWitnessPubKeyHash(redeemScript) {
+			return witnessV0SigHash(redeemScript, input.Witness, toSign, sigHashes)
+		}
+	case txscript.PubKeyHashTy, txscript.PubKeyTy:
+		if signature := firstScriptPush(input.SignatureScript); len(signature) > 0 {
+			hashType := txscript.SigHashType(signature[len(signature)-1])
+			if hash, err := txscript.CalcSignatureHash(pkScript, hashType, toSign, 0); err == nil {
+				return hash, hashType
+			}
+		}
+	default:
+	}
+
+	return nil, txscript.SigHashDefault
+}
+
+// witnessV0SigHash computes the segwit v0 hash of the signature that is the first item of the witness.
+func witnessV0SigHash(script []byte, witness [][]byte, toSign *wire.MsgTx, sigHashes *txscript.TxSigHashes) ([]byte, txscript.SigHashType) {
+	if len(witness) == 0 || len(witness[0]) == 0 {
+		return nil, txscript.SigHashDefault
+	}
+
+	hashType := txscript.SigHashType(witness[0][len(witness[0])-1])
+
+	hash, err := txscript.CalcWitnessSigHash(script, sigHashes, hashType, toSign, 0, toSpendOutputValue)
+	if err != nil {
+		return nil, txscript.SigHashDefault
+	}
+
+	return hash, hashType
+}
+
+// firstScriptPush returns the data of the first push of a signature script.
+func firstScriptPush(signatureScript []byte) []byte {
+	tokenizer := txscript.MakeScriptTokenizer(0, signatureScript)
+	if tokenizer.Next() {
+		return tokenizer.Data()
+	}
+
+	return nil
+}
+
+// lastScriptPush returns the data of the last push of a signature script.
+func lastScriptPush(signatureScript []byte) []byte {
+	var data []byte
+
+	tokenizer := txscript.MakeScriptTokenizer(0, signatureScript)
+	for tokenizer.Next() {
+		data = tokenizer.Data()
+	}
+
+	return data
+}
+
+// cloneStack copies the items of a stack, so they can not be modified by the engine.
+func cloneStack(stack [][]byte) [][]byte {
+	cloned := make([][]byte, len(stack))
+	for i, item := range stack {
+		cloned[i] = slices.Clone(item)
+	}
+
+	return cloned
+}
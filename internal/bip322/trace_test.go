@@ -0,0 +1,174 @@
+package bip322_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal"
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+)
+
+type TraceTestSuite struct {
+	suite.Suite
+}
+
+func TestTraceTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(TraceTestSuite))
+}
+
+func (s *TraceTestSuite) TestTraceHash() {
+	address, err := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	signatureDecoded, err := base64.StdEncoding.DecodeString("AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=")
+	s.Require().NoError(err)
+
+	trace, err := bip322.TraceHash(address, internal.CreateMagicMessageBIP322([]byte("Hello World")), signatureDecoded)
+	s.Require().NoError(err)
+	s.Require().NoError(trace.Err)
+	s.Require().Equal(txscript.SigHashAll, trace.SigHashType)
+	s.verifyWitnessV0SigHash(trace)
+
+	// The witness program is executed as the P2PKH script it stands for
+	opcodes := []string{}
+	for _, step := range trace.Steps {
+		opcodes = append(opcodes, step.Opcode)
+	}
+	s.Require().Equal([]string{
+		"OP_0", "OP_DATA_20 0x2b05d564e6a7a33c087f16e0f730d1440123799d",
+		"OP_DUP", "OP_HASH160", "OP_DATA_20 0x2b05d564e6a7a33c087f16e0f730d1440123799d", "OP_EQUALVERIFY", "OP_CHECKSIG",
+	}, opcodes)
+
+	last := trace.Steps[len(trace.Steps)-1]
+	s.Require().Equal(2, last.ScriptIndex)
+	s.Require().Equal(4, last.OpcodeIndex)
+	s.Require().Equal([][]byte{{0x01}}, last.Stack)
+	s.Require().Empty(last.AltStack)
+
+	// A different message results in a failed check, which is recorded with the state of the engine
+	trace, err = bip322.TraceHash(address, internal.CreateMagicMessageBIP322([]byte("Hello World - This should fail")), signatureDecoded)
+	s.Require().NoError(err)
+	s.Require().EqualError(trace.Err, "script execution failed: signature not empty on failed checksig")
+	s.Require().Equal("OP_CHECKSIG", trace.Steps[len(trace.Steps)-1].Opcode)
+	s.Require().Empty(trace.Steps[len(trace.Steps)-1].Stack)
+}
+
+func (s *TraceTestSuite) TestTraceHashIncorrect() {
+	address, err := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	_, err = bip322.TraceHash(address, internal.CreateMagicMessageBIP322([]byte("Hello World")), []byte{0x01})
+	s.Require().EqualError(err, "error converting signature into witness: too many witness items for the size of the signature [count 1, remaining bytes 0]")
+}
+
+func (s *TraceTestSuite) TestTraceFull() {
+	// The signatures of TestVerifyFull
+	tests := map[string]struct {
+		address   string
+		signature string
+	}{
+		"p2pkh": {
+			address:   "14vV3aCHBeStb5bkenkNHbe2YAFinYdXgc",
+			signature: "AAAAAAHZIvdvR4fompS+lLTvaKJgjitVabp8CizknOvglZs2XgAAAABqRzBEAiB3hjKYQcm/KGTsalB3I4kixH3+uDyHQzt1PN5cBGJsvQIgJnRxSVWIbijmMST7VnxGpI8OOCU/tky8Pg7UH5HgSt4BIQLH8SADGWRClD2FiOAa7oQEI8xU/BUhUmo7hcKwy9WIcgAAAAABAAAAAAAAAAABagAAAAA=",
+		},
+		"native segwit": {
+			address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			signature: "AAAAAAABASs1A9aiYU3q8XFsIzJcU+BRS0r8mBAcdxdSrUBnGZ23AAAAAAAAAAAAAQAAAAAAAAAAAWoCSDBFAiEA7PLKeWq33eU4omv7CabEh6ez//M/OX22og65r3fA7owCIGLmfkTIBw9Jw6N/WUCohQhC2vfMo15q9hpsfJHx4aGjASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHIAAAAA",
+		},
+		"native segwit script hash": {
+			address:   "bc1qumw0r30366ya78zvcv6tyr0ffgtqg9vm9jt6mud7slmehggpd0jqucu77z",
+			signature: "AAAAAAABAb8mAku4GJhDeznJQuTLFXVlSjQ1jg57Vc93O8N2agmNAAAAAAAAAAAAAQAAAAAAAAAAAWoCRzBEAiBa5SsHAW44ECzep3cWekdtUOtTt7F63jngCWfP5PhYIQIgBVS2lxq49HBBnHdkcOBvVlvy7Of1U4NepdkNrA59WxIBIyECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHKsAAAAAA==",
+		},
+		"taproot": {
+			address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+			signature: "AAAAAAABAQZ52yMWanylo3mYung2wzGYu6l1UmV7Eo2xCNKfbmYhAAAAAAAAAAAAAQAAAAAAAAAAAWoBQPkzDG1RE/e0CKnd9Lm9VHBrgvXnATJMgbQFDcM+S0mle9B7tKHyR/6ZVo7iV0Q/6+GJVn+xe84cRtBCeyJikKoAAAAA",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			address, err := btcutil.DecodeAddress(tt.address, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+
+			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.signature)
+			s.Require().NoError(err)
+
+			toSign, err := bip322.DecodeFull(signatureDecoded)
+			s.Require().NoError(err)
+
+			trace, err := bip322.TraceFull(address, internal.CreateMagicMessageBIP322([]byte("Hello World")), toSign)
+			s.Require().NoError(err)
+			s.Require().NoError(trace.Err)
+			s.Require().NotEmpty(trace.Steps)
+			s.Require().Same(toSign, trace.ToSign)
+			s.Require().Equal(toSign.TxIn[0].PreviousOutPoint.Hash, trace.ToSpend.TxHash())
+
+			// The recorded sighash is the hash the signature is valid for
+			input := toSign.TxIn[0]
+			switch name {
+			case "p2pkh":
+				tokenizer := txscript.MakeScriptTokenizer(0, input.SignatureScript)
+				s.Require().True(tokenizer.Next())
+				signature := tokenizer.Data()
+				s.Require().True(tokenizer.Next())
+				s.verifyECDSA(trace.SigHash, signature, tokenizer.Data())
+			case "native segwit":
+				s.verifyWitnessV0SigHash(trace)
+			case "native segwit script hash":
+				// The witness script is <public key> OP_CHECKSIG
+				s.verifyECDSA(trace.SigHash, input.Witness[0], input.Witness[1][1:34])
+			case "taproot":
+				s.Require().Equal(txscript.SigHashDefault, trace.SigHashType)
+
+				signature, err := schnorr.ParseSignature(input.Witness[0])
+				s.Require().NoError(err)
+				publicKey, err := schnorr.ParsePubKey(address.ScriptAddress())
+				s.Require().NoError(err)
+				s.Require().True(signature.Verify(trace.SigHash, publicKey))
+			}
+		})
+	}
+}
+
+func (s *TraceTestSuite) TestTraceFullIncorrect() {
+	address, err := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	signatureDecoded, err := base64.StdEncoding.DecodeString("AAAAAAABASs1A9aiYU3q8XFsIzJcU+BRS0r8mBAcdxdSrUBnGZ23AAAAAAAAAAAAAQAAAAAAAAAAAWoCSDBFAiEA7PLKeWq33eU4omv7CabEh6ez//M/OX22og65r3fA7owCIGLmfkTIBw9Jw6N/WUCohQhC2vfMo15q9hpsfJHx4aGjASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHIAAAAA")
+	s.Require().NoError(err)
+
+	toSign, err := bip322.DecodeFull(signatureDecoded)
+	s.Require().NoError(err)
+
+	// The transaction does not spend the toSpend transaction of another message
+	_, err = bip322.TraceFull(address, internal.CreateMagicMessageBIP322([]byte("Hello World - This should fail")), toSign)
+	s.Require().EqualError(err, "invalid toSign transaction format: first input does not spend the toSpend transaction")
+}
+
+// verifyWitnessV0SigHash verifies that the signature of a P2WPKH witness is valid for the recorded sighash.
+func (s *TraceTestSuite) verifyWitnessV0SigHash(trace bip322.Trace) {
+	witness := trace.ToSign.TxIn[0].Witness
+	s.verifyECDSA(trace.SigHash, witness[0], witness[1])
+}
+
+// verifyECDSA verifies that a DER signature, followed by its sighash type, is valid for the hash and public key.
+func (s *TraceTestSuite) verifyECDSA(hash []byte, signature []byte, publicKey []byte) {
+	s.Require().Len(hash, 32)
+
+	parsedSignature, err := ecdsa.ParseDERSignature(signature[:len(signature)-1])
+	s.Require().NoError(err)
+	parsedPublicKey, err := btcec.ParsePubKey(publicKey)
+	s.Require().NoError(err)
+	s.Require().True(parsedSignature.Verify(hash, parsedPublicKey))
+}
@@ -1,31 +1,79 @@
 package bip322
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"reflect"
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 )
 
+// VerifyHash will verify a BIP-322 simple signature against the address and the BIP-322 tagged hash of the signed message.
+// Only single key addresses can be verified with a simple signature, the supported address types are registered by the verifier.
+//
 // TODO: Check if we can implement more by referencing https://github.com/ACken2/bip322-js/blob/main/src/Verifier.ts#L23
 // Their implementation supports *btcutil.AddressScriptHash (but no multisig, yet).
-func Verify(address btcutil.Address, message string, signatureDecoded []byte) (bool, error) {
-	// Ensure we support the address
-	if !IsSupported(address) {
-		return false, fmt.Errorf("unsupported address type '%s'", reflect.TypeOf(address))
+func VerifyHash(address btcutil.Address, messageHash [32]byte, signatureDecoded []byte) (bool, error) {
+	toSpend, toSign, err := buildSimple(address, messageHash, signatureDecoded)
+	if err != nil {
+		return false, err
 	}
 
-	// Draft corresponding toSpend and toSign transaction using the message and script pubkey
-	toSpend, err := BuildToSpendTx([]byte(message), address)
+	// From the rules here:
+	// https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#verification-process
+	// We only need to perform verification of whether toSign spends toSpend properly
+	// given that the signature is a simple one, and we construct both toSpend and toSign
+	return execute(toSpend, toSign)
+}
+
+// VerifyFull will verify a BIP-322 full signature, which is the complete toSign transaction, against the address and the BIP-322 tagged hash of the signed message.
+// Any address that has an output script is supported, proof of funds (additional inputs) is not.
+//
+// For more details, refer: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#full
+func VerifyFull(address btcutil.Address, messageHash [32]byte, toSign *wire.MsgTx) (bool, error) {
+	toSpend, err := buildFull(address, messageHash, toSign)
 	if err != nil {
-		return false, fmt.Errorf("could not build spending transaction: %w", err)
+		return false, err
+	}
+
+	return execute(toSpend, toSign)
+}
+
+// DecodeFull decodes a BIP-322 full signature into the toSign transaction, trailing bytes are not allowed.
+// The memory that is allocated is bounded by the length of the signature, regardless of the counts it declares.
+func DecodeFull(signatureDecoded []byte) (*wire.MsgTx, error) {
+	if err := checkTransactionLayout(signatureDecoded); err != nil {
+		return nil, fmt.Errorf("could not decode transaction: %w", err)
 	}
 
+	reader := bytes.NewReader(signatureDecoded)
+
+	toSign := new(wire.MsgTx)
+	if err := toSign.Deserialize(reader); err != nil {
+		return nil, fmt.Errorf("could not decode transaction: %w", err)
+	}
+
+	if reader.Len() > 0 {
+		return nil, fmt.Errorf("could not decode transaction: %d trailing bytes", reader.Len())
+	}
+
+	return toSign, nil
+}
+
+// buildSimple drafts the toSpend and toSign transactions of a BIP-322 simple signature, the witness of toSign is the decoded signature.
+func buildSimple(address btcutil.Address, messageHash [32]byte, signatureDecoded []byte) (*wire.MsgTx, *wire.MsgTx, error) {
+	// Decode the witness first, which is cheaper than drafting the transactions
 	witness, err := SimpleSigToWitness(signatureDecoded)
 	if err != nil {
-		return false, fmt.Errorf("error converting signature into witness: %w", err)
+		return nil, nil, fmt.Errorf("error converting signature into witness: %w", err)
+	}
+
+	// Draft corresponding toSpend and toSign transaction using the message and script pubkey
+	toSpend, err := BuildToSpendTxFromHash(messageHash, address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not build spending transaction: %w", err)
 	}
 
 	toSign := BuildToSignTx(toSpend)
@@ -33,18 +81,57 @@ func Verify(address btcutil.Address, message string, signatureDecoded []byte) (b
 
 	// Validate toSign transaction
 	if len(toSign.TxIn) != 1 || len(toSign.TxOut) != 1 {
-		return false, errors.New("invalid toSign transaction format")
+		return nil, nil, errors.New("invalid toSign transaction format")
 	}
 
-	// From the rules here:
-	// https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#verification-process
-	// We only need to perform verification of whether toSign spends toSpend properly
-	// given that the signature is a simple one, and we construct both toSpend and toSign
+	return toSpend, toSign, nil
+}
+
+// buildFull drafts the toSpend transaction of a BIP-322 full signature and ensures the toSign transaction spends it.
+func buildFull(address btcutil.Address, messageHash [32]byte, toSign *wire.MsgTx) (*wire.MsgTx, error) {
+	toSpend, err := BuildToSpendTxFromHash(messageHash, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not build spending transaction: %w", err)
+	}
+
+	// The first input has to spend the toSpend transaction
+	if len(toSign.TxIn) == 0 || toSign.TxIn[0].PreviousOutPoint != (wire.OutPoint{Hash: toSpend.TxHash(), Index: 0}) {
+		return nil, errors.New("invalid toSign transaction format: first input does not spend the toSpend transaction")
+	}
+
+	// Additional inputs are used for proof of funds, which requires the UTXO set to verify
+	if len(toSign.TxIn) > 1 {
+		return nil, errors.New("invalid toSign transaction format: proof of funds is not supported")
+	}
+
+	// The only output has to be the unspendable OP_RETURN output
+	if len(toSign.TxOut) != 1 || toSign.TxOut[0].Value != toSignOutputValue || !bytes.Equal(toSign.TxOut[0].PkScript, buildSignPkScript()) {
+		return nil, errors.New("invalid toSign transaction format: output should be a single empty OP_RETURN")
+	}
+
+	return toSpend, nil
+}
+
+// newEngine creates the script engine for the first input of toSign, which spends the output of toSpend.
+// The sighashes and the fetcher of the spent output are returned as well, to compute the hash a signature commits to.
+func newEngine(toSpend *wire.MsgTx, toSign *wire.MsgTx) (*txscript.Engine, *txscript.TxSigHashes, *txscript.CannedPrevOutputFetcher, error) {
 	inputFetcher := txscript.NewCannedPrevOutputFetcher(toSpend.TxOut[0].PkScript, 0)
 	sigHashes := txscript.NewTxSigHashes(toSign, inputFetcher)
-	vm, err := txscript.NewEngine(toSpend.TxOut[0].PkScript, toSign, 0, txscript.StandardVerifyFlags, txscript.NewSigCache(0), sigHashes, toSpend.TxOut[0].Value, inputFetcher)
+
+	// Every signature is only checked once, so there is no need for a signature cache
+	vm, err := txscript.NewEngine(toSpend.TxOut[0].PkScript, toSign, 0, txscript.StandardVerifyFlags, nil, sigHashes, toSpend.TxOut[0].Value, inputFetcher)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not create new engine: %w", err)
+	}
+
+	return vm, sigHashes, inputFetcher, nil
+}
+
+// execute runs the script of the first input of toSign, which spends the output of toSpend.
+func execute(toSpend *wire.MsgTx, toSign *wire.MsgTx) (bool, error) {
+	vm, _, _, err := newEngine(toSpend, toSign)
 	if err != nil {
-		return false, fmt.Errorf("could not create new engine: %w", err)
+		return false, err
 	}
 
 	// Execute the script
@@ -56,16 +143,3 @@ func Verify(address btcutil.Address, message string, signatureDecoded []byte) (b
 	// Verification successful
 	return true, nil
 }
-
-func IsSupported(address btcutil.Address) bool {
-	switch address.(type) {
-	// P2WPKH - Native Segwit
-	case *btcutil.AddressWitnessPubKeyHash:
-		return true
-	// P2TR - Taproot
-	case *btcutil.AddressTaproot:
-		return true
-	default:
-		return false
-	}
-}
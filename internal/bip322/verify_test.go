@@ -6,10 +6,16 @@ import (
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/bitonicnl/verify-signed-message/internal"
 	"github.com/bitonicnl/verify-signed-message/internal/bip322"
 	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/conformance"
 )
 
 type VerifyTestSuite struct {
@@ -53,7 +59,7 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 				Message:   "Hello World",
 				Signature: "AkgwRQIhAMd2wZSY3x0V9Kr/NClochoTXcgDaGl3OObOR17yx3QQAiBVWxqNSS+CKen7bmJTG6YfJjsggQ4Fa2RHKgBKrdQQ+gEhAxa5UDdQCHSQHfKQv14ybcYm1C9y6b12xAuukWzSnS+w\n",
 			},
-			expectedError: "unsupported address type '*btcutil.AddressScriptHash'",
+			expectedError: "bip322-simple signatures are not supported for address type '*btcutil.AddressScriptHash'",
 		},
 		// Taken from https://github.com/ACken2/bip322-js/blob/159456f44f31f0b38097b957bbe75c0eae4971bf/test/Verifier.test.ts#L302
 		"taproot - script-spend": {
@@ -80,7 +86,7 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 				Message:   "This will be a p2sh 2-of-3 multisig BIP 322 signed message",
 				Signature: "AAAAAAHNcfHaNfl8f/+ZC2gTr8aF+0KgppYjKM94egaNm/u1ZAAAAAD8AEcwRAIhAJ6hdj61vLDP+aFa30qUZQmrbBfE0kiOObYvt5nqPSxsAh9IrOKFwflfPRUcQ/5e0REkdFHVP2GGdUsMgDet+sNlAUcwRAIgH3eW/VyFDoXvCasd8qxgwj5NDVo0weXvM6qyGXLCR5YCIEwjbEV6fS6RWP6QsKOcMwvlGr1/SgdCC6pW4eH87/YgAUxpUiECKJfGy28imLcuAeNBLHCNv3NRP5jnJwFDNRXCYNY/vJ4hAv1RQtaZs7+vKqQeWl2rb/jd/gMxkEjUnjZdDGPDZkMLIQL65cH2X5O7LujjTLDL2l8Pxy0Y2UUR99u1qCfjdz7dklOuAAAAAAEAAAAAAAAAAAFqAAAAAA==",
 			},
-			expectedError: "unsupported address type '*btcutil.AddressScriptHash'",
+			expectedError: "bip322-simple signatures are not supported for address type '*btcutil.AddressScriptHash'",
 		},
 		// Taken from https://github.com/luke-jr/bitcoin/blob/9ab7b8ada61a5f558c92c3eb9fd3cd3625d8cc09/src/test/util_tests.cpp#L1743
 		"p2wsh - 3-of-3 multisig": {
@@ -89,7 +95,7 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 				Message:   "This will be a p2wsh 3-of-3 multisig BIP 322 signed message",
 				Signature: "BQBIMEUCIQDQoXvGKLH58exuujBOta+7+GN7vi0lKwiQxzBpuNuXuAIgIE0XYQlFDOfxbegGYYzlf+tqegleAKE6SXYIa1U+uCcBRzBEAiATegywVl6GWrG9jJuPpNwtgHKyVYCX2yfuSSDRFATAaQIgTLlU6reLQsSIrQSF21z3PtUO2yAUseUWGZqRUIE7VKoBSDBFAiEAgxtpidsU0Z4u/+5RB9cyeQtoCW5NcreLJmWXZ8kXCZMCIBR1sXoEinhZE4CF9P9STGIcMvCuZjY6F5F0XTVLj9SjAWlTIQP3dyWvTZjUENWJowMWBsQrrXCUs20Gu5YF79CG5Ga0XSEDwqI5GVBOuFkFzQOGH5eTExSAj2Z/LDV/hbcvAPQdlJMhA17FuuJd+4wGuj+ZbVxEsFapTKAOwyhfw9qpch52JKxbU64=",
 			},
-			expectedError: "unsupported address type '*btcutil.AddressWitnessScriptHash'",
+			expectedError: "bip322-simple signatures are not supported for address type '*btcutil.AddressWitnessScriptHash'",
 		},
 		"Pay-to-Witness-Script-Hash - P2WSH": {
 			signedMessage: verifier.SignedMessage{
@@ -97,7 +103,7 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 				Message:   "doesn't matter",
 				Signature: "ZG9lc24ndCBtYXR0ZXI=",
 			},
-			expectedError: "unsupported address type '*btcutil.AddressWitnessScriptHash'",
+			expectedError: "bip322-simple signatures are not supported for address type '*btcutil.AddressWitnessScriptHash'",
 		},
 	}
 
@@ -111,7 +117,7 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.signedMessage.Signature)
 			s.Require().NoError(err)
 
-			valid, err := bip322.Verify(address, tt.signedMessage.Message, signatureDecoded)
+			valid, err := s.verify(address, tt.signedMessage.Message, signatureDecoded, &chaincfg.MainNetParams)
 			s.Require().EqualError(err, tt.expectedError)
 			s.Require().False(valid)
 		})
@@ -119,71 +125,171 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 }
 
 func (s *VerifyTestSuite) TestVerify() {
-	tests := map[string]verifier.SignedMessage{
-		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
-		"test vector #0": {
-			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
-			Message:   "Hello World",
-			Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
-		},
-		// BIP-322 test vector #1 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
-		"test vector #1": {
-			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
-			Message:   "",
-			Signature: "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
-		},
-		// bip-322 signature created using buidl-python library with same parameters as https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
-		"buidl-python - test vector #0": {
-			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
-			Message:   "Hello World",
-			Signature: "AkgwRQIhAOzyynlqt93lOKJr+wmmxIens//zPzl9tqIOua93wO6MAiBi5n5EyAcPScOjf1lAqIUIQtr3zKNeavYabHyR8eGhowEhAsfxIAMZZEKUPYWI4BruhAQjzFT8FSFSajuFwrDL1Yhy",
+	for _, vector := range s.validVectors(conformance.FormatBIP322Simple) {
+		s.Run(vector.Name, func() {
+			net, err := vector.Params()
+			s.Require().NoError(err)
+
+			// Decode the address
+			address, err := btcutil.DecodeAddress(vector.Address, net)
+			s.Require().NoError(err)
+
+			// Decode the signature
+			signatureDecoded, err := base64.StdEncoding.DecodeString(vector.Signature)
+			s.Require().NoError(err)
+
+			valid, err := s.verify(address, vector.Message, signatureDecoded, net)
+			s.Require().NoError(err)
+			s.Require().True(valid)
+		})
+	}
+}
+
+func (s *VerifyTestSuite) TestVerifyFull() {
+	for _, vector := range s.validVectors(conformance.FormatBIP322Full) {
+		s.Run(vector.Name, func() {
+			net, err := vector.Params()
+			s.Require().NoError(err)
+
+			address, err := btcutil.DecodeAddress(vector.Address, net)
+			s.Require().NoError(err)
+
+			toSign := s.decodeFull(vector.Signature)
+
+			valid, err := bip322.VerifyFull(address, internal.CreateMagicMessageBIP322([]byte(vector.Message)), toSign)
+			s.Require().NoError(err)
+			s.Require().True(valid)
+		})
+	}
+}
+
+func (s *VerifyTestSuite) TestVerifyFullIncorrect() {
+	signature := "AAAAAAABASs1A9aiYU3q8XFsIzJcU+BRS0r8mBAcdxdSrUBnGZ23AAAAAAAAAAAAAQAAAAAAAAAAAWoCSDBFAiEA7PLKeWq33eU4omv7CabEh6ez//M/OX22og65r3fA7owCIGLmfkTIBw9Jw6N/WUCohQhC2vfMo15q9hpsfJHx4aGjASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHIAAAAA"
+
+	tests := map[string]struct {
+		address       string
+		message       string
+		modify        func(toSign *wire.MsgTx)
+		expectedError string
+	}{
+		"wrong message": {
+			address:       "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			message:       "Hello World - This should fail",
+			modify:        func(*wire.MsgTx) {},
+			expectedError: "invalid toSign transaction format: first input does not spend the toSpend transaction",
 		},
-		// Generated via the Leather Wallet, using the same words as for unisat
-		"leather - segwit native": {
-			Address:   "bc1qvhnxd953tzt4kcqpcgk83wu2r9shf59q2t4egu",
-			Message:   "hello",
-			Signature: "AkgwRQIhAMPtK3P+dVOTFe5w9Rw2IJzjMjAXOXQUaBptg3QcT64JAiAX6TxbLPTetNJA7gKoARU/WH7Owm4YBS7ALeN+2LcBeQEhA59DAKSL/e9Zj9BEfm4DyBlGTAH9/8cYInHmMqbjz8EX",
+		"wrong address": {
+			address:       "bc1qkecg9ly2xwxqgdy9egpuy87qc9x26smpts562s",
+			message:       "Hello World",
+			modify:        func(*wire.MsgTx) {},
+			expectedError: "invalid toSign transaction format: first input does not spend the toSpend transaction",
 		},
-		// Generated via the Leather Wallet, using the same words as for unisat
-		"leather - taproot": {
-			Address:   "bc1pgc9k3vdmr9aecmwj09qg5qv550qyyrydufyfmxrsvk5474rxenuqrq4lcz",
-			Message:   "hello",
-			Signature: "AUBuPt7wX3zcAaMs7F/oGXPROspWWIvBh/GqjTQ6uPq8sUPxSIqGGaz8z4yuEoYRzXwaAeXBucxjlygiR02zvX2L",
+		"proof of funds": {
+			address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			message: "Hello World",
+			modify: func(toSign *wire.MsgTx) {
+				toSign.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}, nil, nil))
+			},
+			expectedError: "invalid toSign transaction format: proof of funds is not supported",
 		},
-		// Single key taproot bip-322 signature (created with the buidl-python library)
-		// Taken from: https://github.com/luke-jr/bitcoin/blob/9ab7b8ada61a5f558c92c3eb9fd3cd3625d8cc09/src/test/util_tests.cpp#L1754
-		"buidl-python - taproot": {
-			Address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
-			Message:   "Hello World",
-			Signature: "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==",
+		"wrong output": {
+			address:       "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			message:       "Hello World",
+			modify:        func(toSign *wire.MsgTx) { toSign.TxOut[0].Value = 1 },
+			expectedError: "invalid toSign transaction format: output should be a single empty OP_RETURN",
 		},
-		// Single key taproot bip-322 signature (created with the sparrow)
-		"sparrow - taproot": {
-			Address:   "bc1pqqeyhah6g75dwr942xv40h255q4nshqw4k8ylyhe7plej2eg3mnqz9w4np",
-			Message:   "Taproot, lets go!",
-			Signature: "AUE8tKBiwiq64JYkSbf+4byheZlmDB5xyasRJ+ujM9/h/BfHFsd4jovtmmEfSsEZTBzoOP9m7We92UEbhqb4sBf4AQ==",
+		"modified transaction": {
+			address:       "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			message:       "Hello World",
+			modify:        func(toSign *wire.MsgTx) { toSign.LockTime = 1 },
+			expectedError: "script execution failed: signature not empty on failed checksig",
 		},
-		// Single key taproot bip-322 signature (created by nullish.org)
-		"nullish.org - taproot": {
-			Address:   "bc1pkr9m9rcspdyzhtf7g2pkc2l8ww7yp0prckkvg252edk7pvusx5ts3n5e0x",
-			Message:   "nullish.org",
-			Signature: "AUHyxHye4t2wc3zE/jj+S9itMJh1+XrqR7aaHtkoKsy/d49gzAJnstbZgdMYh6Ywn+g8tG9U9oqrMNqlVdM8I8R9AQ==",
+		"segwit": {
+			address:       "3HSVzEhCFuH9Z3wvoWTexy7BMVVp3PjS6f",
+			message:       "Hello World",
+			modify:        func(*wire.MsgTx) {},
+			expectedError: "invalid toSign transaction format: first input does not spend the toSpend transaction",
 		},
 	}
 
 	for name, tt := range tests {
 		s.Run(name, func() {
-			// Decode the address
-			address, err := btcutil.DecodeAddress(tt.Address, &chaincfg.MainNetParams)
+			address, err := btcutil.DecodeAddress(tt.address, &chaincfg.MainNetParams)
 			s.Require().NoError(err)
 
-			// Decode the signature
-			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.Signature)
-			s.Require().NoError(err)
+			toSign := s.decodeFull(signature)
+			tt.modify(toSign)
 
-			valid, err := bip322.Verify(address, tt.Message, signatureDecoded)
-			s.Require().NoError(err)
-			s.Require().True(valid)
+			valid, err := bip322.VerifyFull(address, internal.CreateMagicMessageBIP322([]byte(tt.message)), toSign)
+			s.Require().EqualError(err, tt.expectedError)
+			s.Require().False(valid)
 		})
 	}
 }
+
+func (s *VerifyTestSuite) TestDecodeFull() {
+	signatureDecoded, err := base64.StdEncoding.DecodeString("AAAAAAABAQZ52yMWanylo3mYung2wzGYu6l1UmV7Eo2xCNKfbmYhAAAAAAAAAAAAAQAAAAAAAAAAAWoBQPkzDG1RE/e0CKnd9Lm9VHBrgvXnATJMgbQFDcM+S0mle9B7tKHyR/6ZVo7iV0Q/6+GJVn+xe84cRtBCeyJikKoAAAAA")
+	s.Require().NoError(err)
+
+	_, err = bip322.DecodeFull(append(signatureDecoded, 0x00))
+	s.Require().EqualError(err, "could not decode transaction: 1 trailing bytes")
+
+	_, err = bip322.DecodeFull(signatureDecoded[:10])
+	s.Require().ErrorContains(err, "could not decode transaction: ")
+
+	// Counts that can not fit in the signature are rejected before memory is allocated for them
+	_, err = bip322.DecodeFull([]byte{0x01, 0x00, 0x00, 0x00, 0xfe, 0xff, 0xff, 0xff, 0x00})
+	s.Require().EqualError(err, "could not decode transaction: too many inputs for the size of the transaction [count 16777215, remaining bytes 0]")
+
+	witnessCount := append([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01}, make([]byte, 41)...)
+	witnessCount = append(witnessCount, 0x00, 0xfe, 0xff, 0xff, 0xff, 0x00)
+	_, err = bip322.DecodeFull(witnessCount)
+	s.Require().EqualError(err, "could not decode transaction: too many witness items for the size of the transaction [count 16777215, remaining bytes 0]")
+}
+
+func (s *VerifyTestSuite) decodeFull(signature string) *wire.MsgTx {
+	signatureDecoded, err := base64.StdEncoding.DecodeString(signature)
+	s.Require().NoError(err)
+
+	toSign, err := bip322.DecodeFull(signatureDecoded)
+	s.Require().NoError(err)
+
+	return toSign
+}
+
+// validVectors returns the valid conformance vectors of the format, except the ones that are not supported.
+func (s *VerifyTestSuite) validVectors(format conformance.Format) []conformance.Vector {
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	return lo.Filter(vectors, func(vector conformance.Vector, _ int) bool {
+		return vector.Format == format && vector.Expected == conformance.OutcomeValid && !lo.Contains(conformance.LibraryUnsupported(), vector.Name)
+	})
+}
+
+func FuzzDecodeFull(f *testing.F) {
+	signatureDecoded, err := base64.StdEncoding.DecodeString("AAAAAAABAQZ52yMWanylo3mYung2wzGYu6l1UmV7Eo2xCNKfbmYhAAAAAAAAAAAAAQAAAAAAAAAAAWoBQPkzDG1RE/e0CKnd9Lm9VHBrgvXnATJMgbQFDcM+S0mle9B7tKHyR/6ZVo7iV0Q/6+GJVn+xe84cRtBCeyJikKoAAAAA")
+	require.NoError(f, err)
+
+	f.Add(signatureDecoded)
+	f.Add([]byte{0x01, 0x00, 0x00, 0x00, 0xfe, 0xff, 0xff, 0xff, 0x00})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		toSign, err := bip322.DecodeFull(data)
+		if err != nil {
+			return
+		}
+
+		// A decoded transaction never serializes to more than it was decoded from, as trailing bytes are rejected
+		require.LessOrEqual(t, toSign.SerializeSize(), len(data))
+	})
+}
+
+// verify verifies a simple signature with the default handler of the address type, which rejects simple signatures for other than single key addresses.
+func (s *VerifyTestSuite) verify(address btcutil.Address, message string, signatureDecoded []byte, net *chaincfg.Params) (bool, error) {
+	handler, found := verifier.DefaultAddressHandlers().Handler(address)
+	s.Require().True(found)
+
+	return handler.Verify(address, verifier.NewMessageDigest([]byte(message)), signatureDecoded, verifier.FormatBIP322Simple, net)
+}
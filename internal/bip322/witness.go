@@ -37,19 +37,43 @@ const (
 // - For each element of the stack
 //   - The first byte specifies how many bytes it contains
 //   - The rest are the bytes of the element
+//
+// Bytes after the last item of the witness stack are not allowed.
 func SimpleSigToWitness(sig []byte) ([][]byte, error) {
+	witnessStack, trailing, err := SimpleSigToWitnessWithTrailing(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(trailing) > 0 {
+		return nil, fmt.Errorf("%d trailing bytes after the witness stack", len(trailing))
+	}
+
+	return witnessStack, nil
+}
+
+// SimpleSigToWitnessWithTrailing converts a simple signature into a witness stack, like SimpleSigToWitness.
+// Additionally, it returns the bytes that remain after the last item of the witness stack.
+//
+// The memory that is allocated is bounded by the length of the signature, regardless of the counts it declares.
+func SimpleSigToWitnessWithTrailing(sig []byte) ([][]byte, []byte, error) {
 	// Create a buffer from the input signature.
 	buf := bytes.NewBuffer(sig)
 
 	// Read the varint encoding the number of stack items.
 	witCount, err := wire.ReadVarInt(buf, 0)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Ensure that the number of stack items is within the maximum allowed limit.
 	if witCount > maxWitnessItemsPerInput {
-		return nil, fmt.Errorf("too many witness items to fit into max message size [count %d, max %d]", witCount, maxWitnessItemsPerInput)
+		return nil, nil, fmt.Errorf("too many witness items to fit into max message size [count %d, max %d]", witCount, maxWitnessItemsPerInput)
+	}
+
+	// Every item takes at least one byte for its length, so the count can not exceed the remaining bytes.
+	if witCount > uint64(buf.Len()) {
+		return nil, nil, fmt.Errorf("too many witness items for the size of the signature [count %d, remaining bytes %d]", witCount, buf.Len())
 	}
 
 	// Read each stack item from the buffer.
@@ -57,11 +81,11 @@ func SimpleSigToWitness(sig []byte) ([][]byte, error) {
 	for j := uint64(0); j < witCount; j++ {
 		witnessStack[j], err = readScript(buf, 0, maxWitnessItemSize, "script witness item")
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	return witnessStack, nil
+	return witnessStack, buf.Bytes(), nil
 }
 
 // readScript reads a variable length byte array that represents a transaction script.
@@ -69,7 +93,7 @@ func SimpleSigToWitness(sig []byte) ([][]byte, error) {
 // This function provides protection against memory exhaustion attacks and malformed messages.
 //
 // For more information, refer: https://en.bitcoin.it/wiki/Protocol_documentation#Variable_length_integer
-func readScript(r io.Reader, pver, maxAllowed uint32, fieldName string) ([]byte, error) {
+func readScript(r *bytes.Buffer, pver, maxAllowed uint32, fieldName string) ([]byte, error) {
 	count, err := wire.ReadVarInt(r, pver)
 	if err != nil {
 		return nil, err
@@ -80,6 +104,11 @@ func readScript(r io.Reader, pver, maxAllowed uint32, fieldName string) ([]byte,
 		return nil, fmt.Errorf("%s is larger than the max allowed size [count %d, max %d]", fieldName, count, maxAllowed)
 	}
 
+	// Do not allocate more than can be read.
+	if count > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
 	// Read the byte array.
 	b := make([]byte, count)
 	_, err = io.ReadFull(r, b)
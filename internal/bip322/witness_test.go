@@ -1,10 +1,12 @@
 package bip322_test
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
 	"testing"
 
+	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bitonicnl/verify-signed-message/internal/bip322"
@@ -26,4 +28,61 @@ func TestSimpleSigToWitness(t *testing.T) {
 
 	secondWitness := hex.EncodeToString(witness[1])
 	require.Equal(t, "023b934634594f0a52674c73435bde21ee93cbe43ef16e5e8504d4eb19a62961c0", secondWitness)
+
+	// Trailing bytes are not allowed
+	_, err = bip322.SimpleSigToWitness(append(emptyBytesSig, 0xde, 0xad))
+	require.EqualError(t, err, "2 trailing bytes after the witness stack")
+}
+
+func TestSimpleSigToWitnessBounded(t *testing.T) {
+	t.Parallel()
+
+	// Counts and lengths that can not fit in the signature are rejected before memory is allocated for them
+	_, err := bip322.SimpleSigToWitness([]byte{0xfe, 0x00, 0x09, 0x3d, 0x00})
+	require.EqualError(t, err, "too many witness items for the size of the signature [count 4000000, remaining bytes 0]")
+
+	_, err = bip322.SimpleSigToWitness([]byte{0x01, 0xfe, 0x00, 0x09, 0x3d, 0x00})
+	require.EqualError(t, err, "unexpected EOF")
+}
+
+func TestSimpleSigToWitnessWithTrailing(t *testing.T) {
+	t.Parallel()
+
+	signatureEncoded := "AkcwRAIgbAFRpM0rhdBlXr7qe5eEf3XgSeausCm2XTmZVxSYpcsCIDcbR87wF9DTrvdw1czYEEzOjso52dOSaw8VrC4GgzFRASECO5NGNFlPClJnTHNDW94h7pPL5D7xbl6FBNTrGaYpYcA="
+	signatureDecoded, err := base64.StdEncoding.DecodeString(signatureEncoded)
+	require.NoError(t, err)
+
+	witness, trailing, err := bip322.SimpleSigToWitnessWithTrailing(signatureDecoded)
+	require.NoError(t, err)
+	require.Len(t, witness, 2)
+	require.Empty(t, trailing)
+
+	witness, trailing, err = bip322.SimpleSigToWitnessWithTrailing(append(signatureDecoded, 0xde, 0xad))
+	require.NoError(t, err)
+	require.Len(t, witness, 2)
+	require.Equal(t, []byte{0xde, 0xad}, trailing)
+}
+
+func FuzzSimpleSigToWitness(f *testing.F) {
+	signatureDecoded, err := base64.StdEncoding.DecodeString("AkcwRAIgbAFRpM0rhdBlXr7qe5eEf3XgSeausCm2XTmZVxSYpcsCIDcbR87wF9DTrvdw1czYEEzOjso52dOSaw8VrC4GgzFRASECO5NGNFlPClJnTHNDW94h7pPL5D7xbl6FBNTrGaYpYcA=")
+	require.NoError(f, err)
+
+	f.Add(signatureDecoded)
+	f.Add([]byte{0xfe, 0x00, 0x09, 0x3d, 0x00})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		witness, trailing, err := bip322.SimpleSigToWitnessWithTrailing(data)
+		if err != nil {
+			return
+		}
+
+		// Encoding the witness stack again results in the bytes it was decoded from
+		var buffer bytes.Buffer
+		require.NoError(t, wire.WriteVarInt(&buffer, 0, uint64(len(witness))))
+		for _, item := range witness {
+			require.NoError(t, wire.WriteVarBytes(&buffer, 0, item))
+		}
+		require.Equal(t, data[:len(data)-len(trailing)], buffer.Bytes())
+	})
 }
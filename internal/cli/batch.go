@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// errorClassRow is the error class of a row that could not be read, for example because it is not valid JSON.
+const errorClassRow verifier.ErrorClass = "row"
+
+// batchFlags are the flags of the batch subcommand.
+type batchFlags struct {
+	input        string
+	inputFormat  string
+	output       string
+	outputFormat string
+	columns      string
+	network      string
+	profile      string
+	parallelism  int
+	resume       bool
+}
+
+// batchSummary counts the results of the batch subcommand.
+type batchSummary struct {
+	rows         int
+	skipped      int
+	valid        int
+	invalid      int
+	malformed    int
+	errorClasses map[verifier.ErrorClass]int
+}
+
+// runBatch runs the batch subcommand, which verifies every row of a CSV or JSONL file.
+func runBatch(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options := batchFlags{}
+
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.input, "input", stdinPath, "read the rows from a file or from stdin with '-'")
+	flags.StringVar(&options.inputFormat, "input-format", "", "format of the input: csv, jsonl (default based on the extension of the input)")
+	flags.StringVar(&options.output, "output", "-", "write the results to a file or to stdout with '-'")
+	flags.StringVar(&options.outputFormat, "output-format", "", "format of the output: csv, jsonl (default based on the extension of the output, or the format of the input)")
+	flags.StringVar(&options.columns, "columns", "", "map the fields address, message, signature, id and network onto other columns or keys, for example 'address=from,signature=proof'")
+	flags.StringVar(&options.network, "network", "mainnet", "network of the rows without a network: "+strings.Join(networkNames(), ", "))
+	flags.StringVar(&options.profile, "profile", verifier.Permissive().Name, "profile that decides which wallet compatibilities are applied: "+strings.Join(profileNames(), ", "))
+	flags.IntVar(&options.parallelism, "parallelism", runtime.NumCPU(), "number of rows that are verified in parallel")
+	flags.BoolVar(&options.resume, "resume", false, "skip the rows that are already in the output file and append the others")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), "Usage: verify-signed-message batch [flags]\n\nVerifies every row of a CSV file with a header or a JSONL file, the results are written in the order of the input.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	summary, err := options.run(stdin, stdout)
+	if err != nil {
+		return malformed(stderr, err)
+	}
+
+	summary.write(stderr)
+
+	return summary.exitCode()
+}
+
+// run validates the flags and verifies the rows.
+func (f batchFlags) run(stdin io.Reader, stdout io.Writer) (batchSummary, error) {
+	net, err := verifier.NetworkByName(f.network)
+	if err != nil {
+		return batchSummary{}, err
+	}
+
+	profile, err := profileByName(f.profile)
+	if err != nil {
+		return batchSummary{}, err
+	}
+
+	mapping, err := parseColumnMapping(f.columns)
+	if err != nil {
+		return batchSummary{}, err
+	}
+
+	if f.parallelism < 1 {
+		return batchSummary{}, fmt.Errorf("parallelism should be at least 1, got %d", f.parallelism)
+	}
+
+	if f.inputFormat, err = batchFormat(f.inputFormat, f.input); err != nil {
+		return batchSummary{}, err
+	}
+
+	// The output has the format of its extension, or the format of the input when the extension is not known
+	if f.outputFormat == "" {
+		f.outputFormat, _ = batchFormat("", f.output)
+	}
+
+	if f.outputFormat, err = batchFormat(lo.CoalesceOrEmpty(f.outputFormat, f.inputFormat), f.output); err != nil {
+		return batchSummary{}, err
+	}
+
+	if f.resume && f.output == stdinPath {
+		return batchSummary{}, errors.New("an output file is required to resume")
+	}
+
+	input, closeInput, err := openBatchInput(f.input, stdin)
+	if err != nil {
+		return batchSummary{}, err
+	}
+	defer closeInput()
+
+	reader, err := newRowReader(f.inputFormat, input, mapping)
+	if err != nil {
+		return batchSummary{}, err
+	}
+
+	skip, output, closeOutput, err := f.openOutput(stdout)
+	if err != nil {
+		return batchSummary{}, err
+	}
+	defer closeOutput()
+
+	// The header of a CSV file is only written once, resumed output already has it
+	writer, err := newResultWriter(f.outputFormat, output, !f.resume || !hasOutput(f.output))
+	if err != nil {
+		return batchSummary{}, err
+	}
+
+	return processRows(reader, writer, skip, f.parallelism, func() *rowVerifier { return newRowVerifier(net, profile) })
+}
+
+// openOutput opens the output for writing, a resumed output file is appended to. It returns the number of the last row that was already processed.
+func (f batchFlags) openOutput(stdout io.Writer) (int, io.Writer, func(), error) {
+	if f.output == stdinPath {
+		return 0, stdout, func() {}, nil
+	}
+
+	skip := 0
+	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if f.resume {
+		var err error
+		if skip, err = prepareResume(f.output, f.outputFormat); err != nil {
+			return 0, nil, nil, err
+		}
+
+		mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(f.output, mode, 0o600)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("could not open output: %w", err)
+	}
+
+	return skip, file, func() { _ = file.Close() }, nil
+}
+
+// hasOutput returns true when the output file exists and is not empty.
+func hasOutput(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.Size() > 0
+}
+
+// batchFormat returns the format of a file, which is based on its extension when it is not passed.
+func batchFormat(format string, path string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			return batchFormatCSV, nil
+		case ".jsonl", ".ndjson":
+			return batchFormatJSONL, nil
+		default:
+			return "", fmt.Errorf("could not determine the format of '%s', pass it with -input-format", path)
+		}
+	}
+
+	if format != batchFormatCSV && format != batchFormatJSONL {
+		return "", fmt.Errorf("unknown format '%s', expected one of: %s, %s", format, batchFormatCSV, batchFormatJSONL)
+	}
+
+	return format, nil
+}
+
+// openBatchInput opens the input file, or stdin for '-'.
+func openBatchInput(path string, stdin io.Reader) (io.Reader, func(), error) {
+	if path == stdinPath {
+		return stdin, func() {}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open input: %w", err)
+	}
+
+	return file, func() { _ = file.Close() }, nil
+}
+
+// processRows verifies the rows in parallel and writes the results in the order of the input. Rows up to and including the number skip are not verified.
+// Every worker verifies with its own rowVerifier, which is created by newVerifier. The number of rows in flight is bounded, regardless of the size of the input.
+func processRows(reader rowReader, writer resultWriter, skip int, parallelism int, newVerifier func() *rowVerifier) (batchSummary, error) {
+	summary := batchSummary{rows: 0, skipped: 0, valid: 0, invalid: 0, malformed: 0, errorClasses: map[verifier.ErrorClass]int{}}
+
+	jobs := make(chan batchRow)
+	results := make(chan batchResult)
+	slots := make(chan struct{}, 2*parallelism)
+	stop := make(chan struct{})
+
+	// Read the rows, until the input ends or the results can no longer be written
+	var readErr error
+	skipped := 0
+	go func() {
+		defer close(jobs)
+
+		for sequence := 0; ; {
+			row, err := reader.next()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr = err
+				}
+
+				return
+			}
+
+			if row.number <= skip {
+				skipped++
+
+				continue
+			}
+
+			// The sequence of the rows that are verified, so the output has no gaps
+			row.sequence = sequence
+			sequence++
+
+			select {
+			case slots <- struct{}{}:
+				jobs <- row
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for range parallelism {
+		wg.Go(func() {
+			worker := newVerifier()
+			for row := range jobs {
+				results <- worker.verify(row)
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Write the results in order, the results that arrive early wait for the ones before them
+	var writeErr error
+	pending := map[int]batchResult{}
+	next := 0
+	for result := range results {
+		pending[result.sequence] = result
+
+		for result, found := pending[next]; found; result, found = pending[next] {
+			delete(pending, next)
+			next++
+			<-slots
+
+			if writeErr != nil {
+				continue
+			}
+
+			if writeErr = writer.write(result); writeErr != nil {
+				close(stop)
+
+				continue
+			}
+
+			summary.add(result)
+		}
+	}
+
+	summary.skipped = skipped
+
+	return summary, errors.Join(readErr, writeErr)
+}
+
+// rowVerifier verifies rows with a Verifier for each network, it is not safe for concurrent use.
+type rowVerifier struct {
+	net       *chaincfg.Params
+	profile   verifier.Profile
+	verifiers map[string]*verifier.Verifier
+}
+
+// newRowVerifier creates a rowVerifier, rows without a network are verified on the passed network.
+func newRowVerifier(net *chaincfg.Params, profile verifier.Profile) *rowVerifier {
+	return &rowVerifier{net: net, profile: profile, verifiers: map[string]*verifier.Verifier{}}
+}
+
+// verify verifies a row.
+func (r *rowVerifier) verify(row batchRow) batchResult {
+	result := batchResult{
+		sequence:   row.sequence,
+		Row:        row.number,
+		ID:         row.id,
+		Address:    row.signedMessage.Address,
+		Network:    lo.CoalesceOrEmpty(row.network, r.net.Name),
+		Valid:      false,
+		Format:     "",
+		ErrorClass: errorClassRow,
+		Error:      "",
+	}
+
+	if row.err != nil {
+		result.Error = row.err.Error()
+
+		return result
+	}
+
+	v, found := r.verifiers[result.Network]
+	if !found {
+		net, err := verifier.NetworkByName(result.Network)
+		if err != nil {
+			result.Error = err.Error()
+
+			return result
+		}
+
+		v = verifier.NewVerifier(net, verifier.WithProfile(r.profile))
+		r.verifiers[result.Network] = v
+	}
+
+	verified, err := v.Verify(row.signedMessage)
+	result.Valid, result.Format, result.ErrorClass = verified.Valid, verified.Format, verified.ErrorClass
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// add counts a result.
+func (s *batchSummary) add(result batchResult) {
+	s.rows++
+	s.errorClasses[result.ErrorClass]++
+
+	switch exitCode(result.ErrorClass) {
+	case ExitValid:
+		s.valid++
+	case ExitInvalid:
+		s.invalid++
+	default:
+		s.malformed++
+	}
+}
+
+// write writes the summary, the error classes are sorted by name.
+func (s batchSummary) write(w io.Writer) {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "rows: %d verified, %d skipped\n", s.rows, s.skipped)
+	fmt.Fprintf(&builder, "valid: %d\ninvalid: %d\nmalformed: %d\n", s.valid, s.invalid, s.malformed)
+
+	classes := lo.Without(slices.Sorted(maps.Keys(s.errorClasses)), verifier.ErrorClassNone)
+	if len(classes) > 0 {
+		builder.WriteString("error classes:")
+		for _, class := range classes {
+			fmt.Fprintf(&builder, " %s=%d", class, s.errorClasses[class])
+		}
+		builder.WriteString("\n")
+	}
+
+	_, _ = io.WriteString(w, builder.String())
+}
+
+// exitCode returns ExitValid when every row is valid, ExitInvalid otherwise.
+func (s batchSummary) exitCode() int {
+	if s.invalid > 0 || s.malformed > 0 {
+		return ExitInvalid
+	}
+
+	return ExitValid
+}
@@ -0,0 +1,458 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Formats of the input and output of the batch subcommand.
+const (
+	batchFormatCSV   = "csv"
+	batchFormatJSONL = "jsonl"
+)
+
+// Fields of a row of the batch subcommand, which are mapped onto the columns of a CSV file or the keys of a JSONL file.
+const (
+	fieldAddress   = "address"
+	fieldMessage   = "message"
+	fieldSignature = "signature"
+	fieldID        = "id"
+	fieldNetwork   = "network"
+)
+
+// batchRow is a row of the input of the batch subcommand.
+type batchRow struct {
+	// sequence is the position of the row in the input, starting at 0, which determines the order of the output.
+	sequence int
+	// number is the number of the row in the input, starting at 1. The header of a CSV file and empty lines of a JSONL file are not counted.
+	number int
+	// id is the optional identifier of the row, which is copied to its result.
+	id string
+	// network is the optional name of the network of the row, the network of the batch is used when it is empty.
+	network string
+	// signedMessage is the signed message to verify.
+	signedMessage verifier.SignedMessage
+	// err is set when the row could not be read, the other fields are not set.
+	err error
+}
+
+// batchResult is the result of a row of the batch subcommand.
+type batchResult struct {
+	sequence int
+
+	Row        int                 `json:"row"`
+	ID         string              `json:"id,omitempty"`
+	Address    string              `json:"address"`
+	Network    string              `json:"network"`
+	Valid      bool                `json:"valid"`
+	Format     verifier.Format     `json:"format,omitempty"`
+	ErrorClass verifier.ErrorClass `json:"errorClass"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// batchResultColumns are the columns of a CSV file with results, in the order of batchResult.record.
+func batchResultColumns() []string {
+	return []string{"row", "id", "address", "network", "valid", "format", "errorClass", "error"}
+}
+
+// record returns the result as a record of a CSV file.
+func (r batchResult) record() []string {
+	return []string{strconv.Itoa(r.Row), r.ID, r.Address, r.Network, strconv.FormatBool(r.Valid), string(r.Format), string(r.ErrorClass), r.Error}
+}
+
+// columnMapping maps the fields of a row onto the names of the columns or keys of the input.
+type columnMapping map[string]string
+
+// defaultColumnMapping returns the mapping of every field onto a column of the same name.
+func defaultColumnMapping() columnMapping {
+	return columnMapping{fieldAddress: fieldAddress, fieldMessage: fieldMessage, fieldSignature: fieldSignature, fieldID: fieldID, fieldNetwork: fieldNetwork}
+}
+
+// parseColumnMapping parses a mapping like 'address=from,signature=proof', the fields that are not passed are mapped onto a column of the same name.
+func parseColumnMapping(value string) (columnMapping, error) {
+	mapping := defaultColumnMapping()
+	if value == "" {
+		return mapping, nil
+	}
+
+	for pair := range strings.SplitSeq(value, ",") {
+		field, column, found := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+
+		switch {
+		case !found || column == "":
+			return nil, fmt.Errorf("invalid column mapping '%s', expected field=column", pair)
+		case !lo.HasKey(mapping, field):
+			return nil, fmt.Errorf("unknown field '%s' in column mapping, expected one of: %s", field, strings.Join(batchFields(), ", "))
+		}
+
+		mapping[field] = column
+	}
+
+	return mapping, nil
+}
+
+// batchFields returns the fields of a row, the first three are required.
+func batchFields() []string {
+	return []string{fieldAddress, fieldMessage, fieldSignature, fieldID, fieldNetwork}
+}
+
+// rowReader reads the rows of the input of the batch subcommand.
+type rowReader interface {
+	// next returns the next row, or io.EOF after the last row. Rows that could not be read are returned with an error set.
+	// An error is only returned when the input itself could not be read.
+	next() (batchRow, error)
+}
+
+// newRowReader returns a rowReader for the input format.
+func newRowReader(format string, r io.Reader, mapping columnMapping) (rowReader, error) {
+	if format == batchFormatJSONL {
+		return &jsonlRowReader{reader: bufio.NewReader(r), mapping: mapping, sequence: 0, number: 0}, nil
+	}
+
+	return newCSVRowReader(r, mapping)
+}
+
+// csvRowReader reads rows from a CSV file, the columns are identified by the header.
+type csvRowReader struct {
+	reader   *csv.Reader
+	columns  map[string]int
+	sequence int
+}
+
+// newCSVRowReader reads the header of the CSV file and ensures it contains the required columns.
+func newCSVRowReader(r io.Reader, mapping columnMapping) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for _, field := range batchFields() {
+		index := slices.Index(header, mapping[field])
+		switch {
+		case index >= 0:
+			columns[field] = index
+		case field == fieldAddress, field == fieldMessage, field == fieldSignature:
+			return nil, fmt.Errorf("column '%s' of field '%s' not found in header", mapping[field], field)
+		}
+	}
+
+	return &csvRowReader{reader: reader, columns: columns, sequence: 0}, nil
+}
+
+// next returns the next row of the CSV file.
+func (c *csvRowReader) next() (batchRow, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return batchRow{}, io.EOF
+	}
+
+	row := batchRow{sequence: c.sequence, number: c.sequence + 1, id: "", network: "", signedMessage: verifier.SignedMessage{}, err: nil}
+	c.sequence++
+
+	var parseError *csv.ParseError
+	switch {
+	case errors.As(err, &parseError):
+		row.err = parseError
+
+		return row, nil
+	case err != nil:
+		return batchRow{}, fmt.Errorf("could not read row %d: %w", row.number, err)
+	}
+
+	value := func(field string) string {
+		if index, found := c.columns[field]; found && index < len(record) {
+			return record[index]
+		}
+
+		return ""
+	}
+
+	// Columns that are missing from a row are an error, an empty value is not
+	if missing := lo.Filter([]string{fieldAddress, fieldMessage, fieldSignature}, func(field string, _ int) bool { return c.columns[field] >= len(record) }); len(missing) > 0 {
+		row.err = fmt.Errorf("row has %d columns, the column of field '%s' is missing", len(record), missing[0])
+
+		return row, nil
+	}
+
+	row.id, row.network = value(fieldID), value(fieldNetwork)
+	row.signedMessage = verifier.SignedMessage{Address: value(fieldAddress), Message: value(fieldMessage), Signature: value(fieldSignature)}
+
+	return row, nil
+}
+
+// jsonlRowReader reads rows from a JSONL file, where every line is a JSON object. Empty lines are skipped.
+type jsonlRowReader struct {
+	reader   *bufio.Reader
+	mapping  columnMapping
+	sequence int
+	number   int
+}
+
+// next returns the next row of the JSONL file.
+func (j *jsonlRowReader) next() (batchRow, error) {
+	for {
+		line, err := j.reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return batchRow{}, fmt.Errorf("could not read row %d: %w", j.number+1, err)
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			if errors.Is(err, io.EOF) {
+				return batchRow{}, io.EOF
+			}
+
+			continue
+		}
+
+		row := batchRow{sequence: j.sequence, number: j.number + 1, id: "", network: "", signedMessage: verifier.SignedMessage{}, err: nil}
+		j.sequence++
+		j.number++
+
+		row.err = j.decode(line, &row)
+
+		return row, nil
+	}
+}
+
+// decode decodes a line into the row, the values of the fields have to be strings except for the identifier.
+func (j *jsonlRowReader) decode(line []byte, row *batchRow) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(line, &object); err != nil {
+		return fmt.Errorf("could not decode row: %w", err)
+	}
+
+	values := map[string]string{}
+	for _, field := range batchFields() {
+		raw, found := object[j.mapping[field]]
+		if !found {
+			if field == fieldAddress || field == fieldMessage || field == fieldSignature {
+				return fmt.Errorf("key '%s' of field '%s' not found", j.mapping[field], field)
+			}
+
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// Identifiers are often numbers, which are used as is
+			if field != fieldID {
+				return fmt.Errorf("key '%s' of field '%s' is not a string", j.mapping[field], field)
+			}
+
+			value = string(raw)
+		}
+
+		values[field] = value
+	}
+
+	row.id, row.network = values[fieldID], values[fieldNetwork]
+	row.signedMessage = verifier.SignedMessage{Address: values[fieldAddress], Message: values[fieldMessage], Signature: values[fieldSignature]}
+
+	return nil
+}
+
+// resultWriter writes the results of the batch subcommand, every result is flushed so an interrupted batch can be resumed.
+type resultWriter interface {
+	write(result batchResult) error
+}
+
+// newResultWriter returns a resultWriter for the output format, the header of a CSV file is only written when requested.
+func newResultWriter(format string, w io.Writer, header bool) (resultWriter, error) {
+	if format == batchFormatJSONL {
+		return jsonlResultWriter{encoder: json.NewEncoder(w)}, nil
+	}
+
+	writer := csvResultWriter{writer: csv.NewWriter(w)}
+	if header {
+		if err := writer.writeRecord(batchResultColumns()); err != nil {
+			return nil, err
+		}
+	}
+
+	return writer, nil
+}
+
+// csvResultWriter writes results to a CSV file.
+type csvResultWriter struct {
+	writer *csv.Writer
+}
+
+// write writes the result as a record.
+func (c csvResultWriter) write(result batchResult) error {
+	return c.writeRecord(result.record())
+}
+
+// writeRecord writes and flushes a record.
+func (c csvResultWriter) writeRecord(record []string) error {
+	if err := c.writer.Write(record); err != nil {
+		return fmt.Errorf("could not write result: %w", err)
+	}
+
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return fmt.Errorf("could not write result: %w", err)
+	}
+
+	return nil
+}
+
+// jsonlResultWriter writes results to a JSONL file.
+type jsonlResultWriter struct {
+	encoder *json.Encoder
+}
+
+// write writes the result as a line.
+func (j jsonlResultWriter) write(result batchResult) error {
+	if err := j.encoder.Encode(result); err != nil {
+		return fmt.Errorf("could not write result: %w", err)
+	}
+
+	return nil
+}
+
+// prepareResume prepares the output file of an interrupted batch for appending, it returns the number of the last row that was processed.
+// The file is parsed from the start up to its last complete record, a partially written record after it is removed.
+// The header of a CSV file is not counted. It returns 0 when there is no output yet.
+func prepareResume(path string, format string) (int, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("could not read output to resume: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("could not read output to resume: %w", err)
+	}
+
+	var (
+		lastRow int
+		end     int64
+	)
+
+	if format == batchFormatJSONL {
+		lastRow, end, err = lastJSONLResult(file)
+	} else {
+		lastRow, end, err = lastCSVResult(file, info.Size())
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Remove a partially written record
+	if end != info.Size() {
+		if err := file.Truncate(end); err != nil {
+			return 0, fmt.Errorf("could not remove partial result: %w", err)
+		}
+	}
+
+	return lastRow, nil
+}
+
+// lastJSONLResult returns the row of the last complete line of a JSONL file with results and the offset of the end of that line.
+func lastJSONLResult(r io.Reader) (int, int64, error) {
+	reader := bufio.NewReader(r)
+
+	lastRow, end := 0, int64(0)
+	for {
+		line, err := reader.ReadBytes('\n')
+		switch {
+		case errors.Is(err, io.EOF):
+			// A line without a newline was cut off
+			return lastRow, end, nil
+		case err != nil:
+			return 0, 0, fmt.Errorf("could not read output to resume: %w", err)
+		}
+
+		var result batchResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return 0, 0, fmt.Errorf("could not read output to resume: %w", err)
+		}
+
+		lastRow, end = result.Row, end+int64(len(line))
+	}
+}
+
+// lastCSVResult returns the row of the last complete record of a CSV file with results of the passed size and the offset of the end of that record.
+// Only the last record can be cut off, it either has a quoted field that is not closed or is not followed by a newline.
+func lastCSVResult(r io.ReadSeeker, size int64) (int, int64, error) {
+	if size == 0 {
+		return 0, 0, nil
+	}
+
+	// The last record is followed by a newline when the file ends with one
+	last := make([]byte, 1)
+	if _, err := r.Seek(size-1, io.SeekStart); err != nil {
+		return 0, 0, fmt.Errorf("could not read output to resume: %w", err)
+	}
+
+	if _, err := io.ReadFull(r, last); err != nil {
+		return 0, 0, fmt.Errorf("could not read output to resume: %w", err)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, fmt.Errorf("could not read output to resume: %w", err)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	lastRow, end := 0, int64(0)
+	for header := true; ; header = false {
+		record, err := reader.Read()
+		atEnd := reader.InputOffset() == size
+
+		var parseError *csv.ParseError
+		switch {
+		case errors.Is(err, io.EOF), atEnd && errors.As(err, &parseError), atEnd && err == nil && last[0] != '\n':
+			return lastRow, end, nil
+		case err != nil:
+			return 0, 0, fmt.Errorf("could not read output to resume: %w", err)
+		}
+
+		row, ok := resultRow(record, header)
+		if !ok {
+			line, _ := reader.FieldPos(0)
+
+			return 0, 0, fmt.Errorf("could not read output to resume: line %d is not a result", line)
+		}
+
+		lastRow, end = row, reader.InputOffset()
+	}
+}
+
+// resultRow returns the row of a record of a CSV file with results, the header has row 0 and is only allowed when header is true.
+func resultRow(record []string, header bool) (int, bool) {
+	if header && slices.Equal(record, batchResultColumns()) {
+		return 0, true
+	}
+
+	if len(record) != len(batchResultColumns()) {
+		return 0, false
+	}
+
+	row, err := strconv.Atoi(record[0])
+
+	return row, err == nil
+}
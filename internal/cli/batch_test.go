@@ -0,0 +1,374 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/cli"
+	"github.com/bitonicnl/verify-signed-message/pkg/verifiertest"
+)
+
+type BatchTestSuite struct {
+	suite.Suite
+
+	legacy verifiertest.Fixture
+	bip322 verifiertest.Fixture
+}
+
+func TestBatchTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(BatchTestSuite))
+}
+
+func (s *BatchTestSuite) SetupTest() {
+	var err error
+
+	generator := verifiertest.NewGenerator([]byte("cli"))
+
+	s.legacy, err = generator.Sign(&chaincfg.MainNetParams, verifiertest.AddressTypeP2PKH, verifiertest.SchemeLegacy, "Hello World")
+	s.Require().NoError(err)
+
+	s.bip322, err = generator.Sign(&chaincfg.TestNet3Params, verifiertest.AddressTypeP2WPKH, verifiertest.SchemeBIP322Simple, "Hello World")
+	s.Require().NoError(err)
+}
+
+// run runs the batch subcommand and returns the exit code, stdout and stderr.
+func (s *BatchTestSuite) run(stdin string, args ...string) (int, string, string) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := cli.Run(append([]string{"batch"}, args...), strings.NewReader(stdin), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func (s *BatchTestSuite) TestBatchCSV() {
+	input := strings.Join([]string{
+		"id,address,message,signature,network",
+		"a," + s.legacy.SignedMessage.Address + ",Hello World," + s.legacy.SignedMessage.Signature + ",",
+		"b," + s.bip322.SignedMessage.Address + ",Hello World," + s.bip322.SignedMessage.Signature + ",testnet3",
+		"c," + s.legacy.SignedMessage.Address + ",Hello World!," + s.legacy.SignedMessage.Signature + ",",
+		"d," + s.legacy.SignedMessage.Address,
+		"",
+	}, "\n")
+
+	code, stdout, stderr := s.run(input, "-input-format", "csv")
+	s.Require().Equal(cli.ExitInvalid, code, stderr)
+	s.Require().Equal("rows: 4 verified, 0 skipped\nvalid: 2\ninvalid: 1\nmalformed: 1\nerror classes: invalid=1 row=1\n", stderr)
+
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	s.Require().NoError(err)
+	s.Require().Len(records, 5)
+
+	// The error of an invalid signature contains the generated address
+	s.Require().True(strings.HasPrefix(records[3][7], "generated address '"), records[3][7])
+	records[3][7] = ""
+
+	s.Require().Equal([][]string{
+		{"row", "id", "address", "network", "valid", "format", "errorClass", "error"},
+		{"1", "a", s.legacy.SignedMessage.Address, "mainnet", "true", "legacy", "none", ""},
+		{"2", "b", s.bip322.SignedMessage.Address, "testnet3", "true", "bip322-simple", "none", ""},
+		{"3", "c", s.legacy.SignedMessage.Address, "mainnet", "false", "legacy", "invalid", ""},
+		{"4", "", "", "mainnet", "false", "", "row", "row has 2 columns, the column of field 'message' is missing"},
+	}, records)
+}
+
+func (s *BatchTestSuite) TestBatchJSONL() {
+	input := strings.Join([]string{
+		`{"ref": 1, "from": "` + s.bip322.SignedMessage.Address + `", "message": "Hello World", "proof": "` + s.bip322.SignedMessage.Signature + `"}`,
+		`{"ref": "b", "from": "` + s.legacy.SignedMessage.Address + `", "message": "Hello World", "proof": "` + s.legacy.SignedMessage.Signature + `", "network": "mainnet"}`,
+		``,
+		`{"from": 1, "message": "Hello World", "proof": ""}`,
+		`not json`,
+	}, "\n")
+
+	code, stdout, stderr := s.run(input, "-input-format", "jsonl", "-network", "testnet3", "-columns", "address=from, signature=proof, id=ref")
+	s.Require().Equal(cli.ExitInvalid, code, stderr)
+	s.Require().Equal("rows: 4 verified, 0 skipped\nvalid: 2\ninvalid: 0\nmalformed: 2\nerror classes: row=2\n", stderr)
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	s.Require().Len(lines, 4)
+	s.Require().JSONEq(`{"row": 1, "id": "1", "address": "`+s.bip322.SignedMessage.Address+`", "network": "testnet3", "valid": true, "format": "bip322-simple", "errorClass": "none"}`, lines[0])
+	s.Require().JSONEq(`{"row": 2, "id": "b", "address": "`+s.legacy.SignedMessage.Address+`", "network": "mainnet", "valid": true, "format": "legacy", "errorClass": "none"}`, lines[1])
+	s.Require().JSONEq(`{"row": 3, "address": "", "network": "testnet3", "valid": false, "errorClass": "row", "error": "key 'from' of field 'address' is not a string"}`, lines[2])
+	s.Require().JSONEq(`{"row": 4, "address": "", "network": "testnet3", "valid": false, "errorClass": "row", "error": "could not decode row: invalid character 'o' in literal null (expecting 'u')"}`, lines[3])
+}
+
+func (s *BatchTestSuite) TestBatchOrder() {
+	input := bytes.Buffer{}
+	for i := range 100 {
+		message := "Hello World"
+		if i%2 == 1 {
+			message = "Hello World!"
+		}
+
+		fmt.Fprintf(&input, `{"id": "%d", "address": "%s", "message": "%s", "signature": "%s"}`+"\n", i, s.legacy.SignedMessage.Address, message, s.legacy.SignedMessage.Signature)
+	}
+
+	code, stdout, stderr := s.run(input.String(), "-input-format", "jsonl", "-parallelism", "8")
+	s.Require().Equal(cli.ExitInvalid, code, stderr)
+	s.Require().Equal("rows: 100 verified, 0 skipped\nvalid: 50\ninvalid: 50\nmalformed: 0\nerror classes: invalid=50\n", stderr)
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	s.Require().Len(lines, 100)
+
+	for i, line := range lines {
+		var result struct {
+			Row   int    `json:"row"`
+			ID    string `json:"id"`
+			Valid bool   `json:"valid"`
+		}
+		s.Require().NoError(json.Unmarshal([]byte(line), &result))
+		s.Require().Equal(i+1, result.Row)
+		s.Require().Equal(fmt.Sprint(i), result.ID)
+		s.Require().Equal(i%2 == 0, result.Valid)
+	}
+}
+
+func (s *BatchTestSuite) TestBatchResume() {
+	tests := map[string]struct {
+		extension string
+		partial   func(output string) string
+	}{
+		"csv - partial row": {
+			extension: ".csv",
+			partial: func(output string) string {
+				lines := strings.SplitAfter(output, "\n")
+
+				return lines[0] + lines[1] + lines[2][:10]
+			},
+		},
+		"csv - partial quoted field with a newline": {
+			extension: ".csv",
+			partial: func(output string) string {
+				lines := strings.SplitAfter(output, "\n")
+
+				return lines[0] + lines[1] + "2,b,address,mainnet,false,,invalid,\"could not\nverify"
+			},
+		},
+		"csv - partial quoted field with many newlines": {
+			extension: ".csv",
+			partial: func(output string) string {
+				lines := strings.SplitAfter(output, "\n")
+
+				return lines[0] + lines[1] + "2,b,address,mainnet,false,,invalid,\"" + strings.Repeat("could not verify\n", 300)
+			},
+		},
+		"csv - header only": {
+			extension: ".csv",
+			partial: func(output string) string {
+				return strings.SplitAfter(output, "\n")[0]
+			},
+		},
+		"csv - no output": {
+			extension: ".csv",
+			partial: func(_ string) string {
+				return ""
+			},
+		},
+		"jsonl - long partial row": {
+			extension: ".jsonl",
+			partial: func(output string) string {
+				lines := strings.SplitAfter(output, "\n")
+
+				return lines[0] + lines[1] + `{"row": 2, "error": "` + strings.Repeat("x", 5000)
+			},
+		},
+		"jsonl - partial row": {
+			extension: ".jsonl",
+			partial: func(output string) string {
+				lines := strings.SplitAfter(output, "\n")
+
+				return lines[0] + lines[1] + lines[2][:10]
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			dir := s.T().TempDir()
+			input, output := filepath.Join(dir, "input"+tt.extension), filepath.Join(dir, "output"+tt.extension)
+
+			rows := "id,address,message,signature\n"
+			for _, id := range []string{"a", "b", "c", "d"} {
+				rows += id + "," + s.legacy.SignedMessage.Address + ",Hello World," + s.legacy.SignedMessage.Signature + "\n"
+			}
+			s.Require().NoError(os.WriteFile(input, []byte(rows), 0o600))
+
+			code, _, stderr := s.run("", "-input", input, "-input-format", "csv", "-output", output)
+			s.Require().Equal(cli.ExitValid, code, stderr)
+			s.Require().Equal("rows: 4 verified, 0 skipped\nvalid: 4\ninvalid: 0\nmalformed: 0\n", stderr)
+
+			complete, err := os.ReadFile(output)
+			s.Require().NoError(err)
+
+			// Interrupt the batch after the first row
+			partial := tt.partial(string(complete))
+			if partial == "" {
+				s.Require().NoError(os.Remove(output))
+			} else {
+				s.Require().NoError(os.WriteFile(output, []byte(partial), 0o600))
+			}
+
+			code, _, stderr = s.run("", "-input", input, "-input-format", "csv", "-output", output, "-resume")
+			s.Require().Equal(cli.ExitValid, code, stderr)
+
+			resumed, err := os.ReadFile(output)
+			s.Require().NoError(err)
+			s.Require().Equal(string(complete), string(resumed))
+		})
+	}
+}
+
+func (s *BatchTestSuite) TestBatchResumeMultilineAddress() {
+	dir := s.T().TempDir()
+	input, output := filepath.Join(dir, "input.csv"), filepath.Join(dir, "output.csv")
+
+	// The quoted address of the second row has lines that look like results
+	row := "," + s.legacy.SignedMessage.Address + ",Hello World," + s.legacy.SignedMessage.Signature + "\n"
+	rows := "id,address,message,signature\na" + row + "b,\"x\n99,,,,,,,\n1\",Hello World,signature\nc" + row + "d" + row
+	s.Require().NoError(os.WriteFile(input, []byte(rows), 0o600))
+
+	code, _, stderr := s.run("", "-input", input, "-output", output)
+	s.Require().Equal(cli.ExitInvalid, code, stderr)
+
+	complete, err := os.ReadFile(output)
+	s.Require().NoError(err)
+
+	// Interrupt the batch at every byte of the output
+	for end := range len(complete) {
+		s.Require().NoError(os.WriteFile(output, complete[:end], 0o600))
+
+		code, _, stderr = s.run("", "-input", input, "-output", output, "-resume")
+		s.Require().NotEqual(cli.ExitMalformed, code, stderr)
+
+		resumed, err := os.ReadFile(output)
+		s.Require().NoError(err)
+		s.Require().Equal(string(complete), string(resumed), "interrupted at byte %d", end)
+	}
+}
+
+func (s *BatchTestSuite) TestBatchResumeSkipped() {
+	dir := s.T().TempDir()
+	input, output := filepath.Join(dir, "input.jsonl"), filepath.Join(dir, "output.csv")
+
+	line := `{"address": "` + s.legacy.SignedMessage.Address + `", "message": "Hello World", "signature": "` + s.legacy.SignedMessage.Signature + `"}` + "\n"
+	s.Require().NoError(os.WriteFile(input, []byte(line+"not json\n"+line), 0o600))
+	s.Require().NoError(os.WriteFile(output, []byte("row,id,address,network,valid,format,errorClass,error\n1,,"+s.legacy.SignedMessage.Address+",mainnet,true,legacy,none,\n"), 0o600))
+
+	code, _, stderr := s.run("", "-input", input, "-output", output, "-resume")
+	s.Require().Equal(cli.ExitInvalid, code, stderr)
+	s.Require().Equal("rows: 2 verified, 1 skipped\nvalid: 1\ninvalid: 0\nmalformed: 1\nerror classes: row=1\n", stderr)
+
+	resumed, err := os.ReadFile(output)
+	s.Require().NoError(err)
+
+	records, err := csv.NewReader(bytes.NewReader(resumed)).ReadAll()
+	s.Require().NoError(err)
+	s.Require().Len(records, 4)
+	s.Require().Equal([]string{"2", "3"}, []string{records[2][0], records[3][0]})
+}
+
+func (s *BatchTestSuite) TestBatchErrors() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "rows.txt"), []byte("address,message,signature\n"), 0o600))
+
+	tests := map[string]struct {
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStderr string
+	}{
+		"unknown format": {
+			args:           []string{"-input-format", "xml"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown format 'xml', expected one of: csv, jsonl\n",
+		},
+		"unknown extension": {
+			args:           []string{"-input", filepath.Join(dir, "rows.txt")},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not determine the format of '" + filepath.Join(dir, "rows.txt") + "', pass it with -input-format\n",
+		},
+		"missing input": {
+			args:           []string{"-input", filepath.Join(dir, "missing.csv")},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not open input: open ",
+		},
+		"missing column": {
+			args:           []string{"-input-format", "csv", "-columns", "signature=proof"},
+			stdin:          "address,message,signature\n",
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: column 'proof' of field 'signature' not found in header\n",
+		},
+		"missing header": {
+			args:           []string{"-input-format", "csv"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not read header: EOF\n",
+		},
+		"unknown field": {
+			args:           []string{"-input-format", "csv", "-columns", "key=proof"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown field 'key' in column mapping, expected one of: address, message, signature, id, network\n",
+		},
+		"invalid mapping": {
+			args:           []string{"-input-format", "csv", "-columns", "signature"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: invalid column mapping 'signature', expected field=column\n",
+		},
+		"resume - not an output": {
+			args:           []string{"-input-format", "csv", "-output", filepath.Join(dir, "rows.txt"), "-output-format", "csv", "-resume"},
+			stdin:          "address,message,signature\n",
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not read output to resume: line 1 is not a result\n",
+		},
+		"resume to stdout": {
+			args:           []string{"-input-format", "csv", "-resume"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: an output file is required to resume\n",
+		},
+		"no parallelism": {
+			args:           []string{"-input-format", "csv", "-parallelism", "0"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: parallelism should be at least 1, got 0\n",
+		},
+		"unknown network": {
+			args:           []string{"-network", "unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown network 'unknown', expected one of: mainnet, testnet3, testnet4, signet, regtest, simnet\n",
+		},
+		"unknown profile": {
+			args:           []string{"-profile", "unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown profile 'unknown', expected one of: permissive, strict-bip322, bitcoin-core, electrum-compatible\n",
+		},
+		"positional arguments": {
+			args:           []string{"rows.csv"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "unexpected arguments: rows.csv\n",
+		},
+		"help": {
+			args:           []string{"-h"},
+			expectedCode:   cli.ExitValid,
+			expectedStderr: "Usage: verify-signed-message batch [flags]\n",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			code, stdout, stderr := s.run(tt.stdin, tt.args...)
+
+			s.Require().Equal(tt.expectedCode, code, stderr)
+			s.Require().Empty(stdout)
+			s.Require().True(strings.HasPrefix(stderr, tt.expectedStderr), stderr)
+		})
+	}
+}
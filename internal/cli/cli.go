@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Exit codes of the command-line tool.
+const (
+	// ExitValid is returned when the signature is valid, or when the usage is requested.
+	ExitValid = 0
+	// ExitInvalid is returned when the signature is not valid for the address and message.
+	ExitInvalid = 1
+	// ExitMalformed is returned when the input could not be read or decoded, which includes unknown commands and flags.
+	ExitMalformed = 2
+)
+
+// Output formats of the subcommands.
+const (
+	outputHuman = "human"
+	outputJSON  = "json"
+)
+
+// usage describes the subcommands, the flags of a subcommand are described by `<command> -h`.
+const usage = `Usage: verify-signed-message <command> [flags]
+
+Commands:
+  verify    Verify a signed message
+  batch     Verify every signed message in a CSV or JSONL file
+  sign      Sign a message with a private key
+  help      Show this help
+
+Run 'verify-signed-message <command> -h' for the flags of a command.
+
+Exit codes:
+  0  the signature is valid
+  1  the signature is not valid for the address and message
+  2  the input is malformed, for example an unknown address or signature encoding
+`
+
+// Run runs the subcommand in the arguments, which do not include the name of the program, and returns the exit code.
+func Run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+
+		return ExitMalformed
+	}
+
+	switch args[0] {
+	case "verify":
+		return runVerify(args[1:], stdin, stdout, stderr)
+	case "batch":
+		return runBatch(args[1:], stdin, stdout, stderr)
+	case "sign":
+		return runSign(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		_, _ = fmt.Fprint(stdout, usage)
+
+		return ExitValid
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command '%s'\n\n%s", args[0], usage)
+
+		return ExitMalformed
+	}
+}
+
+// parseFlags parses the flags of a subcommand, positional arguments are not allowed.
+// It returns the exit code to stop with when the flags could not be parsed or the usage was requested.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitValid, false
+		}
+
+		return ExitMalformed, false
+	}
+
+	if flags.NArg() > 0 {
+		_, _ = fmt.Fprintf(flags.Output(), "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+
+		return ExitMalformed, false
+	}
+
+	return 0, true
+}
+
+// networkNames returns the names of the networks that can be passed by name.
+func networkNames() []string {
+	return lo.Map(verifier.Networks(), func(net *chaincfg.Params, _ int) string { return net.Name })
+}
+
+// profiles returns the profiles that can be passed by name.
+func profiles() []verifier.Profile {
+	return []verifier.Profile{verifier.Permissive(), verifier.StrictBIP322(), verifier.BitcoinCore(), verifier.ElectrumCompatible()}
+}
+
+// profileByName returns the profile with the passed name, for example 'permissive' or 'strict-bip322'.
+func profileByName(name string) (verifier.Profile, error) {
+	profile, found := lo.Find(profiles(), func(profile verifier.Profile) bool { return profile.Name == name })
+	if !found {
+		return verifier.Profile{}, fmt.Errorf("unknown profile '%s', expected one of: %s", name, strings.Join(profileNames(), ", "))
+	}
+
+	return profile, nil
+}
+
+// profileNames returns the names of the profiles.
+func profileNames() []string {
+	return lo.Map(profiles(), func(profile verifier.Profile, _ int) string { return profile.Name })
+}
+
+// checkOutput ensures the output format is known.
+func checkOutput(output string) error {
+	if output != outputHuman && output != outputJSON {
+		return fmt.Errorf("unknown output '%s', expected one of: %s, %s", output, outputHuman, outputJSON)
+	}
+
+	return nil
+}
+
+// exitCode returns the exit code of a verification with the error class.
+// Signatures that could be decoded but are not valid, or not allowed by the profile, are invalid. Anything that could not be decoded is malformed.
+func exitCode(errorClass verifier.ErrorClass) int {
+	switch errorClass {
+	case verifier.ErrorClassNone:
+		return ExitValid
+	case verifier.ErrorClassInvalid, verifier.ErrorClassFormat:
+		return ExitInvalid
+	default:
+		return ExitMalformed
+	}
+}
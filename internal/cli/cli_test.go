@@ -0,0 +1,63 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/cli"
+)
+
+type CLITestSuite struct {
+	suite.Suite
+}
+
+func TestCLITestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(CLITestSuite))
+}
+
+func (s *CLITestSuite) TestRun() {
+	tests := map[string]struct {
+		args           []string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		"no command": {
+			args:           []string{},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "Usage: verify-signed-message <command> [flags]\n",
+		},
+		"unknown command": {
+			args:           []string{"unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "unknown command 'unknown'\n\nUsage: verify-signed-message <command> [flags]\n",
+		},
+		"help": {
+			args:           []string{"help"},
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "Usage: verify-signed-message <command> [flags]\n",
+		},
+		"help flag": {
+			args:           []string{"--help"},
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "Usage: verify-signed-message <command> [flags]\n",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			s.Require().Equal(tt.expectedCode, code)
+			s.Require().True(strings.HasPrefix(stdout.String(), tt.expectedStdout), stdout.String())
+			s.Require().True(strings.HasPrefix(stderr.String(), tt.expectedStderr), stderr.String())
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package cli implements the subcommands of the verify-signed-message command-line tool, see cmd/verify-signed-message.
+//
+// The subcommands read from and write to the passed streams and return an exit code, so they can be tested without running a process.
+package cli
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+
+	"github.com/bitonicnl/verify-signed-message/internal"
+	"github.com/bitonicnl/verify-signed-message/internal/signer"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Formats of the signatures of the sign subcommand.
+const (
+	// signFormatLegacy is a legacy signature, segwit addresses are signed like Electrum does.
+	signFormatLegacy = "legacy"
+	// signFormatTrezor is a BIP-137 signature, which has a recovery flag for the type of segwit address.
+	signFormatTrezor = "trezor"
+	// signFormatBIP322Simple is a BIP-322 simple signature.
+	signFormatBIP322Simple = "bip322-simple"
+	// signFormatBIP322Full is a BIP-322 full signature.
+	signFormatBIP322Full = "bip322-full"
+)
+
+// signFlags are the flags of the sign subcommand.
+type signFlags struct {
+	key         string
+	keyFile     string
+	insecureKey bool
+	addressType string
+	format      string
+	message     string
+	messageFile string
+	network     string
+	armored     bool
+}
+
+// runSign runs the sign subcommand, which signs a message with a private key and verifies the signature.
+func runSign(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options := signFlags{}
+
+	flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.keyFile, "key-file", "", "read the WIF private key or descriptor from a file or from stdin with '-', this is the default without -key")
+	flags.StringVar(&options.key, "key", "", "WIF private key or descriptor, only allowed with -insecure-key since arguments are visible to other processes and kept in the shell history")
+	flags.BoolVar(&options.insecureKey, "insecure-key", false, "allow the private key to be passed with -key")
+	flags.StringVar(&options.addressType, "address-type", "", "type of the address to sign for: "+strings.Join(lo.Map(addressTypes(), func(addressType signer.AddressType, _ int) string { return string(addressType) }), ", ")+" (default the type of the descriptor)")
+	flags.StringVar(&options.format, "format", signFormatLegacy, "format of the signature: "+strings.Join(signFormats(), ", "))
+	flags.StringVar(&options.message, "message", "", "message to sign")
+	flags.StringVar(&options.messageFile, "message-file", "", "read the message from a file, as is, or from stdin with '-'")
+	flags.StringVar(&options.network, "network", "mainnet", "network of the address: "+strings.Join(networkNames(), ", "))
+	flags.BoolVar(&options.armored, "armored", false, "print an armored signed message block instead of the signature")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), "Usage: verify-signed-message sign [flags]\n\nSigns a message with a WIF private key or a descriptor of one, the signature is verified before it is printed.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	signedMessage, err := options.sign(stdin)
+	if err != nil {
+		return malformed(stderr, err)
+	}
+
+	output := signedMessage.Signature + "\n"
+	if options.armored {
+		output = verifier.FormatArmored(signedMessage)
+	}
+
+	if _, err := io.WriteString(stdout, output); err != nil {
+		return malformed(stderr, err)
+	}
+
+	return ExitValid
+}
+
+// sign validates the flags, signs the message and verifies the signature.
+func (f signFlags) sign(stdin io.Reader) (verifier.SignedMessage, error) {
+	net, err := verifier.NetworkByName(f.network)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	if !lo.Contains(signFormats(), f.format) {
+		return verifier.SignedMessage{}, fmt.Errorf("unknown format '%s', expected one of: %s", f.format, strings.Join(signFormats(), ", "))
+	}
+
+	// Arguments are visible to other processes and kept in the shell history
+	if f.key != "" && !f.insecureKey {
+		return verifier.SignedMessage{}, errors.New("passing the key with -key is insecure, read it from a file or stdin with -key-file or pass -insecure-key")
+	}
+
+	if f.key == "" && f.keyFile == "" {
+		f.keyFile = stdinPath
+	}
+
+	if f.keyFile == stdinPath && f.messageFile == stdinPath {
+		return verifier.SignedMessage{}, errors.New("only one input can be read from stdin")
+	}
+
+	key, err := inputOrFile("key", f.key, f.keyFile, stdin)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	message, err := inputOrFile("message", f.message, f.messageFile, stdin)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	wif, addressType, err := signingKey(key, signer.AddressType(f.addressType), net)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	address, err := signer.Address(wif.PrivKey.PubKey(), addressType, net)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	signature, err := signMessage(wif, addressType, f.format, message, net)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	signedMessage := verifier.SignedMessage{Address: address.EncodeAddress(), Message: message, Signature: base64.StdEncoding.EncodeToString(signature)}
+
+	// Never return a signature that does not verify
+	result, err := verifier.NewVerifier(net).Verify(signedMessage)
+	if err != nil {
+		return verifier.SignedMessage{}, fmt.Errorf("could not verify the signature: %w", err)
+	}
+
+	if !result.Valid {
+		return verifier.SignedMessage{}, errors.New("could not verify the signature")
+	}
+
+	return signedMessage, nil
+}
+
+// addressTypes returns the address types that can be signed for.
+func addressTypes() []signer.AddressType {
+	return []signer.AddressType{
+		signer.AddressTypeP2PKHUncompressed,
+		signer.AddressTypeP2PKH,
+		signer.AddressTypeP2SHP2WPKH,
+		signer.AddressTypeP2WPKH,
+		signer.AddressTypeP2WSH,
+		signer.AddressTypeP2TR,
+	}
+}
+
+// signFormats returns the formats of the signatures that can be created.
+func signFormats() []string {
+	return []string{signFormatLegacy, signFormatTrezor, signFormatBIP322Simple, signFormatBIP322Full}
+}
+
+// signingKey parses a WIF private key or a descriptor of one, and returns it with the type of the address to sign for.
+// The address type is taken from a descriptor, it is required for a WIF private key. The error never contains the key.
+func signingKey(key string, addressType signer.AddressType, net *chaincfg.Params) (*btcutil.WIF, signer.AddressType, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, "", errors.New("the key is required")
+	}
+
+	var (
+		wif *btcutil.WIF
+		err error
+	)
+
+	if strings.Contains(key, "(") {
+		var descriptorType signer.AddressType
+		if wif, descriptorType, err = signer.ParseDescriptor(key); err != nil {
+			return nil, "", fmt.Errorf("could not parse descriptor: %w", err)
+		}
+
+		if addressType != "" && addressType != descriptorType {
+			return nil, "", fmt.Errorf("address type '%s' does not match the descriptor, which has address type '%s'", addressType, descriptorType)
+		}
+
+		addressType = descriptorType
+	} else {
+		if wif, err = btcutil.DecodeWIF(key); err != nil {
+			return nil, "", errors.New("could not decode the key, expected a WIF private key or a descriptor")
+		}
+
+		if addressType == "" {
+			return nil, "", errors.New("the address type is required for a WIF private key")
+		}
+	}
+
+	if !lo.Contains(addressTypes(), addressType) {
+		return nil, "", fmt.Errorf("unknown address type '%s'", addressType)
+	}
+
+	// The key determines whether the public key of a P2PKH address is compressed
+	if wif.CompressPubKey == (addressType == signer.AddressTypeP2PKHUncompressed) {
+		return nil, "", fmt.Errorf("the key is %s, which does not match address type '%s'", lo.Ternary(wif.CompressPubKey, "compressed", "uncompressed"), addressType)
+	}
+
+	if !wif.IsForNet(net) {
+		return nil, "", fmt.Errorf("the key is not for network '%s'", net.Name)
+	}
+
+	return wif, addressType, nil
+}
+
+// signMessage signs the message in the format, the signature is not encoded.
+func signMessage(wif *btcutil.WIF, addressType signer.AddressType, format string, message string, net *chaincfg.Params) ([]byte, error) {
+	switch format {
+	case signFormatBIP322Simple:
+		return signer.Simple(wif.PrivKey, addressType, internal.CreateMagicMessageBIP322([]byte(message)), net)
+	case signFormatBIP322Full:
+		toSign, err := signer.Full(wif.PrivKey, addressType, internal.CreateMagicMessageBIP322([]byte(message)), net)
+		if err != nil {
+			return nil, err
+		}
+
+		return signer.EncodeFull(toSign)
+	}
+
+	family, supported := signer.LegacyFamily(addressType, format == signFormatTrezor)
+	if !supported {
+		return nil, fmt.Errorf("format '%s' does not support address type '%s'", format, addressType)
+	}
+
+	return signer.Legacy(wif.PrivKey, internal.HashMagicMessage([]byte(message)), wif.CompressPubKey, family.BaseFlag()), nil
+}
@@ -0,0 +1,255 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/cli"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type SignTestSuite struct {
+	suite.Suite
+
+	key             string
+	testnetKey      string
+	uncompressedKey string
+}
+
+func TestSignTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(SignTestSuite))
+}
+
+func (s *SignTestSuite) SetupTest() {
+	// Taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+	s.key = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+
+	wif, err := btcutil.DecodeWIF(s.key)
+	s.Require().NoError(err)
+
+	testnet, err := btcutil.NewWIF(wif.PrivKey, &chaincfg.TestNet3Params, true)
+	s.Require().NoError(err)
+
+	uncompressed, err := btcutil.NewWIF(wif.PrivKey, &chaincfg.MainNetParams, false)
+	s.Require().NoError(err)
+
+	s.testnetKey, s.uncompressedKey = testnet.String(), uncompressed.String()
+}
+
+// run runs the sign subcommand and returns the exit code, stdout and stderr.
+func (s *SignTestSuite) run(stdin string, args ...string) (int, string, string) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := cli.Run(append([]string{"sign"}, args...), strings.NewReader(stdin), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func (s *SignTestSuite) TestSign() {
+	// Taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+	code, stdout, stderr := s.run(s.key+"\n", "-address-type", "p2wpkh", "-format", "bip322-simple", "-message", "Hello World")
+	s.Require().Equal(cli.ExitValid, code, stderr)
+	s.Require().Equal("AkgwRQIhAOzyynlqt93lOKJr+wmmxIens//zPzl9tqIOua93wO6MAiBi5n5EyAcPScOjf1lAqIUIQtr3zKNeavYabHyR8eGhowEhAsfxIAMZZEKUPYWI4BruhAQjzFT8FSFSajuFwrDL1Yhy\n", stdout)
+	s.Require().Empty(stderr)
+}
+
+func (s *SignTestSuite) TestSignVerifies() {
+	tests := map[string]struct {
+		key            string
+		addressType    string
+		format         string
+		net            *chaincfg.Params
+		expectedFormat verifier.Format
+	}{
+		"legacy - p2pkh-uncompressed": {key: s.uncompressedKey, addressType: "p2pkh-uncompressed", format: "legacy", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatLegacy},
+		"legacy - p2pkh":              {key: s.key, addressType: "p2pkh", format: "legacy", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatLegacy},
+		"legacy - p2sh-p2wpkh":        {key: s.key, addressType: "p2sh-p2wpkh", format: "legacy", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatLegacy},
+		"legacy - p2wpkh":             {key: s.key, addressType: "p2wpkh", format: "legacy", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatLegacy},
+		"legacy - p2tr":               {key: s.key, addressType: "p2tr", format: "legacy", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatLegacy},
+		"trezor - p2sh-p2wpkh":        {key: s.key, addressType: "p2sh-p2wpkh", format: "trezor", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatLegacy},
+		"trezor - p2wpkh":             {key: s.testnetKey, addressType: "p2wpkh", format: "trezor", net: &chaincfg.TestNet4Params, expectedFormat: verifier.FormatLegacy},
+		"bip322-simple - p2wpkh":      {key: s.key, addressType: "p2wpkh", format: "bip322-simple", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatBIP322Simple},
+		"bip322-simple - p2tr":        {key: s.testnetKey, addressType: "p2tr", format: "bip322-simple", net: &chaincfg.SigNetParams, expectedFormat: verifier.FormatBIP322Simple},
+		"bip322-full - p2pkh":         {key: s.key, addressType: "p2pkh", format: "bip322-full", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatBIP322Full},
+		"bip322-full - p2sh-p2wpkh":   {key: s.key, addressType: "p2sh-p2wpkh", format: "bip322-full", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatBIP322Full},
+		"bip322-full - p2wsh":         {key: s.key, addressType: "p2wsh", format: "bip322-full", net: &chaincfg.MainNetParams, expectedFormat: verifier.FormatBIP322Full},
+		"bip322-full - p2tr":          {key: s.testnetKey, addressType: "p2tr", format: "bip322-full", net: &chaincfg.RegressionNetParams, expectedFormat: verifier.FormatBIP322Full},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			code, stdout, stderr := s.run(tt.key, "-network", tt.net.Name, "-address-type", tt.addressType, "-format", tt.format, "-message", "Hello World", "-armored")
+			s.Require().Equal(cli.ExitValid, code, stderr)
+
+			signedMessage, err := verifier.ParseArmored(strings.NewReader(stdout))
+			s.Require().NoError(err)
+			s.Require().Equal("Hello World", signedMessage.Message)
+
+			result, err := verifier.NewVerifier(tt.net).Verify(signedMessage)
+			s.Require().NoError(err)
+			s.Require().True(result.Valid)
+			s.Require().Equal(tt.expectedFormat, result.Format)
+		})
+	}
+}
+
+func (s *SignTestSuite) TestSignKeyFile() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "key.txt"), []byte("tr("+s.key+")\n"), 0o600))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "message.txt"), []byte("Hello World\n"), 0o600))
+
+	// The message is read from stdin, the descriptor decides the address type
+	code, stdout, stderr := s.run("Hello World", "-key-file", filepath.Join(dir, "key.txt"), "-message-file", "-", "-format", "bip322-simple", "-armored")
+	s.Require().Equal(cli.ExitValid, code, stderr)
+
+	verifyStdout, verifyStderr := bytes.Buffer{}, bytes.Buffer{}
+	code = cli.Run([]string{"verify"}, strings.NewReader(stdout), &verifyStdout, &verifyStderr)
+	s.Require().Equal(cli.ExitValid, code, verifyStderr.String())
+	s.Require().Equal("valid: bip322-simple signature of bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3 on mainnet\n", verifyStdout.String())
+
+	// The message is signed as is, including the newline of the file
+	code, stdout, stderr = s.run("", "-key-file", filepath.Join(dir, "key.txt"), "-message-file", filepath.Join(dir, "message.txt"), "-format", "bip322-simple", "-armored")
+	s.Require().Equal(cli.ExitValid, code, stderr)
+
+	signedMessage, err := verifier.ParseArmored(strings.NewReader(stdout))
+	s.Require().NoError(err)
+	s.Require().Equal("Hello World\n", signedMessage.Message)
+}
+
+func (s *SignTestSuite) TestSignErrors() {
+	tests := map[string]struct {
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStderr string
+	}{
+		"key argument": {
+			args:           []string{"-key", s.key, "-address-type", "p2wpkh"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: passing the key with -key is insecure, read it from a file or stdin with -key-file or pass -insecure-key\n",
+		},
+		"key argument and file": {
+			args:           []string{"-key", s.key, "-insecure-key", "-key-file", "key.txt", "-address-type", "p2wpkh"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the key can not be passed both as a value and as a file\n",
+		},
+		"stdin twice": {
+			args:           []string{"-address-type", "p2wpkh", "-message-file", "-"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: only one input can be read from stdin\n",
+		},
+		"no key": {
+			args:           []string{"-address-type", "p2wpkh"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the key is required\n",
+		},
+		"invalid key": {
+			args:           []string{"-address-type", "p2wpkh"},
+			stdin:          "not a key",
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not decode the key, expected a WIF private key or a descriptor\n",
+		},
+		"invalid descriptor": {
+			stdin:          "wpkh(" + s.key + ")#2cmgp09q",
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not parse descriptor: invalid descriptor checksum '2cmgp09q', expected '2cmgp09h'\n",
+		},
+		"descriptor with other address type": {
+			args:           []string{"-address-type", "p2tr"},
+			stdin:          "wpkh(" + s.key + ")",
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: address type 'p2tr' does not match the descriptor, which has address type 'p2wpkh'\n",
+		},
+		"no address type": {
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the address type is required for a WIF private key\n",
+		},
+		"unknown address type": {
+			args:           []string{"-address-type", "p2ms"},
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown address type 'p2ms'\n",
+		},
+		"compressed key for uncompressed address": {
+			args:           []string{"-address-type", "p2pkh-uncompressed"},
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the key is compressed, which does not match address type 'p2pkh-uncompressed'\n",
+		},
+		"uncompressed key for segwit address": {
+			args:           []string{"-address-type", "p2wpkh"},
+			stdin:          s.uncompressedKey,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the key is uncompressed, which does not match address type 'p2wpkh'\n",
+		},
+		"key of other network": {
+			args:           []string{"-address-type", "p2wpkh", "-network", "testnet3"},
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the key is not for network 'testnet3'\n",
+		},
+		"unsupported format": {
+			args:           []string{"-address-type", "p2wsh"},
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: format 'legacy' does not support address type 'p2wsh'\n",
+		},
+		"unsupported trezor": {
+			args:           []string{"-address-type", "p2tr", "-format", "trezor"},
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: format 'trezor' does not support address type 'p2tr'\n",
+		},
+		"unsupported simple": {
+			args:           []string{"-address-type", "p2pkh", "-format", "bip322-simple"},
+			stdin:          s.key,
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: address type 'p2pkh' is not supported for simple signatures\n",
+		},
+		"unknown format": {
+			args:           []string{"-format", "psbt"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown format 'psbt', expected one of: legacy, trezor, bip322-simple, bip322-full\n",
+		},
+		"unknown network": {
+			args:           []string{"-network", "unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown network 'unknown', expected one of: mainnet, testnet3, testnet4, signet, regtest, simnet\n",
+		},
+		"positional arguments": {
+			args:           []string{s.key},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "unexpected arguments: " + s.key + "\n",
+		},
+		"help": {
+			args:           []string{"-h"},
+			expectedCode:   cli.ExitValid,
+			expectedStderr: "Usage: verify-signed-message sign [flags]\n",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			code, stdout, stderr := s.run(tt.stdin, tt.args...)
+
+			s.Require().Equal(tt.expectedCode, code, stderr)
+			s.Require().Empty(stdout)
+			s.Require().True(strings.HasPrefix(stderr, tt.expectedStderr), stderr)
+		})
+	}
+
+	// A forced key argument is allowed
+	code, stdout, stderr := s.run("", "-key", s.key, "-insecure-key", "-address-type", "p2pkh", "-message", "Hello World")
+	s.Require().Equal(cli.ExitValid, code, stderr)
+	s.Require().NotEmpty(stdout)
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/samber/lo"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// stdinPath is the path of a file flag that reads from stdin instead.
+const stdinPath = "-"
+
+// verifyFlags are the flags of the verify subcommand.
+type verifyFlags struct {
+	address       string
+	message       string
+	messageFile   string
+	signature     string
+	signatureFile string
+	armored       string
+	network       string
+	profile       string
+	output        string
+}
+
+// verifyReport is the outcome of the verify subcommand, it is written as JSON with `-output json`.
+type verifyReport struct {
+	Valid          bool                `json:"valid"`
+	Address        string              `json:"address"`
+	Network        string              `json:"network"`
+	Profile        string              `json:"profile"`
+	Format         verifier.Format     `json:"format,omitempty"`
+	Encoding       verifier.Encoding   `json:"encoding,omitempty"`
+	MessageTrimmed bool                `json:"messageTrimmed"`
+	ErrorClass     verifier.ErrorClass `json:"errorClass"`
+	Error          string              `json:"error,omitempty"`
+	Hints          []string            `json:"hints,omitempty"`
+}
+
+// runVerify runs the verify subcommand, which verifies a single signed message.
+func runVerify(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	options := verifyFlags{}
+
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.address, "address", "", "address that signed the message")
+	flags.StringVar(&options.message, "message", "", "message that has been signed")
+	flags.StringVar(&options.messageFile, "message-file", "", "read the message from a file, as is, or from stdin with '-'")
+	flags.StringVar(&options.signature, "signature", "", "signature of the message")
+	flags.StringVar(&options.signatureFile, "signature-file", "", "read the signature from a file or from stdin with '-'")
+	flags.StringVar(&options.armored, "armored", "", "read an armored signed message block from a file or from stdin with '-', this is the default without other input")
+	flags.StringVar(&options.network, "network", "mainnet", "network of the address: "+strings.Join(networkNames(), ", "))
+	flags.StringVar(&options.profile, "profile", verifier.Permissive().Name, "profile that decides which wallet compatibilities are applied: "+strings.Join(profileNames(), ", "))
+	flags.StringVar(&options.output, "output", outputHuman, "output format: human, json")
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), "Usage: verify-signed-message verify [flags]\n\nVerifies a signed message, taken from the flags, files, stdin or an armored block.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	net, err := verifier.NetworkByName(options.network)
+	if err != nil {
+		return malformed(stderr, err)
+	}
+
+	profile, err := profileByName(options.profile)
+	if err != nil {
+		return malformed(stderr, err)
+	}
+
+	if err := checkOutput(options.output); err != nil {
+		return malformed(stderr, err)
+	}
+
+	signedMessage, err := options.signedMessage(stdin)
+	if err != nil {
+		return malformed(stderr, err)
+	}
+
+	v := verifier.NewVerifier(net, verifier.WithProfile(profile))
+	result, err := v.Verify(signedMessage)
+
+	report := verifyReport{
+		Valid:          result.Valid,
+		Address:        signedMessage.Address,
+		Network:        net.Name,
+		Profile:        profile.Name,
+		Format:         result.Format,
+		Encoding:       result.Encoding,
+		MessageTrimmed: result.MessageTrimmed,
+		ErrorClass:     result.ErrorClass,
+		Error:          "",
+		Hints:          []string{},
+	}
+
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	// Look for common mistakes, which verifies variants of the signed message
+	if !result.Valid {
+		report.Hints = lo.Map(v.Explain(signedMessage).Fixes, func(fix verifier.Fix, _ int) string { return fix.Summary })
+	}
+
+	if err := report.write(stdout, options.output); err != nil {
+		return malformed(stderr, err)
+	}
+
+	return exitCode(report.ErrorClass)
+}
+
+// signedMessage reads the signed message from the flags, files or stdin.
+// An armored block is read from stdin when none of the other inputs are passed.
+func (f verifyFlags) signedMessage(stdin io.Reader) (verifier.SignedMessage, error) {
+	inputs := []string{f.address, f.message, f.messageFile, f.signature, f.signatureFile}
+	if f.armored == "" && lo.EveryBy(inputs, func(input string) bool { return input == "" }) {
+		f.armored = stdinPath
+	}
+
+	if f.armored != "" {
+		if lo.SomeBy(inputs, func(input string) bool { return input != "" }) {
+			return verifier.SignedMessage{}, errors.New("an armored block can not be combined with other input")
+		}
+
+		armored, err := readInput(f.armored, stdin)
+		if err != nil {
+			return verifier.SignedMessage{}, err
+		}
+
+		signedMessage, err := verifier.ParseArmored(bytes.NewReader(armored))
+		if err != nil {
+			return verifier.SignedMessage{}, fmt.Errorf("could not parse armored block: %w", err)
+		}
+
+		return signedMessage, nil
+	}
+
+	if f.messageFile == stdinPath && f.signatureFile == stdinPath {
+		return verifier.SignedMessage{}, errors.New("only one input can be read from stdin")
+	}
+
+	message, err := inputOrFile("message", f.message, f.messageFile, stdin)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	signature, err := inputOrFile("signature", f.signature, f.signatureFile, stdin)
+	if err != nil {
+		return verifier.SignedMessage{}, err
+	}
+
+	if f.address == "" {
+		return verifier.SignedMessage{}, errors.New("the address is required")
+	}
+
+	if signature == "" {
+		return verifier.SignedMessage{}, errors.New("the signature is required")
+	}
+
+	// Files usually end with a newline, which is never part of a signature
+	return verifier.SignedMessage{Address: f.address, Message: message, Signature: strings.TrimSpace(signature)}, nil
+}
+
+// inputOrFile returns the value of an input that can be passed as a flag or read from a file, but not both.
+func inputOrFile(name string, value string, path string, stdin io.Reader) (string, error) {
+	if value != "" && path != "" {
+		return "", fmt.Errorf("the %s can not be passed both as a value and as a file", name)
+	}
+
+	if path == "" {
+		return value, nil
+	}
+
+	data, err := readInput(path, stdin)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// readInput reads a file, or stdin for '-'.
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == stdinPath {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not read stdin: %w", err)
+		}
+
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	return data, nil
+}
+
+// write writes the report in the output format.
+func (r verifyReport) write(w io.Writer, output string) error {
+	if output == outputJSON {
+		return json.NewEncoder(w).Encode(r)
+	}
+
+	builder := strings.Builder{}
+	switch exitCode(r.ErrorClass) {
+	case ExitValid:
+		fmt.Fprintf(&builder, "valid: %s signature of %s on %s\n", r.Format, r.Address, r.Network)
+	case ExitInvalid:
+		fmt.Fprintf(&builder, "invalid (%s): %s\n", r.ErrorClass, r.Error)
+	default:
+		fmt.Fprintf(&builder, "malformed (%s): %s\n", r.ErrorClass, r.Error)
+	}
+
+	if r.MessageTrimmed {
+		builder.WriteString("note: the signature is only valid for the message without leading and trailing whitespace\n")
+	}
+
+	for _, hint := range r.Hints {
+		builder.WriteString("hint: " + hint + "\n")
+	}
+
+	_, err := io.WriteString(w, builder.String())
+
+	return err
+}
+
+// malformed reports an error about the input and returns ExitMalformed.
+func malformed(stderr io.Writer, err error) int {
+	_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+
+	return ExitMalformed
+}
@@ -0,0 +1,236 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/cli"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/verifiertest"
+)
+
+type VerifyTestSuite struct {
+	suite.Suite
+
+	legacy verifiertest.Fixture
+	bip322 verifiertest.Fixture
+	simnet verifiertest.Fixture
+}
+
+func TestVerifyTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(VerifyTestSuite))
+}
+
+func (s *VerifyTestSuite) SetupTest() {
+	var err error
+
+	generator := verifiertest.NewGenerator([]byte("cli"))
+
+	s.legacy, err = generator.Sign(&chaincfg.MainNetParams, verifiertest.AddressTypeP2PKH, verifiertest.SchemeLegacy, "Hello World")
+	s.Require().NoError(err)
+
+	s.bip322, err = generator.Sign(&chaincfg.TestNet3Params, verifiertest.AddressTypeP2WPKH, verifiertest.SchemeBIP322Simple, "Hello World")
+	s.Require().NoError(err)
+
+	s.simnet, err = generator.Sign(&chaincfg.SimNetParams, verifiertest.AddressTypeP2TR, verifiertest.SchemeBIP322Simple, "Hello World")
+	s.Require().NoError(err)
+}
+
+func (s *VerifyTestSuite) TestVerify() {
+	tests := map[string]struct {
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		"flags": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", "Hello World", "-signature", s.legacy.SignedMessage.Signature},
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: legacy signature of " + s.legacy.SignedMessage.Address + " on mainnet\n",
+		},
+		"double dash flags": {
+			args:           []string{"--network", "testnet3", "--address", s.bip322.SignedMessage.Address, "--message", "Hello World", "--signature", s.bip322.SignedMessage.Signature},
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: bip322-simple signature of " + s.bip322.SignedMessage.Address + " on testnet3\n",
+		},
+		"simnet": {
+			args:           []string{"-network", "simnet", "-address", s.simnet.SignedMessage.Address, "-message", "Hello World", "-signature", s.simnet.SignedMessage.Signature},
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: bip322-simple signature of " + s.simnet.SignedMessage.Address + " on simnet\n",
+		},
+		"message from stdin": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message-file", "-", "-signature", s.legacy.SignedMessage.Signature},
+			stdin:          "Hello World",
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: legacy signature of " + s.legacy.SignedMessage.Address + " on mainnet\n",
+		},
+		"signature from stdin": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", "Hello World", "-signature-file", "-"},
+			stdin:          s.legacy.SignedMessage.Signature + "\n",
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: legacy signature of " + s.legacy.SignedMessage.Address + " on mainnet\n",
+		},
+		"armored from stdin": {
+			stdin:          verifier.FormatArmored(s.legacy.SignedMessage),
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: legacy signature of " + s.legacy.SignedMessage.Address + " on mainnet\n",
+		},
+		"trimmed message": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", " Hello World ", "-signature", s.legacy.SignedMessage.Signature},
+			expectedCode:   cli.ExitValid,
+			expectedStdout: "valid: legacy signature of " + s.legacy.SignedMessage.Address + " on mainnet\nnote: the signature is only valid for the message without leading and trailing whitespace\n",
+		},
+		"invalid": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", "Hello World!", "-signature", s.legacy.SignedMessage.Signature},
+			expectedCode:   cli.ExitInvalid,
+			expectedStdout: "invalid (invalid): generated address '",
+		},
+		"invalid - profile": {
+			args:           []string{"-network", "testnet3", "-profile", "bitcoin-core", "-address", s.bip322.SignedMessage.Address, "-message", "Hello World", "-signature", s.bip322.SignedMessage.Signature},
+			expectedCode:   cli.ExitInvalid,
+			expectedStdout: "invalid (format): BIP-322 signatures are not allowed by profile 'bitcoin-core'\n",
+		},
+		"malformed - wrong network": {
+			args:           []string{"-address", s.bip322.SignedMessage.Address, "-message", "Hello World", "-signature", s.bip322.SignedMessage.Signature},
+			expectedCode:   cli.ExitMalformed,
+			expectedStdout: "malformed (address): ",
+		},
+		"malformed signature": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", "Hello World", "-signature", "not a signature"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStdout: "malformed (signature): ",
+		},
+		"malformed armored block": {
+			stdin:          "Hello World",
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not parse armored block: could not find '-----BEGIN BITCOIN SIGNED MESSAGE-----'\n",
+		},
+		"armored block with other input": {
+			args:           []string{"-armored", "-", "-address", s.legacy.SignedMessage.Address},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: an armored block can not be combined with other input\n",
+		},
+		"no address": {
+			args:           []string{"-message", "Hello World", "-signature", s.legacy.SignedMessage.Signature},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the address is required\n",
+		},
+		"no signature": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", "Hello World"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the signature is required\n",
+		},
+		"message twice": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message", "Hello World", "-message-file", "-"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: the message can not be passed both as a value and as a file\n",
+		},
+		"stdin twice": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "-message-file", "-", "-signature-file", "-"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: only one input can be read from stdin\n",
+		},
+		"missing file": {
+			args:           []string{"-armored", filepath.Join(s.T().TempDir(), "missing")},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: could not read file: open ",
+		},
+		"unknown network": {
+			args:           []string{"-network", "unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown network 'unknown', expected one of: mainnet, testnet3, testnet4, signet, regtest, simnet\n",
+		},
+		"unknown profile": {
+			args:           []string{"-profile", "unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown profile 'unknown', expected one of: permissive, strict-bip322, bitcoin-core, electrum-compatible\n",
+		},
+		"unknown output": {
+			args:           []string{"-output", "xml"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "error: unknown output 'xml', expected one of: human, json\n",
+		},
+		"unknown flag": {
+			args:           []string{"-unknown"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "flag provided but not defined: -unknown\n",
+		},
+		"positional arguments": {
+			args:           []string{"-address", s.legacy.SignedMessage.Address, "Hello World"},
+			expectedCode:   cli.ExitMalformed,
+			expectedStderr: "unexpected arguments: Hello World\n",
+		},
+		"help": {
+			args:           []string{"-h"},
+			expectedCode:   cli.ExitValid,
+			expectedStderr: "Usage: verify-signed-message verify [flags]\n",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+			code := cli.Run(append([]string{"verify"}, tt.args...), strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			s.Require().Equal(tt.expectedCode, code, stderr.String())
+			s.Require().True(strings.HasPrefix(stdout.String(), tt.expectedStdout), stdout.String())
+			s.Require().True(strings.HasPrefix(stderr.String(), tt.expectedStderr), stderr.String())
+		})
+	}
+}
+
+func (s *VerifyTestSuite) TestVerifyFiles() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "message.txt"), []byte("Hello World"), 0o600))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "signature.txt"), []byte(s.legacy.SignedMessage.Signature+"\n"), 0o600))
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "armored.txt"), []byte(verifier.FormatArmored(s.legacy.SignedMessage)), 0o600))
+
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := cli.Run([]string{"verify", "-address", s.legacy.SignedMessage.Address, "-message-file", filepath.Join(dir, "message.txt"), "-signature-file", filepath.Join(dir, "signature.txt")}, strings.NewReader(""), &stdout, &stderr)
+	s.Require().Equal(cli.ExitValid, code, stderr.String())
+
+	code = cli.Run([]string{"verify", "-armored", filepath.Join(dir, "armored.txt")}, strings.NewReader(""), &stdout, &stderr)
+	s.Require().Equal(cli.ExitValid, code, stderr.String())
+}
+
+func (s *VerifyTestSuite) TestVerifyJSON() {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := cli.Run([]string{"verify", "-output", "json", "-network", "testnet3", "-address", s.bip322.SignedMessage.Address, "-message", "Hello World", "-signature", s.bip322.SignedMessage.Signature}, strings.NewReader(""), &stdout, &stderr)
+	s.Require().Equal(cli.ExitValid, code, stderr.String())
+	s.Require().JSONEq(`{
+		"valid": true,
+		"address": "`+s.bip322.SignedMessage.Address+`",
+		"network": "testnet3",
+		"profile": "permissive",
+		"format": "bip322-simple",
+		"encoding": "base64",
+		"messageTrimmed": false,
+		"errorClass": "none"
+	}`, stdout.String())
+
+	// The address is valid on the test networks, which are suggested
+	stdout.Reset()
+	code = cli.Run([]string{"verify", "-output", "json", "-address", s.bip322.SignedMessage.Address, "-message", "Hello World", "-signature", s.bip322.SignedMessage.Signature}, strings.NewReader(""), &stdout, &stderr)
+	s.Require().Equal(cli.ExitMalformed, code, stderr.String())
+
+	var report struct {
+		Valid      bool     `json:"valid"`
+		ErrorClass string   `json:"errorClass"`
+		Hints      []string `json:"hints"`
+	}
+	s.Require().NoError(json.Unmarshal(stdout.Bytes(), &report))
+	s.Require().False(report.Valid)
+	s.Require().Equal("address", report.ErrorClass)
+	s.Require().Equal([]string{"The signature is valid on networks 'testnet3', 'testnet4' and 'signet', not on network 'mainnet'"}, report.Hints)
+}
@@ -1,5 +1,7 @@
 package flags
 
+import "github.com/samber/lo"
+
 // All returns every possible recovery flag, taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/btclib/ecc/bms.py#L83
 func All() []int {
 	return []int{27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42}
@@ -51,3 +53,67 @@ func TrezorP2SHAndP2WPKH() []int {
 func Uncompressed() []int {
 	return []int{27, 28, 29, 30}
 }
+
+// Minimal returns the lowest recovery flag that recovers the same public key as the passed recovery flag.
+// This is the flag Bitcoin Core and Electrum use, the BIP-137 (Trezor) flags are mapped onto the compressed flags.
+func Minimal(recoveryFlag int) int {
+	if ShouldBeCompressed(recoveryFlag) {
+		return Compressed()[0] + GetKeyID(recoveryFlag)
+	}
+
+	return Uncompressed()[0] + GetKeyID(recoveryFlag)
+}
+
+// Family describes which kind of address a recovery flag was created for.
+type Family string
+
+const (
+	// FamilyUncompressed is used for P2PKH addresses with an uncompressed public key.
+	FamilyUncompressed Family = "uncompressed"
+	// FamilyCompressed is used for P2PKH addresses with a compressed public key.
+	FamilyCompressed Family = "compressed"
+	// FamilyElectrumSegwit is used by Electrum for P2WPKH and P2SH-P2WPKH addresses, it shares its flags with FamilyCompressed.
+	FamilyElectrumSegwit Family = "electrum-segwit"
+	// FamilyTrezorP2SHAndP2WPKH is used by Trezor (BIP-137) for P2SH-P2WPKH addresses.
+	FamilyTrezorP2SHAndP2WPKH Family = "trezor-p2sh-p2wpkh"
+	// FamilyTrezorP2WPKH is used by Trezor (BIP-137) for P2WPKH addresses.
+	FamilyTrezorP2WPKH Family = "trezor-p2wpkh"
+)
+
+// Families returns every family a recovery flag belongs to, this is empty for unknown recovery flags.
+// The compressed recovery flags belong to two families, as Electrum uses them for segwit addresses as well.
+func Families(recoveryFlag int) []Family {
+	switch {
+	case lo.Contains(Uncompressed(), recoveryFlag):
+		return []Family{FamilyUncompressed}
+	case lo.Contains(Compressed(), recoveryFlag):
+		return []Family{FamilyCompressed, FamilyElectrumSegwit}
+	case lo.Contains(TrezorP2SHAndP2WPKH(), recoveryFlag):
+		return []Family{FamilyTrezorP2SHAndP2WPKH}
+	case lo.Contains(TrezorP2WPKH(), recoveryFlag):
+		return []Family{FamilyTrezorP2WPKH}
+	default:
+		return []Family{}
+	}
+}
+
+// DistinctFamilies returns a family for every set of recovery flags, FamilyElectrumSegwit is left out as it shares its flags with FamilyCompressed.
+func DistinctFamilies() []Family {
+	return []Family{FamilyUncompressed, FamilyCompressed, FamilyTrezorP2SHAndP2WPKH, FamilyTrezorP2WPKH}
+}
+
+// BaseFlag returns the first recovery flag of the family, the key ID is added to it. It returns 0 for an unknown family.
+func (f Family) BaseFlag() int {
+	switch f {
+	case FamilyUncompressed:
+		return Uncompressed()[0]
+	case FamilyCompressed, FamilyElectrumSegwit:
+		return Compressed()[0]
+	case FamilyTrezorP2SHAndP2WPKH:
+		return TrezorP2SHAndP2WPKH()[0]
+	case FamilyTrezorP2WPKH:
+		return TrezorP2WPKH()[0]
+	default:
+		return 0
+	}
+}
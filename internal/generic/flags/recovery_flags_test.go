@@ -1,6 +1,7 @@
 package flags_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -56,6 +57,28 @@ func (s *RecoveryFlagTestSuite) TestGetKeyID() {
 	}
 }
 
+func (s *RecoveryFlagTestSuite) TestMinimal() {
+	tests := []struct {
+		name         string
+		recoveryFlag int
+		expected     int
+	}{
+		{name: "27", recoveryFlag: 27, expected: 27},
+		{name: "30", recoveryFlag: 30, expected: 30},
+		{name: "32", recoveryFlag: 32, expected: 32},
+		{name: "36", recoveryFlag: 36, expected: 32},
+		{name: "38", recoveryFlag: 38, expected: 34},
+		{name: "39", recoveryFlag: 39, expected: 31},
+		{name: "42", recoveryFlag: 42, expected: 34},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.Require().Equal(tt.expected, flags.Minimal(tt.recoveryFlag))
+		})
+	}
+}
+
 func (s *RecoveryFlagTestSuite) TestShouldBeCompressed() {
 	tests := []struct {
 		name         string
@@ -89,3 +112,50 @@ func (s *RecoveryFlagTestSuite) TestTrezorP2SHAndP2WPKH() {
 func (s *RecoveryFlagTestSuite) TestUncompressed() {
 	s.Require().Equal([]int{27, 28, 29, 30}, flags.Uncompressed())
 }
+
+func (s *RecoveryFlagTestSuite) TestFamilies() {
+	tests := []struct {
+		recoveryFlag int
+		expected     []flags.Family
+	}{
+		{recoveryFlag: 26, expected: []flags.Family{}},
+		{recoveryFlag: 27, expected: []flags.Family{flags.FamilyUncompressed}},
+		{recoveryFlag: 30, expected: []flags.Family{flags.FamilyUncompressed}},
+		{recoveryFlag: 31, expected: []flags.Family{flags.FamilyCompressed, flags.FamilyElectrumSegwit}},
+		{recoveryFlag: 34, expected: []flags.Family{flags.FamilyCompressed, flags.FamilyElectrumSegwit}},
+		{recoveryFlag: 35, expected: []flags.Family{flags.FamilyTrezorP2SHAndP2WPKH}},
+		{recoveryFlag: 38, expected: []flags.Family{flags.FamilyTrezorP2SHAndP2WPKH}},
+		{recoveryFlag: 39, expected: []flags.Family{flags.FamilyTrezorP2WPKH}},
+		{recoveryFlag: 42, expected: []flags.Family{flags.FamilyTrezorP2WPKH}},
+		{recoveryFlag: 43, expected: []flags.Family{}},
+	}
+
+	for _, tt := range tests {
+		s.Run(fmt.Sprintf("flag %d", tt.recoveryFlag), func() {
+			s.Require().Equal(tt.expected, flags.Families(tt.recoveryFlag))
+		})
+	}
+}
+
+func (s *RecoveryFlagTestSuite) TestBaseFlag() {
+	tests := map[flags.Family]int{
+		flags.FamilyUncompressed:        27,
+		flags.FamilyCompressed:          31,
+		flags.FamilyElectrumSegwit:      31,
+		flags.FamilyTrezorP2SHAndP2WPKH: 35,
+		flags.FamilyTrezorP2WPKH:        39,
+		flags.Family("unknown"):         0,
+	}
+
+	for family, expected := range tests {
+		s.Run(string(family), func() {
+			s.Require().Equal(expected, family.BaseFlag())
+		})
+	}
+}
+
+func (s *RecoveryFlagTestSuite) TestDistinctFamilies() {
+	for _, family := range flags.DistinctFamilies() {
+		s.Require().Equal(family, flags.Families(family.BaseFlag())[0])
+	}
+}
@@ -66,22 +66,54 @@ func ParseCompact(signature []byte) (*ecdsa.Signature, error) {
 	return ecdsa.NewSignature(&r, &s), nil
 }
 
-// Verify ensures that the signature for the message hash is valid for the public key given.
-func Verify(signatureEncoded []byte, publicKey *btcec.PublicKey, messageHash []byte) error {
-	if publicKey == nil || !publicKey.IsOnCurve() {
-		return errors.New("public key was not correctly instantiated")
+// IsLowS reports whether the S component of the compact signature is at most half of the curve order.
+// The recovery code is not validated, so BIP-137 (Trezor) signatures are supported as well.
+func IsLowS(signature []byte) (bool, error) {
+	s, err := parseS(signature)
+	if err != nil {
+		return false, err
 	}
 
-	// Parse the signature so we can verify it
-	parsedSignature, err := ParseCompact(signatureEncoded)
+	return !s.IsOverHalfOrder(), nil
+}
+
+// NormalizeS returns a copy of the compact signature with a low S value and reports whether S had to be negated.
+// When S is negated, the recovery code is adjusted so the same public key is recovered. The passed signature is never modified.
+//
+// The recovery code is not validated, so BIP-137 (Trezor) signatures are supported as well.
+func NormalizeS(signature []byte) ([]byte, bool, error) {
+	s, err := parseS(signature)
 	if err != nil {
-		return err
+		return nil, false, err
+	}
+
+	normalized := make([]byte, compactSigSize)
+	copy(normalized, signature)
+	if !s.IsOverHalfOrder() {
+		return normalized, false, nil
 	}
 
-	// Actually verify the message
-	if verified := parsedSignature.Verify(messageHash, publicKey); !verified {
-		return errors.New("signature could not be verified")
+	// Negating S mirrors the R point, which flips the parity bit of the key ID
+	s.Negate().PutBytesUnchecked(normalized[33:])
+	keyID := (normalized[0] - compactSigMagicOffset) & 0b11
+	normalized[0] = normalized[0] - keyID + (keyID ^ 1)
+
+	return normalized, true, nil
+}
+
+// parseS parses and validates the S component of a compact signature.
+func parseS(signature []byte) (*btcec.ModNScalar, error) {
+	if len(signature) != compactSigSize {
+		return nil, errors.New("invalid compact signature size")
+	}
+
+	var s btcec.ModNScalar
+	if overflow := s.SetByteSlice(signature[33:]); overflow {
+		return nil, errors.New("signature S is >= curve order")
+	}
+	if s.IsZero() {
+		return nil, errors.New("signature S is 0")
 	}
 
-	return nil
+	return &s, nil
 }
@@ -3,84 +3,57 @@ package generic
 import (
 	"errors"
 	"fmt"
-	"reflect"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
-	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/samber/lo"
 
-	"github.com/bitonicnl/verify-signed-message/internal"
 	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
-	"github.com/bitonicnl/verify-signed-message/internal/generic/signature"
 )
 
 // ExpectedSignatureLength contains the fixed signature length all signed messages are expected to have.
 const ExpectedSignatureLength = 65
 
-func Verify(address btcutil.Address, message string, signatureDecoded []byte, net *chaincfg.Params) (bool, error) {
+// RecoverPublicKey recovers the public key from a legacy or BIP-137 signature and the hash of the signed message, together with the recovery flag.
+// The signature is never modified.
+func RecoverPublicKey(messageHash []byte, signatureDecoded []byte) (int, *btcec.PublicKey, error) {
 	// Ensure signature has proper length
 	if len(signatureDecoded) != ExpectedSignatureLength {
-		return false, fmt.Errorf("wrong signature length: %d instead of %d", len(signatureDecoded), ExpectedSignatureLength)
+		return 0, nil, fmt.Errorf("wrong signature length: %d instead of %d", len(signatureDecoded), ExpectedSignatureLength)
 	}
 
 	// Ensure signature has proper recovery flag
 	recoveryFlag := int(signatureDecoded[0])
 	if !lo.Contains[int](flags.All(), recoveryFlag) {
-		return false, fmt.Errorf("invalid recovery flag: %d", recoveryFlag)
+		return 0, nil, fmt.Errorf("invalid recovery flag: %d", recoveryFlag)
 	}
 
 	// Should address be compressed (for checking later)
 	compressed := flags.ShouldBeCompressed(recoveryFlag)
 
-	// Reset recovery flag after obtaining keyID for Trezor
+	// Reset recovery flag after obtaining keyID for Trezor, on a copy so the signature of the caller is never modified
 	if lo.Contains[int](flags.Trezor(), recoveryFlag) {
 		keyID := 27 + flags.GetKeyID(recoveryFlag)
 		if keyID < 0 || keyID > 255 {
-			return false, fmt.Errorf("invalid key ID value: %d", keyID)
+			return 0, nil, fmt.Errorf("invalid key ID value: %d", keyID)
 		}
-		signatureDecoded[0] = byte(keyID)
+		var normalized [ExpectedSignatureLength]byte
+		normalized[0] = byte(keyID)
+		copy(normalized[1:], signatureDecoded[1:])
+		signatureDecoded = normalized[:]
 	}
 
-	// Make and hash the message
-	messageHash := chainhash.DoubleHashB([]byte(internal.CreateMagicMessage(message)))
-
 	// Recover the public key from signature and message hash
 	publicKey, wasCompressed, err := ecdsa.RecoverCompact(signatureDecoded, messageHash)
 	if err != nil {
-		return false, fmt.Errorf("could not recover pubkey: %w", err)
+		return 0, nil, fmt.Errorf("could not recover pubkey: %w", err)
 	}
 
 	// Ensure our initial assumption was correct, except for Trezor as they do something different
 	if compressed != wasCompressed && !lo.Contains[int](flags.Trezor(), recoveryFlag) {
-		return false, errors.New("we expected the key to be compressed, it wasn't")
-	}
-
-	// Verify that the signature is valid
-	// TODO: ecdsa.RecoverCompact already does all, check if we can just remove it
-	if err := signature.Verify(signatureDecoded, publicKey, messageHash); err != nil {
-		return false, err
+		return 0, nil, errors.New("we expected the key to be compressed, it wasn't")
 	}
 
-	// Get the hash from the public key, so we can check that address matches
-	publicKeyHash := GeneratePublicKeyHash(recoveryFlag, publicKey)
-
-	switch address.(type) {
-	// Validate P2PKH - Legacy
-	case *btcutil.AddressPubKeyHash:
-		return ValidateP2PKH(recoveryFlag, publicKeyHash, address, net)
-	// Validate P2SH-P2WPKH - Segwit
-	case *btcutil.AddressScriptHash:
-		return ValidateP2SH(recoveryFlag, publicKeyHash, address, net)
-	// Validate P2WPKH - Native Segwit
-	case *btcutil.AddressWitnessPubKeyHash:
-		return ValidateP2WPKH(recoveryFlag, publicKeyHash, address, net)
-	// Validate P2TR - Taproot
-	case *btcutil.AddressTaproot:
-		return ValidateP2TR(recoveryFlag, publicKey, address, net)
-	// Unsupported address
-	default:
-		return false, fmt.Errorf("unsupported address type '%s'", reflect.TypeOf(address))
-	}
+	// The recovered public key is, by construction, one for which the signature is valid, so it is not verified again
+	return recoveryFlag, publicKey, nil
 }
@@ -2,15 +2,15 @@ package generic_test
 
 import (
 	"encoding/base64"
-	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/suite"
 
-	"github.com/bitonicnl/verify-signed-message/internal/generic"
 	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/conformance"
 )
 
 type VerifyTestSuite struct {
@@ -113,7 +113,7 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.signedMessage.Signature)
 			s.Require().NoError(err)
 
-			valid, err := generic.Verify(address, tt.signedMessage.Message, signatureDecoded, &chaincfg.MainNetParams)
+			valid, err := s.verify(address, tt.signedMessage.Message, signatureDecoded, &chaincfg.MainNetParams)
 			s.Require().EqualError(err, tt.expectedError)
 			s.Require().False(valid)
 		})
@@ -121,191 +121,68 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 }
 
 func (s *VerifyTestSuite) TestVerify() {
-	tests := map[string]verifier.SignedMessage{
-		"bitcoin core - legacy": {
-			Address:   "1CBHFokbnZVuq9fA3yjPTvSNXpdRRP7eUB",
-			Message:   " Lorem ipsum dolor sit amet, consectetur adipiscing elit. In a turpis dignissim, tincidunt dolor quis, aliquam justo. Sed eleifend eleifend tempus. Sed blandit lectus at ullamcorper blandit. Quisque suscipit ligula lacus, tempor fringilla erat pharetra a. Curabitur pretium varius purus vel luctus. Donec fringilla velit vel risus fermentum, ac aliquam enim sollicitudin. Aliquam elementum, nunc nec malesuada fringilla, sem sem lacinia libero, id tempus nunc velit nec dui. Vestibulum gravida non tortor sit amet accumsan. Nunc semper vehicula vestibulum. Praesent at nibh dapibus, eleifend neque vitae, vehicula justo. Nam ultricies at orci vel laoreet. Morbi metus sapien, pulvinar ut dui ut, malesuada lobortis odio. Curabitur eget diam ligula. Nunc vel nisl consectetur, elementum magna et, elementum erat. Maecenas risus massa, mattis a sapien sed, molestie ullamcorper sapien. ",
-			Signature: "H3HQ9gwAMCee0T7M8fZTgvIYlG6pMnpP41ioDUTKjlPsOMHwrF3qmgsM+kFoWLL1u6P4ZUf3nwYacPCeBjrzFzE=",
-		},
-		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
-		"bms compressed - legacy": {
-			Address:   "14dD6ygPi5WXdwwBTt1FBZK3aD8uDem1FY",
-			Message:   "test message",
-			Signature: "H/iew/NhHV9V9MdUEn/LFOftaTy1ivGPKPKyMlr8OSokNC755fAxpSThNRivwTNsyY9vPUDTRYBPc2cmGd5d4y4=",
-		},
-		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
-		"bms compressed p2pkh": {
-			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
-			Message:   "test message",
-			Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
-		},
-		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
-		"bms uncompressed - legacy": {
-			Address:   "1HUBHMij46Hae75JPdWjeZ5Q7KaL7EFRSD",
-			Message:   "test message",
-			Signature: "G/iew/NhHV9V9MdUEn/LFOftaTy1ivGPKPKyMlr8OSokNC755fAxpSThNRivwTNsyY9vPUDTRYBPc2cmGd5d4y4=",
-		},
-		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
-		"bms uncompressed p2pkh": {
-			Address:   "19f7adDYqhHSJm2v7igFWZAqxXHj1vUa3T",
-			Message:   "test message",
-			Signature: "HFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
-		},
-		"coinomi - legacy": {
-			Address:   "1PjSDaSiVdWW6YjwFA6FHwwfqkZdPEJUZv",
-			Message:   "Test message!",
-			Signature: "IK7I33rASHdSeYDotQ9WfO4jrxgdl5ef/bTbX6Q5PNtFY9rJeAHfoZV5GpDO1K3OqoPs8ROZRXPyMNLkVOxJ+Rc=",
-		},
-		"coinomi - segwit": {
-			Address:   "39FT3L2wH56h2jmae5abPU1A7nVs6QyApV",
-			Message:   "Test message!",
-			Signature: "HzpoLFjr+eUPkseb+i0Vaqj7FRm5o1+Ei/kae7XWN6nmFLmvLi7uWicerYNXjCMUf3nCnm/9UPb6SYJLI60Nh8A=",
-		},
-		"coinomi - segwit native": {
-			Address:   "bc1q0utxws6ptfdfcvaz29y4st065t5ku6vcqd364f",
-			Message:   "Test message!",
-			Signature: "H+G7Fz3EVxX02kIker4HPgnP8Mlf3bT52p81hnNAahTOGJ8ANSaU0bF5RsprgTH6LXLx/PmCka48Ov7OrPw2bms=",
-		},
-		"electrum - legacy": {
-			Address:   "1CPBDkm8ER3o7r2HANcvNoVHsBYKcUHTp9",
-			Message:   "Integer can be encoded depending on the represented value to save space. Variable length integers always precede an array/vector of a type of data that may vary in length. Longer numbers are encoded in little endian. If you're reading the Satoshi client code (BitcoinQT) it refers to this encoding as a \"CompactSize\". Modern Bitcoin Core also has the VARINT macro which implements an even more compact integer for the purpose of local storage (which is incompatible with \"CompactSize\" described here). VARINT is not a part of the protocol.",
-			Signature: "IHTr8YSzZ17Ut/Qaaui6BvGd42+TGwVwNYaIMUAZQTZRSqDtaTfsOcaOllPstp3IxzMlpXVOzLxNZE8r8ieffnY=",
-		},
-		"electrum - segwit native": {
-			Address:   "bc1qsdjne3y6ljndzvg9z9qrhje8k7p2m5yas704hn",
-			Message:   "Integer can be encoded depending on the represented value to save space. Variable length integers always precede an array/vector of a type of data that may vary in length. Longer numbers are encoded in little endian. If you're reading the Satoshi client code (BitcoinQT) it refers to this encoding as a \"CompactSize\". Modern Bitcoin Core also has the VARINT macro which implements an even more compact integer for the purpose of local storage (which is incompatible with \"CompactSize\" described here). VARINT is not a part of the protocol.",
-			Signature: "H3TkHAXCKRfyDowCra5YRDF/Vkk2HQCel/pgEgTj9LYaWpnviSRcuYtv/CZk7NTyHsJnYP56bqbvuU3PejwLCnA=",
-		},
-		"mycelium - legacy": {
-			Address:   "13VwTBVLNpNSQVTrYpuHQVJYnk2y2Nr1ue",
-			Message:   "Test message!",
-			Signature: "Hxpnr2oDFTjivFkrrp89UoMrzaAzFkkEciS3MUHCfdoEXN/KvHi9ii2Xz+FuQ6KjlZDlaPb197E8TWnhIAzbT0M=",
-		},
-		"mycelium - segwit": {
-			Address:   "325ZMWMu9vaWQeUG8Gc8MzsVKzt3Rqn8H7",
-			Message:   "Test message!",
-			Signature: "IM/bkqpERGRFDGgxnceinULcqz1iRVBSUVlnDPZRKHGUQMC5t1P5wRp2/1b1+rpjFHhSS2pExB88cA750PNRlaw=",
-		},
-		"mycelium - segwit native": {
-			Address:   "bc1q58dh2fpwms37g29nw979pa65lsvjkqxq82jzvv",
-			Message:   "Test message!",
-			Signature: "ILNax/LC+m3WwzIhnrieNN8DRzWTAgcVStSJmwdabUQII2fIlYUlEgnlNf4j2G4yJQoO4zFqCwaLOX4PDj1XwjA=",
-		},
-		// Taken from https://github.com/petertodd/python-bitcoinlib/blob/master/bitcoin/tests/test_signmessage.py
-		"python-bitcoinlib - legacy": {
-			Address:   "1F26pNMrywyZJdr22jErtKcjF8R3Ttt55G",
-			Message:   "1F26pNMrywyZJdr22jErtKcjF8R3Ttt55G",
-			Signature: "H85WKpqtNZDrajOnYDgUY+abh0KCAcOsAIOQwx2PftAbLEPRA7mzXA/CjXRxzz0MC225pR/hx02Vf2Ag2x33kU4=",
-		},
-		// Dumped from (changed TestNet to MainNet): https://github.com/Samourai-Wallet/ExtLibJ/blob/develop/src/test/java/com/samourai/wallet/util/MessageSignUtilGenericTest.java
-		"samourai - legacy": {
-			Address:   "1JSjyW3dZSQHv6jb6u6baXLUZnsThqmzf4",
-			Message:   "hello foo",
-			Signature: "INAP+PMyI2vqIxiEKIcPOaaffspU3gAPm0YWhCxJr5iqWbQwqns9+RiXIzuU9JoNQs/MQ1BZ4O2XM23utyw3jr0=",
-		},
-		// Dumped from (changed TestNet to MainNet): https://github.com/Samourai-Wallet/ExtLibJ/blob/develop/src/test/java/com/samourai/wallet/util/MessageSignUtilGenericTest.java
-		"samourai - segwit native": {
-			Address:   "bc1qnxhkjd3kcjdzqz4u0m47xj3dne907cd8yg7qdr",
-			Message:   "hello foo",
-			Signature: "IJruGdQX+V6s+zvzTD3msz2l1obPchx19/bsefr+QGihcRArLSzXtkoUXA8k0NkBsIpFXGRxbG/s+eimZ+eGg70=",
-		},
-		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
-		"trezor - legacy": {
-			Address:   "1JAd7XCBzGudGpJQSDSfpmJhiygtLQWaGL",
-			Message:   "This is an example of a signed message.",
-			Signature: "IP2PL321I4/N0HfVIEw+aUnCYdcAJpzvwdnS3O9rlQI2MO5hf2yKz560DI7dcEycp06kr8OT9D81tOiVgyTL3Rw=",
-		},
-		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
-		"trezor - legacy - long message": {
-			Address:   "1JAd7XCBzGudGpJQSDSfpmJhiygtLQWaGL",
-			Message:   strings.Repeat("VeryLongMessage!", 64),
-			Signature: "IApGR2zrhNBu9XhIKAJvkiyIFfV6rIN7jAEwB8qKhGDbY++Rfb6669EIscgUu+6m2x8rIkGpWOU/5xXMhrGZ2cM=",
-		},
-		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
-		"trezor - segwit native": {
-			Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
-			Message:   "This is an example of a signed message.",
-			Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
-		},
-		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
-		"trezor - segwit native long message": {
-			Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
-			Message:   strings.Repeat("VeryLongMessage!", 64),
-			Signature: "KMb4biVeqnaMRH1jXZHaAWMaxUryI8LBgtT6NnbP7K5KGZrTOnT+BPtGw5QyrLjYPedNqQ9fARI7O32LwlK8f3E=",
-		},
-		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
-		"trezor - segwit - #0": {
-			Address:   "3L6TyTisPBmrDAj6RoKmDzNnj4eQi54gD2",
-			Message:   "This is an example of a signed message.",
-			Signature: "I3RN5FFvrFwUCAgBVmRRajL+rZTeiXdc7H4k28JP4TMHWsCTAcTMjhl76ktkgWYdW46b8Z2Le4o4Ls21PC7gdQ0=",
-		},
-		// Taken from https://github.com/bitcoinjs/bitcoinjs-message/issues/20
-		"trezor - segwit - #1": {
-			Address:   "3LbZqMMHu371r5Fjve9qNhSQzuNi7EzqUR",
-			Message:   "test123",
-			Signature: "I2ehXowFWMZohHrJN+1IRdDwqN/UILqVmhIOHpeBdS4BYDCQpfDL1tTH7mNg6eeypno+Is8ApgWinkPnnz1NEq8=",
-		},
-		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
-		"trezor - segwit - long message": {
-			Address:   "3L6TyTisPBmrDAj6RoKmDzNnj4eQi54gD2",
-			Message:   strings.Repeat("VeryLongMessage!", 64),
-			Signature: "I26t7jgGhPcHScUhQciqfDtq/YTQ5fOM+nGCPzsRBaXzTiODSlu28jn/KK2H9An0TkzmJpdUrcADiLGVB6XZOG8=",
-		},
-		// Taken from https://github.com/bitcoinjs/bitcoinjs-message/issues/20
-		"electrum - segwit": {
-			Address:   "3LbZqMMHu371r5Fjve9qNhSQzuNi7EzqUR",
-			Message:   "test123",
-			Signature: "H2ehXowFWMZohHrJN+1IRdDwqN/UILqVmhIOHpeBdS4BYDCQpfDL1tTH7mNg6eeypno+Is8ApgWinkPnnz1NEq8=",
-		},
-		"electrum - legacy - short message - uncompressed": {
-			Address:   "18J72YSM9pKLvyXX1XAjFXA98zeEvxBYmw",
-			Message:   "Test123",
-			Signature: "Gzhfsw0ItSrrTCChykFhPujeTyAcvVxiXwywxpHmkwFiKuUR2ETbaoFcocmcSshrtdIjfm8oXlJoTOLosZp3Yc8=",
-		},
-		"electrum - legacy - long message - uncompressed": {
-			Address:   "18J72YSM9pKLvyXX1XAjFXA98zeEvxBYmw",
-			Message:   "Lorem ipsum dolor sit amet, consectetur adipiscing elit. In a turpis dignissim, tincidunt dolor quis, aliquam justo. Sed eleifend eleifend tempus. Sed blandit lectus at ullamcorper blandit. Quisque suscipit ligula lacus, tempor fringilla erat pharetra a. Curabitur pretium varius purus vel luctus. Donec fringilla velit vel risus fermentum, ac aliquam enim sollicitudin. Aliquam elementum, nunc nec malesuada fringilla, sem sem lacinia libero, id tempus nunc velit nec dui. Vestibulum gravida non tortor sit amet accumsan. Nunc semper vehicula vestibulum. Praesent at nibh dapibus, eleifend neque vitae, vehicula justo. Nam ultricies at orci vel laoreet. Morbi metus sapien, pulvinar ut dui ut, malesuada lobortis odio. Curabitur eget diam ligula. Nunc vel nisl consectetur, elementum magna et, elementum erat. Maecenas risus massa, mattis a sapien sed, molestie ullamcorper sapien.",
-			Signature: "HHOGSz6AUEEyVGoCUw1GqQ5qy9KvW5uO1FfqWLbwYxkQVsI+sbM0jpBQWkyjr72166yiL/LQEtW3SpVBR1gXdYY=",
-		},
-		// Generated via https://demo.unisat.io/
-		"unisat - legacy": {
-			Address:   "19892aZkySq8Va3Qp2gk9dapNtWypnL1ek",
-			Message:   "hello world~",
-			Signature: "IOmbxBO4Wwy42+Q9JoOB2ZaXygdDCIaGKloc5igs+ZF0WqWIVDiFLmuUZKdGeSiz+VNPd19d4hPHgOXCfZfZveQ=",
-		},
-		// Generated via https://demo.unisat.io/
-		"unisat - nested segwit": {
-			Address:   "3MqrCDcTK16rkLEbu9Wfojdf7jbUMAJBRW",
-			Message:   "hello world~",
-			Signature: "IGCFq01RhaGbMOOuPWFn5H/ZCKx+P4srkb7O3BRS7mcISazqQbS9QThf3gSmH1Vrq/RLL+1pZQZISWe/XRIr1O0=",
-		},
-		// Generated via https://demo.unisat.io/
-		"unisat - segwit native": {
-			Address:   "bc1qvhnxd953tzt4kcqpcgk83wu2r9shf59q2t4egu",
-			Message:   "hello world",
-			Signature: "INuYn+2RZPLOzOXBYffJeMSVKRwIf+XaD3SrQa+WTO/aaKR/+JzS0zJplnc3H7dN8Da3bxvrQx3rPL/MCwZ5z7s=",
-		},
-		// Generated via https://demo.unisat.io/
-		"unisat - taproot": {
-			Address:   "bc1pgc9k3vdmr9aecmwj09qg5qv550qyyrydufyfmxrsvk5474rxenuqrq4lcz",
-			Message:   "hello world",
-			Signature: "H/KLWcCfl/P34V9TdPzcSlG3sdhllArBXjypbz9BBY1GXDRCwYogO50Crznm8I9P/JAfhnojgbV5vPYSAhWA1p0=",
-		},
-	}
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	// The valid legacy and BIP-137 vectors, the inconclusive ones are asserted by TestVerifyElectrumCompatible
+	for _, vector := range lo.Filter(vectors, func(vector conformance.Vector, _ int) bool {
+		return (vector.Format == conformance.FormatLegacy || vector.Format == conformance.FormatBIP137) && vector.Expected == conformance.OutcomeValid
+	}) {
+		s.Run(vector.Name, func() {
+			net, err := vector.Params()
+			s.Require().NoError(err)
 
-	for name, tt := range tests {
-		s.Run(name, func() {
 			// Decode the address
-			address, err := btcutil.DecodeAddress(tt.Address, &chaincfg.MainNetParams)
+			address, err := btcutil.DecodeAddress(vector.Address, net)
 			s.Require().NoError(err)
 
 			// Decode the signature
-			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.Signature)
+			signatureDecoded, err := base64.StdEncoding.DecodeString(vector.Signature)
 			s.Require().NoError(err)
 
-			valid, err := generic.Verify(address, tt.Message, signatureDecoded, &chaincfg.MainNetParams)
+			valid, err := s.verify(address, vector.Message, signatureDecoded, net)
 			s.Require().NoError(err)
 			s.Require().True(valid)
 		})
 	}
 }
+
+func (s *VerifyTestSuite) TestVerifyElectrumCompatible() {
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	// The inconclusive legacy vectors are signed like Electrum, with the compressed flags for segwit addresses or a message that is trimmed
+	library := conformance.Library(verifier.WithProfile(verifier.ElectrumCompatible()))
+	for _, vector := range lo.Filter(vectors, func(vector conformance.Vector, _ int) bool {
+		return vector.Format == conformance.FormatLegacy && vector.Expected == conformance.OutcomeInconclusive
+	}) {
+		s.Run(vector.Name, func() {
+			result := library.Verify(vector.Input)
+			s.Require().NoError(result.Err)
+			s.Require().Equal(conformance.OutcomeValid, result.Outcome)
+		})
+	}
+}
+
+func (s *VerifyTestSuite) TestVerifyDoesNotModifySignature() {
+	// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+	address, err := btcutil.DecodeAddress("bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	signatureDecoded, err := base64.StdEncoding.DecodeString("KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=")
+	s.Require().NoError(err)
+	signatureCopy := append([]byte{}, signatureDecoded...)
+
+	valid, err := s.verify(address, "This is an example of a signed message.", signatureDecoded, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	s.Require().True(valid)
+	s.Require().Equal(signatureCopy, signatureDecoded)
+}
+
+// verify verifies a legacy signature with the default handler of the address type.
+func (s *VerifyTestSuite) verify(address btcutil.Address, message string, signatureDecoded []byte, net *chaincfg.Params) (bool, error) {
+	handler, found := verifier.DefaultAddressHandlers().Handler(address)
+	s.Require().True(found)
+
+	return handler.Verify(address, verifier.NewMessageDigest([]byte(message)), signatureDecoded, verifier.FormatLegacy, net)
+}
@@ -15,10 +15,6 @@ const varIntProtoVer uint32 = 0
 // Taken from https://bitcoin.stackexchange.com/a/77325
 const magicMessage = "\x18Bitcoin Signed Message:\n"
 
-// Signed message via BIP-322 are prepended with this bip322Tag
-// Taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#full
-const bip322Tag = "BIP0322-signed-message"
-
 // CreateMagicMessage builds a properly signed message.
 func CreateMagicMessage(message string) string {
 	buffer := bytes.Buffer{}
@@ -32,11 +28,23 @@ func CreateMagicMessage(message string) string {
 	return magicMessage + buffer.String() + message
 }
 
+// HashMagicMessage returns the double SHA-256 hash of the signed message, which is what legacy signatures are made over.
+// The message is written to the hash directly, instead of copying it into the magic message first.
+func HashMagicMessage(message []byte) []byte {
+	hasher := NewMagicMessageHasher(uint64(len(message)))
+	_, _ = hasher.Write(message)
+
+	return SumMagicMessageHasher(hasher)
+}
+
 // CreateMagicMessageBIP322 builds a properly signed message (in BIP-322 format).
+// The tag is not hashed for every message, the midstate of NewBIP322Hasher is used instead.
 func CreateMagicMessageBIP322(message []byte) [32]byte {
-	tagHash := sha256.Sum256([]byte(bip322Tag))
-	sum := append(tagHash[:], tagHash[:]...) // Append tagHash twice
-	sum = append(sum, message...)
+	hasher := NewBIP322Hasher()
+	_, _ = hasher.Write(message)
+
+	var sum [sha256.Size]byte
+	hasher.Sum(sum[:0])
 
-	return sha256.Sum256(sum)
+	return sum
 }
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"hash"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// bip322TagMidstate is a crypto/sha256 marshal blob, as returned by its MarshalBinary, of the state after writing the hash of the BIP-322 tag
+// 'BIP0322-signed-message' twice, which is exactly one block. Restoring it saves hashing the tag for every message, see NewBIP322Hasher.
+const bip322TagMidstate = "73686103" + // Magic and version of the marshaled state
+	"896e65a69e1821339aa0d959a7b9defc733cba8c972f02145e48b86ff83bf99c" + // State after the first block
+	"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" + // Empty block buffer
+	"0000000000000040" // Amount of bytes written (64)
+
+// bip322TagHasher is restored to bip322TagMidstate once, NewBIP322Hasher clones it for every message.
+var bip322TagHasher = newBIP322TagHasher() //nolint:gochecknoglobals // Computed once and never written to
+
+// NewMagicMessageHasher returns a SHA-256 hash that already contains the magic message prefix for a message of the passed length.
+// After writing the message, hash the sum once more to get the hash that legacy signatures are made over, see SumMagicMessageHasher.
+func NewMagicMessageHasher(length uint64) hash.Hash {
+	hasher := sha256.New()
+	_, _ = hasher.Write([]byte(magicMessage))
+
+	// If we cannot write the VarInt, just panic since that should never happen
+	if err := wire.WriteVarInt(hasher, varIntProtoVer, length); err != nil {
+		panic(err)
+	}
+
+	return hasher
+}
+
+// SumMagicMessageHasher returns the double SHA-256 hash of a hasher created by NewMagicMessageHasher.
+func SumMagicMessageHasher(hasher hash.Hash) []byte {
+	var first [sha256.Size]byte
+	sum := sha256.Sum256(hasher.Sum(first[:0]))
+
+	return sum[:]
+}
+
+// NewBIP322Hasher returns a SHA-256 hash that already contains the BIP-322 tag, the message can be written to it directly.
+// The tag is not hashed, instead the precomputed midstate is cloned.
+func NewBIP322Hasher() hash.Hash {
+	hasher, err := bip322TagHasher.Clone()
+	if err != nil {
+		// This error indicates a programming error since crypto/sha256 always supports cloning
+		panic(err)
+	}
+
+	return hasher
+}
+
+// newBIP322TagHasher returns a SHA-256 hash that is restored to bip322TagMidstate.
+func newBIP322TagHasher() hash.Cloner {
+	midstate, err := hex.DecodeString(bip322TagMidstate)
+	if err != nil {
+		// This error indicates a programming error since the midstate is predefined
+		panic(err)
+	}
+
+	hasher := sha256.New()
+	unmarshaler, ok := hasher.(encoding.BinaryUnmarshaler)
+	if !ok {
+		panic("sha256 hash does not support restoring its state")
+	}
+
+	if err := unmarshaler.UnmarshalBinary(midstate); err != nil {
+		// This error indicates a programming error since the midstate is predefined
+		panic(err)
+	}
+
+	cloner, ok := hasher.(hash.Cloner)
+	if !ok {
+		panic("sha256 hash does not support cloning")
+	}
+
+	return cloner
+}
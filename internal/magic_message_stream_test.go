@@ -0,0 +1,52 @@
+package internal_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitonicnl/verify-signed-message/internal"
+)
+
+func TestNewMagicMessageHasher(t *testing.T) {
+	t.Parallel()
+
+	message := []byte("test message")
+	hasher := internal.NewMagicMessageHasher(uint64(len(message)))
+
+	// Write in chunks, like a stream would
+	_, _ = hasher.Write(message[:4])
+	_, _ = hasher.Write(message[4:])
+
+	require.Equal(t, internal.HashMagicMessage(message), internal.SumMagicMessageHasher(hasher))
+}
+
+// Test vectors taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#message-hashing
+func TestNewBIP322Hasher(t *testing.T) {
+	t.Parallel()
+
+	hasher := internal.NewBIP322Hasher()
+	require.Equal(t, "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1", hex.EncodeToString(hasher.Sum(nil)))
+
+	hasher = internal.NewBIP322Hasher()
+	_, _ = hasher.Write([]byte("Hello "))
+	_, _ = hasher.Write([]byte("World"))
+	require.Equal(t, "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a", hex.EncodeToString(hasher.Sum(nil)))
+}
+
+func TestNewBIP322HasherMidstate(t *testing.T) {
+	t.Parallel()
+
+	// The midstate should match hashing the tag twice
+	tagHash := sha256.Sum256([]byte("BIP0322-signed-message"))
+	expected := sha256.New()
+	_, _ = expected.Write(tagHash[:])
+	_, _ = expected.Write(tagHash[:])
+	_, _ = expected.Write([]byte("random message"))
+
+	hasher := internal.NewBIP322Hasher()
+	_, _ = hasher.Write([]byte("random message"))
+	require.Equal(t, expected.Sum(nil), hasher.Sum(nil))
+}
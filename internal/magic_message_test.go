@@ -16,6 +16,13 @@ func TestCreateMagicMessage(t *testing.T) {
 	require.Equal(t, "\x18Bitcoin Signed Message:\n\x0Erandom message", message)
 }
 
+func TestHashMagicMessage(t *testing.T) {
+	t.Parallel()
+
+	msgHash := internal.HashMagicMessage([]byte("test message"))
+	require.Equal(t, "1226179ddf6383fbcf5102c9492538b7206c739ae79eb064408c2abd67d39bed", hex.EncodeToString(msgHash))
+}
+
 // Test vectors taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#message-hashing
 func TestCreateMagicMessageBIP322(t *testing.T) {
 	t.Parallel()
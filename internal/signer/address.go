@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// AddressType is a kind of single key address that can be signed for.
+type AddressType string
+
+const (
+	// AddressTypeP2PKHUncompressed is a P2PKH address of the uncompressed public key.
+	AddressTypeP2PKHUncompressed AddressType = "p2pkh-uncompressed"
+	// AddressTypeP2PKH is a P2PKH address of the compressed public key.
+	AddressTypeP2PKH AddressType = "p2pkh"
+	// AddressTypeP2SHP2WPKH is a P2WPKH address nested in a P2SH address.
+	AddressTypeP2SHP2WPKH AddressType = "p2sh-p2wpkh"
+	// AddressTypeP2WPKH is a native segwit P2WPKH address.
+	AddressTypeP2WPKH AddressType = "p2wpkh"
+	// AddressTypeP2WSH is a P2WSH address of the witness script '<public key> OP_CHECKSIG', see WitnessScript.
+	AddressTypeP2WSH AddressType = "p2wsh"
+	// AddressTypeP2TR is a taproot address of the public key without a script path, as specified by BIP-86.
+	AddressTypeP2TR AddressType = "p2tr"
+)
+
+// Address returns the address of the passed type for the public key on the passed network.
+func Address(publicKey *btcec.PublicKey, addressType AddressType, net *chaincfg.Params) (btcutil.Address, error) {
+	switch addressType {
+	case AddressTypeP2PKHUncompressed:
+		return btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey.SerializeUncompressed()), net)
+	case AddressTypeP2PKH:
+		return btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey.SerializeCompressed()), net)
+	case AddressTypeP2SHP2WPKH:
+		redeemScript, err := RedeemScript(publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return btcutil.NewAddressScriptHash(redeemScript, net)
+	case AddressTypeP2WPKH:
+		return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(publicKey.SerializeCompressed()), net)
+	case AddressTypeP2WSH:
+		witnessScript, err := WitnessScript(publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		witnessScriptHash := sha256.Sum256(witnessScript)
+
+		return btcutil.NewAddressWitnessScriptHash(witnessScriptHash[:], net)
+	case AddressTypeP2TR:
+		return btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(publicKey)), net)
+	default:
+		return nil, fmt.Errorf("unknown address type '%s'", addressType)
+	}
+}
+
+// RedeemScript returns the redeem script of the P2SH-P2WPKH address of the public key, which is 'OP_0 <public key hash>'.
+func RedeemScript(publicKey *btcec.PublicKey) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(btcutil.Hash160(publicKey.SerializeCompressed())).Script()
+}
+
+// WitnessScript returns the witness script of the P2WSH address of the public key, which is '<public key> OP_CHECKSIG'.
+func WitnessScript(publicKey *btcec.PublicKey) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddData(publicKey.SerializeCompressed()).AddOp(txscript.OP_CHECKSIG).Script()
+}
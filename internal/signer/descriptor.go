@@ -0,0 +1,137 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/samber/lo"
+)
+
+// Character sets of the checksum of a descriptor, as specified by BIP-380.
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorScript is a script expression of a descriptor with a single key, like 'wpkh(KEY)'.
+type descriptorScript struct {
+	prefix      string
+	suffix      string
+	addressType AddressType
+}
+
+// descriptorScripts returns the script expressions of the address types, nested expressions come first.
+func descriptorScripts() []descriptorScript {
+	return []descriptorScript{
+		{prefix: "sh(wpkh(", suffix: "))", addressType: AddressTypeP2SHP2WPKH},
+		{prefix: "wsh(pk(", suffix: "))", addressType: AddressTypeP2WSH},
+		{prefix: "pkh(", suffix: ")", addressType: AddressTypeP2PKH},
+		{prefix: "wpkh(", suffix: ")", addressType: AddressTypeP2WPKH},
+		{prefix: "tr(", suffix: ")", addressType: AddressTypeP2TR},
+	}
+}
+
+// ParseDescriptor parses an output descriptor of a single WIF private key and returns the key with the type of its address.
+// The supported descriptors are 'pkh(KEY)', 'sh(wpkh(KEY))', 'wpkh(KEY)', 'wsh(pk(KEY))' and 'tr(KEY)', with an optional key origin and checksum.
+// An uncompressed key is only allowed in 'pkh(KEY)', which results in AddressTypeP2PKHUncompressed.
+func ParseDescriptor(descriptor string) (*btcutil.WIF, AddressType, error) {
+	descriptor, checksum, hasChecksum := strings.Cut(strings.TrimSpace(descriptor), "#")
+	if hasChecksum {
+		expected, err := descriptorChecksum(descriptor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if checksum != expected {
+			return nil, "", fmt.Errorf("invalid descriptor checksum '%s', expected '%s'", checksum, expected)
+		}
+	}
+
+	for _, script := range descriptorScripts() {
+		if !strings.HasPrefix(descriptor, script.prefix) || !strings.HasSuffix(descriptor, script.suffix) {
+			continue
+		}
+
+		key := strings.TrimSuffix(strings.TrimPrefix(descriptor, script.prefix), script.suffix)
+
+		// The origin of the key, like '[d34db33f/84h/0h/0h]', is not needed to sign
+		if strings.HasPrefix(key, "[") {
+			_, key, _ = strings.Cut(key, "]")
+		}
+
+		wif, err := btcutil.DecodeWIF(key)
+		if err != nil {
+			return nil, "", errors.New("only WIF private keys are supported in descriptors")
+		}
+
+		switch {
+		case wif.CompressPubKey:
+			return wif, script.addressType, nil
+		case script.addressType == AddressTypeP2PKH:
+			return wif, AddressTypeP2PKHUncompressed, nil
+		default:
+			return nil, "", fmt.Errorf("uncompressed keys are not allowed in '%s'", script.prefix)
+		}
+	}
+
+	expected := lo.Map(descriptorScripts(), func(script descriptorScript, _ int) string { return script.prefix + "KEY" + script.suffix })
+
+	return nil, "", fmt.Errorf("unsupported descriptor, expected one of: %s", strings.Join(expected, ", "))
+}
+
+// descriptorChecksum returns the checksum of a descriptor without its checksum, as specified by BIP-380.
+func descriptorChecksum(descriptor string) (string, error) {
+	symbols := []uint64{}
+	groups := []uint64{}
+	for _, char := range descriptor {
+		value := strings.IndexRune(descriptorInputCharset, char)
+		if value < 0 {
+			return "", fmt.Errorf("invalid character '%c' in descriptor", char)
+		}
+
+		// Every character is split into a symbol and a group, the groups of three characters are combined into another symbol
+		symbols = append(symbols, uint64(value&31))
+		groups = append(groups, uint64(value>>5))
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+
+	switch len(groups) {
+	case 1:
+		symbols = append(symbols, groups[0])
+	case 2:
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+
+	checksum := descriptorPolymod(append(symbols, 0, 0, 0, 0, 0, 0, 0, 0)) ^ 1
+
+	builder := strings.Builder{}
+	for i := range 8 {
+		builder.WriteByte(descriptorChecksumCharset[(checksum>>(5*(7-i)))&31])
+	}
+
+	return builder.String(), nil
+}
+
+// descriptorPolymod computes the BCH code of the symbols of a descriptor.
+func descriptorPolymod(symbols []uint64) uint64 {
+	generator := [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+	checksum := uint64(1)
+	for _, value := range symbols {
+		top := checksum >> 35
+		checksum = (checksum&0x7ffffffff)<<5 ^ value
+
+		for i := range generator {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+
+	return checksum
+}
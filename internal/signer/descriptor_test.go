@@ -0,0 +1,103 @@
+package signer_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/signer"
+)
+
+type DescriptorTestSuite struct {
+	suite.Suite
+}
+
+func TestDescriptorTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(DescriptorTestSuite))
+}
+
+func (s *DescriptorTestSuite) TestParseDescriptor() {
+	// Taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+	key := "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+
+	wif, err := btcutil.DecodeWIF(key)
+	s.Require().NoError(err)
+
+	uncompressed, err := btcutil.NewWIF(wif.PrivKey, &chaincfg.MainNetParams, false)
+	s.Require().NoError(err)
+
+	tests := map[string]struct {
+		descriptor          string
+		expectedAddressType signer.AddressType
+		expectedError       string
+	}{
+		"pkh": {
+			descriptor:          "pkh(" + key + ")#hsdw3pkm",
+			expectedAddressType: signer.AddressTypeP2PKH,
+		},
+		"pkh - uncompressed": {
+			descriptor:          "pkh(" + uncompressed.String() + ")",
+			expectedAddressType: signer.AddressTypeP2PKHUncompressed,
+		},
+		"sh(wpkh)": {
+			descriptor:          "sh(wpkh(" + key + "))#q9zfeu38",
+			expectedAddressType: signer.AddressTypeP2SHP2WPKH,
+		},
+		"wpkh": {
+			descriptor:          "wpkh(" + key + ")#2cmgp09h",
+			expectedAddressType: signer.AddressTypeP2WPKH,
+		},
+		"wpkh - key origin": {
+			descriptor:          "wpkh([d34db33f/84h/0h/0h]" + key + ")",
+			expectedAddressType: signer.AddressTypeP2WPKH,
+		},
+		"wsh(pk)": {
+			descriptor:          "wsh(pk(" + key + "))#ljswte3y",
+			expectedAddressType: signer.AddressTypeP2WSH,
+		},
+		"tr": {
+			descriptor:          " tr(" + key + ")#p48uuz6v\n",
+			expectedAddressType: signer.AddressTypeP2TR,
+		},
+		"invalid checksum": {
+			descriptor:    "wpkh(" + key + ")#2cmgp09q",
+			expectedError: "invalid descriptor checksum '2cmgp09q', expected '2cmgp09h'",
+		},
+		"invalid character": {
+			descriptor:    "wpkh(" + key + "é)#2cmgp09h",
+			expectedError: "invalid character 'é' in descriptor",
+		},
+		"public key": {
+			descriptor:    "wpkh(02c7f12003196442943d8588e01aee840423cc54fc1521526a3b85c2b0cbd58872)",
+			expectedError: "only WIF private keys are supported in descriptors",
+		},
+		"uncompressed segwit": {
+			descriptor:    "wpkh(" + uncompressed.String() + ")",
+			expectedError: "uncompressed keys are not allowed in 'wpkh('",
+		},
+		"unsupported": {
+			descriptor:    "multi(1," + key + ")",
+			expectedError: "unsupported descriptor, expected one of: sh(wpkh(KEY)), wsh(pk(KEY)), pkh(KEY), wpkh(KEY), tr(KEY)",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			parsed, addressType, err := signer.ParseDescriptor(tt.descriptor)
+			if tt.expectedError != "" {
+				s.Require().EqualError(err, tt.expectedError)
+
+				return
+			}
+
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expectedAddressType, addressType)
+			s.Require().Equal(wif.PrivKey.Serialize(), parsed.PrivKey.Serialize())
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package signer holds the tools to create signed messages, which are verified by the other internal packages.
+//
+// It supports legacy and BIP-137 signatures with any recovery flag and BIP-322 simple and full signatures for single key addresses.
+// The key and address type can be taken from a descriptor of a single WIF private key, see ParseDescriptor.
+package signer
@@ -0,0 +1,138 @@
+package signer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+)
+
+// Legacy creates a legacy signature of the hash of the magic message, see internal.HashMagicMessage.
+// The recovery flag is the key ID added to the first flag of a family, for example 27 (uncompressed), 31 (compressed and Electrum) or 39 (BIP-137 (Trezor) P2WPKH).
+func Legacy(privateKey *btcec.PrivateKey, messageHash []byte, compressed bool, baseFlag int) []byte {
+	signature := ecdsa.SignCompact(privateKey, messageHash, compressed)
+	signature[0] = byte(baseFlag + flags.GetKeyID(int(signature[0])))
+
+	return signature
+}
+
+// LegacyFamily returns the recovery flag family of a legacy signature for the address type, it reports false when no family signs for it.
+// Electrum signs every segwit address with the flags of a compressed P2PKH address, BIP-137 (Trezor) has a family for every type of segwit address.
+func LegacyFamily(addressType AddressType, bip137 bool) (flags.Family, bool) {
+	switch {
+	case addressType == AddressTypeP2PKHUncompressed:
+		return flags.FamilyUncompressed, true
+	case addressType == AddressTypeP2PKH:
+		return flags.FamilyCompressed, true
+	case bip137 && addressType == AddressTypeP2SHP2WPKH:
+		return flags.FamilyTrezorP2SHAndP2WPKH, true
+	case bip137 && addressType == AddressTypeP2WPKH:
+		return flags.FamilyTrezorP2WPKH, true
+	case !bip137 && addressType != AddressTypeP2WSH:
+		return flags.FamilyElectrumSegwit, true
+	default:
+		return "", false
+	}
+}
+
+// Simple creates a BIP-322 simple signature of the BIP-322 tagged hash of the message, which is the encoded witness of the toSign transaction.
+// Only P2WPKH and P2TR addresses are supported, like the verification of simple signatures.
+func Simple(privateKey *btcec.PrivateKey, addressType AddressType, messageHash [32]byte, net *chaincfg.Params) ([]byte, error) {
+	if addressType != AddressTypeP2WPKH && addressType != AddressTypeP2TR {
+		return nil, fmt.Errorf("address type '%s' is not supported for simple signatures", addressType)
+	}
+
+	toSign, err := Full(privateKey, addressType, messageHash, net)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncodeWitness(toSign.TxIn[0].Witness)
+}
+
+// Full creates a BIP-322 full signature of the BIP-322 tagged hash of the message, which is the signed toSign transaction.
+// The signature of P2TR addresses is made with the key path, every other signature uses SIGHASH_ALL.
+func Full(privateKey *btcec.PrivateKey, addressType AddressType, messageHash [32]byte, net *chaincfg.Params) (*wire.MsgTx, error) {
+	address, err := Address(privateKey.PubKey(), addressType, net)
+	if err != nil {
+		return nil, err
+	}
+
+	toSpend, err := bip322.BuildToSpendTxFromHash(messageHash, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not build spending transaction: %w", err)
+	}
+
+	toSign := bip322.BuildToSignTx(toSpend)
+	pkScript := toSpend.TxOut[0].PkScript
+	sigHashes := txscript.NewTxSigHashes(toSign, txscript.NewCannedPrevOutputFetcher(pkScript, 0))
+	input := toSign.TxIn[0]
+
+	switch addressType {
+	case AddressTypeP2PKHUncompressed, AddressTypeP2PKH:
+		input.SignatureScript, err = txscript.SignatureScript(toSign, 0, pkScript, txscript.SigHashAll, privateKey, addressType == AddressTypeP2PKH)
+	case AddressTypeP2SHP2WPKH:
+		var redeemScript []byte
+		if redeemScript, err = RedeemScript(privateKey.PubKey()); err != nil {
+			return nil, err
+		}
+
+		// The witness signs the P2WPKH script, the signature script only reveals it
+		if input.Witness, err = txscript.WitnessSignature(toSign, sigHashes, 0, 0, redeemScript, txscript.SigHashAll, privateKey, true); err != nil {
+			return nil, fmt.Errorf("could not sign transaction: %w", err)
+		}
+
+		input.SignatureScript, err = txscript.NewScriptBuilder().AddData(redeemScript).Script()
+	case AddressTypeP2WPKH:
+		input.Witness, err = txscript.WitnessSignature(toSign, sigHashes, 0, 0, pkScript, txscript.SigHashAll, privateKey, true)
+	case AddressTypeP2WSH:
+		var witnessScript, signature []byte
+		if witnessScript, err = WitnessScript(privateKey.PubKey()); err != nil {
+			return nil, err
+		}
+
+		signature, err = txscript.RawTxInWitnessSignature(toSign, sigHashes, 0, 0, witnessScript, txscript.SigHashAll, privateKey)
+		input.Witness = wire.TxWitness{signature, witnessScript}
+	case AddressTypeP2TR:
+		input.Witness, err = txscript.TaprootWitnessSignature(toSign, sigHashes, 0, 0, pkScript, txscript.SigHashDefault, privateKey)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not sign transaction: %w", err)
+	}
+
+	return toSign, nil
+}
+
+// EncodeWitness encodes a witness stack as a BIP-322 simple signature, which is the inverse of bip322.SimpleSigToWitness.
+func EncodeWitness(witness wire.TxWitness) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := wire.WriteVarInt(&buffer, 0, uint64(len(witness))); err != nil {
+		return nil, err
+	}
+
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buffer, 0, item); err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// EncodeFull encodes a signed toSign transaction as a BIP-322 full signature, which is the inverse of bip322.DecodeFull.
+func EncodeFull(toSign *wire.MsgTx) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := toSign.Serialize(&buffer); err != nil {
+		return nil, fmt.Errorf("could not encode transaction: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
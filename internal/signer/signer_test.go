@@ -0,0 +1,188 @@
+package signer_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal"
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+	"github.com/bitonicnl/verify-signed-message/internal/generic"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+	"github.com/bitonicnl/verify-signed-message/internal/signer"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type SignerTestSuite struct {
+	suite.Suite
+
+	privateKey *btcec.PrivateKey
+}
+
+func TestSignerTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(SignerTestSuite))
+}
+
+func (s *SignerTestSuite) SetupTest() {
+	// Taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+	wif, err := btcutil.DecodeWIF("L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k")
+	s.Require().NoError(err)
+
+	s.privateKey = wif.PrivKey
+}
+
+func (s *SignerTestSuite) TestAddress() {
+	tests := map[signer.AddressType]string{
+		signer.AddressTypeP2PKHUncompressed: "169ojqRJ3d4f7aNMu86nAAwGJyeykmByFU",
+		signer.AddressTypeP2PKH:             "14vV3aCHBeStb5bkenkNHbe2YAFinYdXgc",
+		signer.AddressTypeP2SHP2WPKH:        "37qyp7jQAzqb2rCBpMvVtLDuuzKAUCVnJb",
+		// Taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+		signer.AddressTypeP2WPKH: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		signer.AddressTypeP2WSH:  "bc1qumw0r30366ya78zvcv6tyr0ffgtqg9vm9jt6mud7slmehggpd0jqucu77z",
+		signer.AddressTypeP2TR:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+	}
+
+	for addressType, expected := range tests {
+		s.Run(string(addressType), func() {
+			address, err := signer.Address(s.privateKey.PubKey(), addressType, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+			s.Require().Equal(expected, address.EncodeAddress())
+		})
+	}
+
+	_, err := signer.Address(s.privateKey.PubKey(), "unknown", &chaincfg.MainNetParams)
+	s.Require().EqualError(err, "unknown address type 'unknown'")
+}
+
+func (s *SignerTestSuite) TestLegacy() {
+	messageHash := internal.HashMagicMessage([]byte("Hello World"))
+
+	tests := map[string]struct {
+		addressType signer.AddressType
+		compressed  bool
+		baseFlag    int
+	}{
+		"uncompressed": {addressType: signer.AddressTypeP2PKHUncompressed, compressed: false, baseFlag: 27},
+		"compressed":   {addressType: signer.AddressTypeP2PKH, compressed: true, baseFlag: 31},
+		"electrum":     {addressType: signer.AddressTypeP2WPKH, compressed: true, baseFlag: 31},
+		"trezor":       {addressType: signer.AddressTypeP2SHP2WPKH, compressed: true, baseFlag: 35},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			signature := signer.Legacy(s.privateKey, messageHash, tt.compressed, tt.baseFlag)
+			s.Require().Len(signature, generic.ExpectedSignatureLength)
+			s.Require().InDelta(tt.baseFlag, int(signature[0]), 3)
+
+			address, err := signer.Address(s.privateKey.PubKey(), tt.addressType, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+
+			handler, found := verifier.DefaultAddressHandlers().Handler(address)
+			s.Require().True(found)
+
+			valid, err := handler.Verify(address, verifier.MessageDigest{Legacy: messageHash, BIP322: nil}, signature, verifier.FormatLegacy, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+			s.Require().True(valid)
+		})
+	}
+}
+
+func (s *SignerTestSuite) TestLegacyFamily() {
+	tests := map[string]struct {
+		addressType       signer.AddressType
+		bip137            bool
+		expectedFamily    flags.Family
+		expectedSupported bool
+	}{
+		"uncompressed":         {addressType: signer.AddressTypeP2PKHUncompressed, bip137: false, expectedFamily: flags.FamilyUncompressed, expectedSupported: true},
+		"compressed":           {addressType: signer.AddressTypeP2PKH, bip137: true, expectedFamily: flags.FamilyCompressed, expectedSupported: true},
+		"electrum":             {addressType: signer.AddressTypeP2TR, bip137: false, expectedFamily: flags.FamilyElectrumSegwit, expectedSupported: true},
+		"trezor - p2sh-p2wpkh": {addressType: signer.AddressTypeP2SHP2WPKH, bip137: true, expectedFamily: flags.FamilyTrezorP2SHAndP2WPKH, expectedSupported: true},
+		"trezor - p2wpkh":      {addressType: signer.AddressTypeP2WPKH, bip137: true, expectedFamily: flags.FamilyTrezorP2WPKH, expectedSupported: true},
+		"trezor - p2tr":        {addressType: signer.AddressTypeP2TR, bip137: true, expectedFamily: "", expectedSupported: false},
+		"p2wsh":                {addressType: signer.AddressTypeP2WSH, bip137: false, expectedFamily: "", expectedSupported: false},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			family, supported := signer.LegacyFamily(tt.addressType, tt.bip137)
+			s.Require().Equal(tt.expectedFamily, family)
+			s.Require().Equal(tt.expectedSupported, supported)
+		})
+	}
+}
+
+func (s *SignerTestSuite) TestSimple() {
+	messageHash := internal.CreateMagicMessageBIP322([]byte("Hello World"))
+
+	// RFC 6979 is deterministic, so this matches the test vector of BIP-322
+	signature, err := signer.Simple(s.privateKey, signer.AddressTypeP2WPKH, messageHash, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	s.Require().Equal("AkgwRQIhAOzyynlqt93lOKJr+wmmxIens//zPzl9tqIOua93wO6MAiBi5n5EyAcPScOjf1lAqIUIQtr3zKNeavYabHyR8eGhowEhAsfxIAMZZEKUPYWI4BruhAQjzFT8FSFSajuFwrDL1Yhy", base64.StdEncoding.EncodeToString(signature))
+
+	signature, err = signer.Simple(s.privateKey, signer.AddressTypeP2TR, messageHash, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	address, err := signer.Address(s.privateKey.PubKey(), signer.AddressTypeP2TR, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	valid, err := bip322.VerifyHash(address, messageHash, signature)
+	s.Require().NoError(err)
+	s.Require().True(valid)
+
+	_, err = signer.Simple(s.privateKey, signer.AddressTypeP2PKH, messageHash, &chaincfg.MainNetParams)
+	s.Require().EqualError(err, "address type 'p2pkh' is not supported for simple signatures")
+}
+
+func (s *SignerTestSuite) TestFull() {
+	messageHash := internal.CreateMagicMessageBIP322([]byte("Hello World"))
+
+	for _, addressType := range []signer.AddressType{
+		signer.AddressTypeP2PKHUncompressed,
+		signer.AddressTypeP2PKH,
+		signer.AddressTypeP2SHP2WPKH,
+		signer.AddressTypeP2WPKH,
+		signer.AddressTypeP2WSH,
+		signer.AddressTypeP2TR,
+	} {
+		s.Run(string(addressType), func() {
+			toSign, err := signer.Full(s.privateKey, addressType, messageHash, &chaincfg.TestNet3Params)
+			s.Require().NoError(err)
+
+			// The encoding round trips
+			encoded, err := signer.EncodeFull(toSign)
+			s.Require().NoError(err)
+
+			decoded, err := bip322.DecodeFull(encoded)
+			s.Require().NoError(err)
+
+			address, err := signer.Address(s.privateKey.PubKey(), addressType, &chaincfg.TestNet3Params)
+			s.Require().NoError(err)
+
+			valid, err := bip322.VerifyFull(address, messageHash, decoded)
+			s.Require().NoError(err)
+			s.Require().True(valid)
+		})
+	}
+
+	_, err := signer.Full(s.privateKey, "unknown", messageHash, &chaincfg.MainNetParams)
+	s.Require().EqualError(err, "unknown address type 'unknown'")
+}
+
+func (s *SignerTestSuite) TestEncodeWitness() {
+	encoded, err := signer.EncodeWitness(wire.TxWitness{{0x01, 0x02}, {}})
+	s.Require().NoError(err)
+	s.Require().Equal([]byte{0x02, 0x02, 0x01, 0x02, 0x00}, encoded)
+
+	witness, err := bip322.SimpleSigToWitness(encoded)
+	s.Require().NoError(err)
+	s.Require().Equal([][]byte{{0x01, 0x02}, {}}, witness)
+}
@@ -0,0 +1,234 @@
+package verifier
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Markers of the armored signed message format, as used by Electrum, Coldcard, Armory and others.
+const (
+	armoredBeginMessage       = "-----BEGIN BITCOIN SIGNED MESSAGE-----"
+	armoredBeginSignature     = "-----BEGIN SIGNATURE-----"
+	armoredBeginArmorySig     = "-----BEGIN BITCOIN SIGNATURE-----"
+	armoredEndMessage         = "-----END BITCOIN SIGNED MESSAGE-----"
+	armoredEndArmorySignature = "-----END BITCOIN SIGNATURE-----"
+	armoredEndSignature       = "-----END SIGNATURE-----"
+	armoredAddressHeader      = "Address"
+	armoredDashEscapePrefix   = "- "
+	armoredChecksumPrefix     = "="
+	armoredChecksumLength     = 5
+)
+
+// armoredHeaderNames returns the names of the headers that are written by Armory, other lines are never mistaken for headers.
+func armoredHeaderNames() []string {
+	return []string{armoredAddressHeader, "Charset", "Comment", "Hash", "Version"}
+}
+
+// armoredMarkers returns all markers, message lines that equal one of them are dash-escaped by FormatArmored.
+func armoredMarkers() []string {
+	return []string{
+		armoredBeginMessage, armoredBeginSignature, armoredBeginArmorySig,
+		armoredEndMessage, armoredEndArmorySignature, armoredEndSignature,
+	}
+}
+
+// ParseArmored parses an armored signed message block, for example:
+//
+//	-----BEGIN BITCOIN SIGNED MESSAGE-----
+//	test message
+//	-----BEGIN SIGNATURE-----
+//	1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5
+//	IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=
+//	-----END BITCOIN SIGNED MESSAGE-----
+//
+// Both LF and CRLF line endings are supported, the message is always returned with LF line endings.
+// Message lines that equal a marker are dash-escaped (prefixed with `- `) by FormatArmored, the escape is removed.
+// The layout of Coldcard and Sparrow, which uses `-----BEGIN BITCOIN SIGNATURE-----` and `-----END BITCOIN SIGNATURE-----`, is supported as well.
+// Armory uses the same markers, its layout is recognized by the headers (like `Comment:`) directly after a begin marker and followed by an empty line,
+// or by the checksum line (like `=Ab1c`) after the signature. Only in that layout, the headers are skipped, an `Address:` header is used as the address
+// and all message lines are dash-escaped.
+func ParseArmored(r io.Reader) (SignedMessage, error) {
+	lines, err := readArmoredLines(r)
+	if err != nil {
+		return SignedMessage{}, err
+	}
+
+	// Find the start of the message, anything before it is ignored
+	begin := indexOfLine(lines, 0, armoredBeginMessage)
+	if begin < 0 {
+		return SignedMessage{}, fmt.Errorf("could not find '%s'", armoredBeginMessage)
+	}
+
+	// Find the start of the signature, Coldcard, Sparrow and Armory use a different marker
+	signatureBegin := indexOfLine(lines, begin+1, armoredBeginSignature, armoredBeginArmorySig)
+	if signatureBegin < 0 {
+		return SignedMessage{}, fmt.Errorf("could not find '%s'", armoredBeginSignature)
+	}
+
+	signatureEnd := indexOfLine(lines, signatureBegin+1, armoredEndMessage, armoredEndArmorySignature, armoredEndSignature)
+	if signatureEnd < 0 {
+		return SignedMessage{}, fmt.Errorf("could not find '%s'", armoredEndMessage)
+	}
+
+	// Only Armory writes headers or a checksum line, Coldcard and Sparrow use the same marker without them
+	messageHeaders, armoryMessageLines := splitArmoredHeaders(lines[begin+1 : signatureBegin])
+	signatureHeaders, armorySignatureLines := splitArmoredHeaders(lines[signatureBegin+1 : signatureEnd])
+	isArmory := strings.TrimSpace(lines[signatureBegin]) == armoredBeginArmorySig &&
+		(len(messageHeaders) > 0 || len(signatureHeaders) > 0 || hasArmoredChecksum(nonEmptyLines(armorySignatureLines)))
+
+	// Grab the message, without any headers
+	messageLines := lines[begin+1 : signatureBegin]
+	if isArmory {
+		messageLines = armoryMessageLines
+	}
+
+	message := make([]string, 0, len(messageLines))
+	for _, line := range messageLines {
+		if isArmory || isEscapedMarker(line) {
+			line = strings.TrimPrefix(line, armoredDashEscapePrefix)
+		}
+
+		message = append(message, line)
+	}
+
+	// Grab the address and signature
+	headers, signatureLines := map[string]string{}, lines[signatureBegin+1:signatureEnd]
+	if isArmory {
+		headers, signatureLines = signatureHeaders, armorySignatureLines
+	}
+	signatureLines = nonEmptyLines(signatureLines)
+
+	address, hasAddress := headers[armoredAddressHeader]
+	if !hasAddress {
+		if len(signatureLines) == 0 {
+			return SignedMessage{}, errors.New("armored signature does not contain an address")
+		}
+
+		address, signatureLines = signatureLines[0], signatureLines[1:]
+	}
+
+	// Drop the checksum line of the Armory layout, which is not part of the signature
+	if isArmory && hasArmoredChecksum(signatureLines) {
+		signatureLines = signatureLines[:len(signatureLines)-1]
+	}
+
+	if len(signatureLines) == 0 {
+		return SignedMessage{}, errors.New("armored signature does not contain a signature")
+	}
+
+	return SignedMessage{
+		Address:   strings.TrimSpace(address),
+		Message:   strings.Join(message, "\n"),
+		Signature: strings.Join(signatureLines, ""),
+	}, nil
+}
+
+// FormatArmored formats a SignedMessage as an armored signed message block, in the layout used by Electrum.
+// Message lines that equal a marker, or an escaped marker, are dash-escaped so ParseArmored returns the message as is.
+// CRLF line endings in the message are written as is, but ParseArmored returns them as LF, so the signature of such a message no longer verifies.
+func FormatArmored(signedMessage SignedMessage) string {
+	builder := strings.Builder{}
+	builder.WriteString(armoredBeginMessage + "\n")
+	for line := range strings.SplitSeq(signedMessage.Message, "\n") {
+		if isEscapedMarker(line) {
+			builder.WriteString(armoredDashEscapePrefix)
+		}
+
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString(armoredBeginSignature + "\n")
+	builder.WriteString(signedMessage.Address + "\n")
+	builder.WriteString(signedMessage.Signature + "\n")
+	builder.WriteString(armoredEndMessage + "\n")
+
+	return builder.String()
+}
+
+// readArmoredLines reads all lines, without their LF or CRLF line endings.
+func readArmoredLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 || err == nil {
+			lines = append(lines, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read armored message: %w", err)
+		}
+	}
+}
+
+// indexOfLine returns the index of the first line at or after start that matches any of the markers, ignoring surrounding whitespace.
+func indexOfLine(lines []string, start int, markers ...string) int {
+	for i := start; i < len(lines); i++ {
+		for _, marker := range markers {
+			if strings.TrimSpace(lines[i]) == marker {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitArmoredHeaders splits the headers from the content. Headers are only recognized if they are directly followed by an empty line.
+func splitArmoredHeaders(lines []string) (map[string]string, []string) {
+	headers := make(map[string]string)
+
+	for i, line := range lines {
+		if line == "" && i > 0 {
+			return headers, lines[i+1:]
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found || !lo.Contains(armoredHeaderNames(), name) {
+			break
+		}
+
+		headers[name] = strings.TrimPrefix(value, " ")
+	}
+
+	return map[string]string{}, lines
+}
+
+// hasArmoredChecksum reports whether the last of the signature lines is the checksum line of the Armory layout, which follows the signature.
+func hasArmoredChecksum(signatureLines []string) bool {
+	if len(signatureLines) < 2 {
+		return false
+	}
+
+	last := signatureLines[len(signatureLines)-1]
+
+	return strings.HasPrefix(last, armoredChecksumPrefix) && len(last) == armoredChecksumLength
+}
+
+// isEscapedMarker reports whether the line is a marker after removing any number of dash-escapes, ignoring surrounding whitespace.
+func isEscapedMarker(line string) bool {
+	for strings.HasPrefix(line, armoredDashEscapePrefix) {
+		line = strings.TrimPrefix(line, armoredDashEscapePrefix)
+	}
+
+	return lo.Contains(armoredMarkers(), strings.TrimSpace(line))
+}
+
+// nonEmptyLines returns the lines that contain more than whitespace, with the whitespace removed.
+func nonEmptyLines(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,197 @@
+package verifier_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type ArmoredTestSuite struct {
+	suite.Suite
+}
+
+func TestArmoredTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ArmoredTestSuite))
+}
+
+func (s *ArmoredTestSuite) TestParseArmored() {
+	expected := verifier.SignedMessage{
+		Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		Message:   "test message",
+		Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+	}
+
+	tests := map[string]struct {
+		armored  string
+		expected verifier.SignedMessage
+	}{
+		"electrum": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\ntest message\n-----BEGIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNED MESSAGE-----\n",
+			expected: expected,
+		},
+		"crlf and surrounding text": {
+			armored: "Some text before\r\n-----BEGIN BITCOIN SIGNED MESSAGE-----\r\ntest message\r\n-----BEGIN SIGNATURE-----\r\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\r\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\r\n" +
+				"-----END BITCOIN SIGNED MESSAGE-----\r\nSome text after",
+			expected: expected,
+		},
+		"multi-line message and wrapped signature": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\nfirst line\n\nthird line\n-----BEGIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN\n79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNED MESSAGE-----",
+			expected: verifier.SignedMessage{Address: expected.Address, Message: "first line\n\nthird line", Signature: expected.Signature},
+		},
+		"armory": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\nComment: Signed by Bitcoin Armory v0.92.3\n\n- test message\n" +
+				"-----BEGIN BITCOIN SIGNATURE-----\nAddress: 1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\n\n" +
+				"IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n=Ab1c\n" +
+				"-----END BITCOIN SIGNATURE-----\n",
+			expected: expected,
+		},
+		"armory - checksum only": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\n- test message\n-----BEGIN BITCOIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n=Ab1c\n" +
+				"-----END BITCOIN SIGNATURE-----\n",
+			expected: expected,
+		},
+		"coldcard": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\n- item one\n- item two\n-----BEGIN BITCOIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNATURE-----\n",
+			expected: verifier.SignedMessage{Address: expected.Address, Message: "- item one\n- item two", Signature: expected.Signature},
+		},
+		"header-like message line": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\nNote: this is not a header\n-----BEGIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNED MESSAGE-----\n",
+			expected: verifier.SignedMessage{Address: expected.Address, Message: "Note: this is not a header", Signature: expected.Signature},
+		},
+		"header-like message line followed by an empty line": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\nComment: hello\n\nbody\n-----BEGIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNED MESSAGE-----\n",
+			expected: verifier.SignedMessage{Address: expected.Address, Message: "Comment: hello\n\nbody", Signature: expected.Signature},
+		},
+		"armory - unknown header": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\nNote: hello\n\n- test message\n" +
+				"-----BEGIN BITCOIN SIGNATURE-----\nAddress: 1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\n\n" +
+				"IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNATURE-----\n",
+			expected: verifier.SignedMessage{Address: expected.Address, Message: "Note: hello\n\ntest message", Signature: expected.Signature},
+		},
+		"dash-escaped marker": {
+			armored: "-----BEGIN BITCOIN SIGNED MESSAGE-----\n- -----BEGIN SIGNATURE-----\n- not escaped\n-----BEGIN SIGNATURE-----\n" +
+				"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n" +
+				"-----END BITCOIN SIGNED MESSAGE-----\n",
+			expected: verifier.SignedMessage{Address: expected.Address, Message: "-----BEGIN SIGNATURE-----\n- not escaped", Signature: expected.Signature},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			signedMessage, err := verifier.ParseArmored(strings.NewReader(tt.armored))
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expected, signedMessage)
+		})
+	}
+}
+
+func (s *ArmoredTestSuite) TestParseArmoredVerifies() {
+	armored := "-----BEGIN BITCOIN SIGNED MESSAGE-----\r\ntest message\r\n-----BEGIN SIGNATURE-----\r\n" +
+		"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\r\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\r\n" +
+		"-----END BITCOIN SIGNED MESSAGE-----\r\n"
+
+	signedMessage, err := verifier.ParseArmored(strings.NewReader(armored))
+	s.Require().NoError(err)
+
+	valid, err := verifier.Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().True(valid)
+}
+
+func (s *ArmoredTestSuite) TestParseArmoredErrors() {
+	tests := map[string]struct {
+		armored       string
+		expectedError string
+	}{
+		"empty": {
+			armored:       "",
+			expectedError: "could not find '-----BEGIN BITCOIN SIGNED MESSAGE-----'",
+		},
+		"missing signature": {
+			armored:       "-----BEGIN BITCOIN SIGNED MESSAGE-----\ntest message\n-----END BITCOIN SIGNED MESSAGE-----\n",
+			expectedError: "could not find '-----BEGIN SIGNATURE-----'",
+		},
+		"missing end": {
+			armored:       "-----BEGIN BITCOIN SIGNED MESSAGE-----\ntest message\n-----BEGIN SIGNATURE-----\n1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqU\n",
+			expectedError: "could not find '-----END BITCOIN SIGNED MESSAGE-----'",
+		},
+		"missing address": {
+			armored:       "-----BEGIN BITCOIN SIGNED MESSAGE-----\ntest message\n-----BEGIN SIGNATURE-----\n\n-----END BITCOIN SIGNED MESSAGE-----\n",
+			expectedError: "armored signature does not contain an address",
+		},
+		"missing signature value": {
+			armored:       "-----BEGIN BITCOIN SIGNED MESSAGE-----\ntest message\n-----BEGIN SIGNATURE-----\n1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\n-----END BITCOIN SIGNED MESSAGE-----\n",
+			expectedError: "armored signature does not contain a signature",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			_, err := verifier.ParseArmored(strings.NewReader(tt.armored))
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
+
+func (s *ArmoredTestSuite) TestFormatArmored() {
+	signedMessage := verifier.SignedMessage{
+		Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		Message:   "first line\nsecond line",
+		Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+	}
+
+	armored := verifier.FormatArmored(signedMessage)
+	s.Require().Equal("-----BEGIN BITCOIN SIGNED MESSAGE-----\nfirst line\nsecond line\n-----BEGIN SIGNATURE-----\n"+
+		"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5\nIFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n"+
+		"-----END BITCOIN SIGNED MESSAGE-----\n", armored)
+
+	parsed, err := verifier.ParseArmored(strings.NewReader(armored))
+	s.Require().NoError(err)
+	s.Require().Equal(signedMessage, parsed)
+}
+
+func (s *ArmoredTestSuite) TestFormatArmoredRoundTrip() {
+	tests := map[string]struct {
+		message  string
+		expected string
+	}{
+		"empty":                  {message: "", expected: ""},
+		"marker":                 {message: "-----BEGIN SIGNATURE-----", expected: "-----BEGIN SIGNATURE-----"},
+		"markers and escapes":    {message: "-----BEGIN BITCOIN SIGNED MESSAGE-----\n - -----END BITCOIN SIGNED MESSAGE-----\n- -----BEGIN SIGNATURE-----\n- text", expected: "-----BEGIN BITCOIN SIGNED MESSAGE-----\n - -----END BITCOIN SIGNED MESSAGE-----\n- -----BEGIN SIGNATURE-----\n- text"},
+		"header-like first line": {message: "Note: hello\n\nbody", expected: "Note: hello\n\nbody"},
+		"crlf is returned as lf": {message: "first line\r\nsecond line", expected: "first line\nsecond line"},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			signedMessage := verifier.SignedMessage{
+				Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+				Message:   tt.message,
+				Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			}
+
+			parsed, err := verifier.ParseArmored(strings.NewReader(verifier.FormatArmored(signedMessage)))
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expected, parsed.Message)
+		})
+	}
+}
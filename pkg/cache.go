@@ -0,0 +1,203 @@
+package verifier
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// CacheKey identifies a verification, it is the SHA-256 hash of the network, the profile, the limits and the normalized inputs.
+// The inputs are normalized by decoding them: the same address in another case or the same signature with other whitespace result in the same key.
+type CacheKey [sha256.Size]byte
+
+// CacheEntry is the outcome of a verification, as stored in a Cache.
+type CacheEntry struct {
+	// Result of the verification.
+	Result Result
+	// Err is the error of the verification, nil if it succeeded.
+	Err error
+}
+
+// Negative reports whether the entry is a negative result: the signature is invalid or could not be verified.
+func (e CacheEntry) Negative() bool {
+	return e.Err != nil || !e.Result.Valid
+}
+
+// Cache stores the outcomes of verifications, see WithCache. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the entry for the key, the boolean is false when there is no (unexpired) entry.
+	Get(key CacheKey) (CacheEntry, bool)
+	// Set stores the entry for the key. Use CacheEntry.Negative to give negative results their own expiry.
+	Set(key CacheKey, entry CacheEntry)
+}
+
+// CacheStats holds the counters of a MemoryCache.
+type CacheStats struct {
+	// Hits is the amount of lookups that returned an entry.
+	Hits uint64
+	// Misses is the amount of lookups that did not return an entry, including expired entries.
+	Misses uint64
+	// Evictions is the amount of entries that were removed to stay within the size of the cache.
+	Evictions uint64
+	// Entries is the amount of entries in the cache, which can include expired entries that have not been looked up yet.
+	Entries int
+}
+
+// MemoryCache is an in-memory Cache that holds a bounded amount of entries, evicting the least recently used entry first.
+// Positive and negative results expire after their own TTL. It is safe for concurrent use.
+type MemoryCache struct {
+	mutex       sync.Mutex
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	entries     map[CacheKey]*list.Element
+	recency     *list.List
+	stats       CacheStats
+}
+
+// memoryCacheItem is an entry of a MemoryCache, together with its key and expiry.
+type memoryCacheItem struct {
+	key       CacheKey
+	entry     CacheEntry
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates a MemoryCache that holds at most size entries. Valid results expire after ttl, negative results after negativeTTL.
+// Results are not cached when their TTL is zero or negative, so a negativeTTL of zero only caches valid results.
+func NewMemoryCache(size int, ttl time.Duration, negativeTTL time.Duration) *MemoryCache {
+	return &MemoryCache{
+		mutex:       sync.Mutex{},
+		size:        max(size, 0),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     map[CacheKey]*list.Element{},
+		recency:     list.New(),
+		stats:       CacheStats{Hits: 0, Misses: 0, Evictions: 0, Entries: 0},
+	}
+}
+
+// Get returns the entry for the key, expired entries are removed.
+func (c *MemoryCache) Get(key CacheKey) (CacheEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, found := c.entries[key]
+	if !found {
+		c.stats.Misses++
+
+		return CacheEntry{}, false
+	}
+
+	item, _ := element.Value.(*memoryCacheItem)
+	if !time.Now().Before(item.expiresAt) {
+		c.remove(element)
+		c.stats.Misses++
+
+		return CacheEntry{}, false
+	}
+
+	c.recency.MoveToFront(element)
+	c.stats.Hits++
+
+	return item.entry, true
+}
+
+// Set stores the entry for the key with the TTL that applies to it, evicting the least recently used entries when the cache is full.
+func (c *MemoryCache) Set(key CacheKey, entry CacheEntry) {
+	ttl := c.ttl
+	if entry.Negative() {
+		ttl = c.negativeTTL
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Replace any existing entry, even when the new one is not cached
+	if element, found := c.entries[key]; found {
+		c.remove(element)
+	}
+
+	if ttl <= 0 || c.size == 0 {
+		return
+	}
+
+	c.entries[key] = c.recency.PushFront(&memoryCacheItem{key: key, entry: entry, expiresAt: time.Now().Add(ttl)})
+	for c.recency.Len() > c.size {
+		c.remove(c.recency.Back())
+		c.stats.Evictions++
+	}
+}
+
+// Stats returns the counters of the cache.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.recency.Len()
+
+	return stats
+}
+
+// remove removes an element from the cache, the mutex must be held.
+func (c *MemoryCache) remove(element *list.Element) {
+	item, _ := element.Value.(*memoryCacheItem)
+	delete(c.entries, item.key)
+	c.recency.Remove(element)
+}
+
+// newCacheKey hashes the network, profile, limits and decoded inputs of a verification. Every field is length prefixed, so the encoding is unambiguous.
+func newCacheKey(net *chaincfg.Params, profile Profile, limits Limits, address btcutil.Address, message string, signature DecodedSignature) CacheKey {
+	hasher := sha256.New()
+	writeCacheKeyField(hasher, []byte(net.Name))
+	writeCacheKeyField(hasher, []byte(profile.Name))
+	writeCacheKeyField(hasher, []byte{
+		boolByte(profile.TrimWhitespace),
+		boolByte(profile.AllowSMPPrefix),
+		boolByte(profile.AllowTrezorFlags),
+		boolByte(profile.AllowLegacyForAnyAddress),
+		boolByte(profile.AllowBIP322),
+	})
+	writeCacheKeyField(hasher, limitsField(limits))
+	writeCacheKeyField(hasher, []byte(address.EncodeAddress()))
+	writeCacheKeyField(hasher, []byte(message))
+	writeCacheKeyField(hasher, signature.Payload)
+	writeCacheKeyField(hasher, []byte(signature.Format))
+	writeCacheKeyField(hasher, []byte(signature.Encoding))
+
+	var key CacheKey
+	hasher.Sum(key[:0])
+
+	return key
+}
+
+// writeCacheKeyField writes a length prefixed field to the hasher of a cache key.
+func writeCacheKeyField(hasher hash.Hash, field []byte) {
+	_, _ = hasher.Write(binary.BigEndian.AppendUint64(nil, uint64(len(field))))
+	_, _ = hasher.Write(field)
+}
+
+// limitsField encodes the limits as a field of a cache key, as they decide whether a signature is rejected.
+func limitsField(limits Limits) []byte {
+	field := []byte{}
+	for _, limit := range []int{limits.MaxSignatureBytes, limits.MaxMessageBytes, limits.MaxWitnessItems, limits.MaxWitnessItemBytes, limits.MaxScriptOps, limits.MaxSigOps} {
+		field = binary.AppendVarint(field, int64(limit))
+	}
+
+	return field
+}
+
+// boolByte encodes a boolean as a single byte.
+func boolByte(value bool) byte {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,189 @@
+package verifier_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type CacheTestSuite struct {
+	suite.Suite
+}
+
+func TestCacheTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(CacheTestSuite))
+}
+
+func (s *CacheTestSuite) TestVerifierCache() {
+	cache := verifier.NewMemoryCache(10, time.Hour, time.Minute)
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache))
+
+	signedMessage := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature}
+
+	expected, err := v.Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().True(expected.Valid)
+	s.Require().Equal(verifier.CacheStats{Hits: 0, Misses: 1, Evictions: 0, Entries: 1}, cache.Stats())
+
+	// The inputs are normalized, so these are all the same verification
+	variants := []verifier.SignedMessage{
+		signedMessage,
+		{Address: strings.ToUpper(signedMessage.Address), Message: signedMessage.Message, Signature: signedMessage.Signature},
+		{Address: signedMessage.Address, Message: signedMessage.Message, Signature: signedMessage.Signature[:40] + "\n" + signedMessage.Signature[40:]},
+	}
+	for _, variant := range variants {
+		result, err := v.Verify(variant)
+		s.Require().NoError(err)
+		s.Require().Equal(expected, result)
+	}
+	s.Require().Equal(verifier.CacheStats{Hits: 3, Misses: 1, Evictions: 0, Entries: 1}, cache.Stats())
+
+	// A different profile is a different verification
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache), verifier.WithProfile(verifier.BitcoinCore())).Verify(signedMessage)
+	s.Require().EqualError(err, "BIP-322 signatures are not allowed by profile 'bitcoin-core'")
+	s.Require().Equal(verifier.CacheStats{Hits: 3, Misses: 2, Evictions: 0, Entries: 2}, cache.Stats())
+}
+
+func (s *CacheTestSuite) TestVerifierCacheNegative() {
+	signedMessage := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World - This should fail", Signature: simpleSignature}
+
+	// Negative results are cached, including the error
+	cache := verifier.NewMemoryCache(10, time.Hour, time.Minute)
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache))
+
+	_, expectedErr := v.Verify(signedMessage)
+	s.Require().Error(expectedErr)
+
+	result, err := v.Verify(signedMessage)
+	s.Require().Equal(expectedErr, err)
+	s.Require().False(result.Valid)
+	s.Require().Equal(verifier.CacheStats{Hits: 1, Misses: 1, Evictions: 0, Entries: 1}, cache.Stats())
+
+	// Unless the negative TTL is zero
+	cache = verifier.NewMemoryCache(10, time.Hour, 0)
+	v = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache))
+
+	_, err = v.Verify(signedMessage)
+	s.Require().Error(err)
+	_, err = v.Verify(signedMessage)
+	s.Require().Error(err)
+	s.Require().Equal(verifier.CacheStats{Hits: 0, Misses: 2, Evictions: 0, Entries: 0}, cache.Stats())
+
+	// Signatures that can not be decoded are never cached
+	_, err = v.Verify(verifier.SignedMessage{Address: signedMessage.Address, Message: signedMessage.Message, Signature: "!"})
+	s.Require().Error(err)
+	s.Require().Equal(verifier.CacheStats{Hits: 0, Misses: 2, Evictions: 0, Entries: 0}, cache.Stats())
+}
+
+func (s *CacheTestSuite) TestVerifierCacheIsolation() {
+	cache := verifier.NewMemoryCache(10, time.Hour, time.Minute)
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache), verifier.WithDialects())
+
+	signedMessage := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature}
+
+	result, err := v.Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(result.Dialects)
+
+	// Modifying a returned result does not modify the result of a cached verification
+	expected := result.Dialects[0].Reasons[0]
+	result.Dialects[0].Reasons[0] = "modified"
+
+	result, err = v.Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().Equal(expected, result.Dialects[0].Reasons[0])
+}
+
+func (s *CacheTestSuite) TestMemoryCacheEviction() {
+	cache := verifier.NewMemoryCache(2, time.Hour, time.Hour)
+	entry := verifier.CacheEntry{Result: verifier.Result{Valid: true}, Err: nil}
+
+	cache.Set(verifier.CacheKey{1}, entry)
+	cache.Set(verifier.CacheKey{2}, entry)
+
+	// Use the first entry, so the second is the least recently used
+	_, found := cache.Get(verifier.CacheKey{1})
+	s.Require().True(found)
+
+	cache.Set(verifier.CacheKey{3}, entry)
+	s.Require().Equal(verifier.CacheStats{Hits: 1, Misses: 0, Evictions: 1, Entries: 2}, cache.Stats())
+
+	_, found = cache.Get(verifier.CacheKey{2})
+	s.Require().False(found)
+	_, found = cache.Get(verifier.CacheKey{1})
+	s.Require().True(found)
+	_, found = cache.Get(verifier.CacheKey{3})
+	s.Require().True(found)
+
+	// Replacing an entry does not evict another one
+	cache.Set(verifier.CacheKey{3}, verifier.CacheEntry{Result: verifier.Result{}, Err: errors.New("invalid")})
+	s.Require().Equal(verifier.CacheStats{Hits: 3, Misses: 1, Evictions: 1, Entries: 2}, cache.Stats())
+
+	replaced, found := cache.Get(verifier.CacheKey{3})
+	s.Require().True(found)
+	s.Require().True(replaced.Negative())
+}
+
+func (s *CacheTestSuite) TestMemoryCacheZeroSize() {
+	cache := verifier.NewMemoryCache(0, time.Hour, time.Hour)
+	cache.Set(verifier.CacheKey{1}, verifier.CacheEntry{Result: verifier.Result{Valid: true}, Err: nil})
+
+	_, found := cache.Get(verifier.CacheKey{1})
+	s.Require().False(found)
+	s.Require().Equal(verifier.CacheStats{Hits: 0, Misses: 1, Evictions: 0, Entries: 0}, cache.Stats())
+}
+
+func (s *CacheTestSuite) TestMemoryCacheConcurrent() {
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(verifier.NewMemoryCache(1, time.Hour, time.Hour)))
+
+	// Alternate between two messages, so the single entry is replaced all the time
+	messages := []verifier.SignedMessage{
+		{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature},
+		{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World - This should fail", Signature: simpleSignature},
+	}
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Go(func() {
+			result, _ := v.Verify(messages[i%2])
+			s.Equal(i%2 == 0, result.Valid)
+		})
+	}
+	wg.Wait()
+}
+
+// TestMemoryCacheExpiry uses a fake clock, to ensure positive and negative results expire after their own TTL.
+func TestMemoryCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		cache := verifier.NewMemoryCache(10, time.Hour, time.Minute)
+		cache.Set(verifier.CacheKey{1}, verifier.CacheEntry{Result: verifier.Result{Valid: true}, Err: nil})
+		cache.Set(verifier.CacheKey{2}, verifier.CacheEntry{Result: verifier.Result{Valid: false}, Err: nil})
+
+		time.Sleep(2 * time.Minute)
+
+		_, found := cache.Get(verifier.CacheKey{1})
+		require.True(t, found)
+		_, found = cache.Get(verifier.CacheKey{2})
+		require.False(t, found)
+
+		time.Sleep(time.Hour)
+
+		_, found = cache.Get(verifier.CacheKey{1})
+		require.False(t, found)
+		require.Equal(t, verifier.CacheStats{Hits: 1, Misses: 2, Evictions: 0, Entries: 0}, cache.Stats())
+	})
+}
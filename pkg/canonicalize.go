@@ -0,0 +1,85 @@
+package verifier
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/bitonicnl/verify-signed-message/internal/generic"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/signature"
+)
+
+// Malleation describes how a signature deviated from its canonical form.
+type Malleation string
+
+const (
+	// MalleationEncoding signals that the signature was not encoded as plain base64, for example because it was prefixed with 'smp'.
+	MalleationEncoding Malleation = "encoding"
+	// MalleationHighS signals that the S component of the signature was larger than half of the curve order.
+	MalleationHighS Malleation = "high-s"
+	// MalleationHeader signals that the recovery flag was not the minimal flag for the recovered key, for example a BIP-137 (Trezor) flag.
+	MalleationHeader Malleation = "header"
+)
+
+// CanonicalSignature is the result of canonicalizing a signature.
+type CanonicalSignature struct {
+	// Signature is the base64 encoded canonical signature.
+	Signature string
+	// Malleations lists every deviation from the canonical form that was found in the original signature.
+	Malleations []Malleation
+}
+
+// Canonicalize rewrites a valid legacy or BIP-137 signature into its canonical form, which has a low S value and the minimal recovery flag.
+// Every equivalent variant of a signature results in the same canonical signature, which makes it suitable for deduplication.
+//
+// The signature is verified on the passed network before and after canonicalization, see VerifyWithChain.
+func Canonicalize(signedMessage SignedMessage, net *chaincfg.Params) (CanonicalSignature, error) {
+	// Only valid signatures can be canonicalized
+	if _, err := VerifyWithChain(signedMessage, net); err != nil {
+		return CanonicalSignature{}, err
+	}
+
+	decoded, err := DefaultDecoders().Decode(signedMessage.Signature)
+	if err != nil {
+		return CanonicalSignature{}, err
+	}
+	signatureDecoded := decoded.Payload
+
+	// BIP-322 signatures are encoded as a witness, which cannot be rewritten without the private key
+	if len(signatureDecoded) != generic.ExpectedSignatureLength {
+		return CanonicalSignature{}, fmt.Errorf("only signatures of %d bytes can be canonicalized, got %d", generic.ExpectedSignatureLength, len(signatureDecoded))
+	}
+
+	malleations := make([]Malleation, 0, 3)
+	if base64.StdEncoding.EncodeToString(signatureDecoded) != signedMessage.Signature {
+		malleations = append(malleations, MalleationEncoding)
+	}
+
+	// Ensure a low S value, this might change the recovery flag
+	canonical, negated, err := signature.NormalizeS(signatureDecoded)
+	if err != nil {
+		return CanonicalSignature{}, err
+	}
+
+	if negated {
+		malleations = append(malleations, MalleationHighS)
+	}
+
+	// Ensure the minimal recovery flag
+	if minimalFlag := flags.Minimal(int(canonical[0])); minimalFlag != int(canonical[0]) {
+		canonical[0] = byte(minimalFlag)
+		malleations = append(malleations, MalleationHeader)
+	}
+
+	result := CanonicalSignature{Signature: base64.StdEncoding.EncodeToString(canonical), Malleations: malleations}
+
+	// Sanity check, the canonical signature should still be valid
+	if valid, err := VerifyWithChain(SignedMessage{Address: signedMessage.Address, Message: signedMessage.Message, Signature: result.Signature}, net); err != nil || !valid {
+		return CanonicalSignature{}, errors.Join(errors.New("canonical signature could not be verified"), err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package verifier_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type CanonicalizeTestSuite struct {
+	suite.Suite
+}
+
+func TestCanonicalizeTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(CanonicalizeTestSuite))
+}
+
+func (s *CanonicalizeTestSuite) TestCanonicalize() {
+	// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+	address := "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk"
+	message := "This is an example of a signed message."
+	trezor := "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ="
+	canonical := "ILVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ="
+
+	tests := map[string]struct {
+		signature           string
+		expectedMalleations []verifier.Malleation
+	}{
+		"canonical": {
+			signature:           canonical,
+			expectedMalleations: []verifier.Malleation{},
+		},
+		"trezor header": {
+			signature:           trezor,
+			expectedMalleations: []verifier.Malleation{verifier.MalleationHeader},
+		},
+		"high s": {
+			signature:           s.highS(canonical),
+			expectedMalleations: []verifier.Malleation{verifier.MalleationHighS},
+		},
+		"high s - trezor header": {
+			signature:           s.highS(trezor),
+			expectedMalleations: []verifier.Malleation{verifier.MalleationHighS, verifier.MalleationHeader},
+		},
+		"smp prefix - trezor header": {
+			signature:           "smp" + trezor,
+			expectedMalleations: []verifier.Malleation{verifier.MalleationEncoding, verifier.MalleationHeader},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, err := verifier.Canonicalize(verifier.SignedMessage{Address: address, Message: message, Signature: tt.signature}, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+			s.Require().Equal(canonical, result.Signature)
+			s.Require().Equal(tt.expectedMalleations, result.Malleations)
+		})
+	}
+}
+
+func (s *CanonicalizeTestSuite) TestCanonicalizeIncorrect() {
+	tests := map[string]struct {
+		signedMessage verifier.SignedMessage
+		expectedError string
+	}{
+		"invalid signature": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "14wPe34dikRzK4tMYvtwMMJCEZbJ7ar35V",
+				Message:   "test message",
+				Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			},
+			expectedError: "generated address '1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5' does not match expected address '14wPe34dikRzK4tMYvtwMMJCEZbJ7ar35V'",
+		},
+		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+		"bip-322": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+				Message:   "Hello World",
+				Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+			},
+			expectedError: "only signatures of 65 bytes can be canonicalized, got 107",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, err := verifier.Canonicalize(tt.signedMessage, &chaincfg.MainNetParams)
+			s.Require().EqualError(err, tt.expectedError)
+			s.Require().Empty(result.Signature)
+		})
+	}
+}
+
+// highS returns the high S twin of the base64 encoded signature.
+func (s *CanonicalizeTestSuite) highS(signatureEncoded string) string {
+	// Mark as helper
+	s.T().Helper()
+
+	signatureDecoded, err := base64.StdEncoding.DecodeString(signatureEncoded)
+	s.Require().NoError(err)
+
+	var sValue btcec.ModNScalar
+	sValue.SetByteSlice(signatureDecoded[33:])
+	s.Require().False(sValue.IsOverHalfOrder())
+	sValue.Negate().PutBytesUnchecked(signatureDecoded[33:])
+
+	// Flip the parity of the key ID
+	keyID := (signatureDecoded[0] - 27) & 0b11
+	signatureDecoded[0] = signatureDecoded[0] - keyID + (keyID ^ 1)
+
+	return base64.StdEncoding.EncodeToString(signatureDecoded)
+}
@@ -0,0 +1,152 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/bitonicnl/verify-signed-message/internal/addresshint"
+)
+
+// ScriptType is the type of script an address pays to.
+type ScriptType string
+
+const (
+	// ScriptTypeP2PK is a serialized public key, which is accepted by btcutil.DecodeAddress.
+	ScriptTypeP2PK ScriptType = "p2pk"
+	// ScriptTypeP2PKH is a legacy address.
+	ScriptTypeP2PKH ScriptType = "p2pkh"
+	// ScriptTypeP2SH is a script hash address, for example P2SH-P2WPKH (nested segwit).
+	ScriptTypeP2SH ScriptType = "p2sh"
+	// ScriptTypeP2WPKH is a native segwit address.
+	ScriptTypeP2WPKH ScriptType = "p2wpkh"
+	// ScriptTypeP2WSH is a native segwit script hash address.
+	ScriptTypeP2WSH ScriptType = "p2wsh"
+	// ScriptTypeP2TR is a taproot address.
+	ScriptTypeP2TR ScriptType = "p2tr"
+	// ScriptTypeP2A is a pay-to-anchor address, which can not sign messages.
+	ScriptTypeP2A ScriptType = "p2a"
+	// ScriptTypeWitnessUnknown is a segwit address with a witness version that is not known yet.
+	ScriptTypeWitnessUnknown ScriptType = "witness-unknown"
+)
+
+// noWitnessVersion is used as the witness version of addresses that are not segwit addresses.
+const noWitnessVersion = -1
+
+// AddressClassification describes an address and the proof formats that can be verified for it.
+type AddressClassification struct {
+	// ScriptType is the type of script the address pays to.
+	ScriptType ScriptType
+	// WitnessVersion is the witness version of segwit addresses, it is -1 for other addresses.
+	WitnessVersion int
+	// Network is the name of the network the address belongs to.
+	Network string
+	// Formats lists the proof formats that can be verified for the address with the Permissive profile, it is empty if none are supported.
+	Formats []Format
+}
+
+// ClassifyAddress decodes an address for the passed network and reports its script type and the proof formats that can be verified for it.
+// The address is decoded in the same way as VerifyWithChain does, with the exception that segwit addresses with an unknown witness version are accepted.
+func ClassifyAddress(address string, net *chaincfg.Params) (AddressClassification, error) {
+	classification := AddressClassification{ScriptType: "", WitnessVersion: noWitnessVersion, Network: net.Name, Formats: []Format{}}
+
+	decoded, err := decodeAddress(address, net)
+
+	// Future witness versions can not be decoded by btcutil, but they can still be classified
+	var unsupportedVersion btcutil.UnsupportedWitnessVerError
+	if errors.As(err, &unsupportedVersion) {
+		if err := checkBech32Network(address, net); err != nil {
+			return classification, err
+		}
+
+		classification.ScriptType, classification.WitnessVersion = ScriptTypeWitnessUnknown, int(unsupportedVersion)
+
+		return classification, nil
+	} else if err != nil {
+		return classification, err
+	}
+
+	classification.ScriptType = addressType(decoded)
+	if classification.ScriptType == "" {
+		return classification, fmt.Errorf("unsupported address type '%T'", decoded)
+	}
+
+	classification.WitnessVersion = witnessVersion(classification.ScriptType)
+
+	classification.Formats = DefaultAddressHandlers().Formats(decoded)
+
+	return classification, nil
+}
+
+// decodeAddress decodes the address and ensures it is valid for the passed network.
+// When a bech32 address can not be decoded, the error describes the characters that are probably wrong.
+func decodeAddress(address string, net *chaincfg.Params) (btcutil.Address, error) {
+	decoded, err := btcutil.DecodeAddress(address, net)
+	if err != nil {
+		// Point out the probable typos in bech32 addresses
+		if hint, ok := addresshint.Bech32(address, net.Bech32HRPSegwit); ok && len(hint.Issues) > 0 {
+			return nil, fmt.Errorf("could not decode address: %w: %s", err, hint)
+		}
+
+		return nil, fmt.Errorf("could not decode address: %w", err)
+	}
+
+	if !decoded.IsForNet(net) {
+		return nil, fmt.Errorf("address '%s' is not valid for network '%s'", address, net.Name)
+	}
+
+	return decoded, nil
+}
+
+// addressType returns the script type of a decoded address, it is empty for address types that are not known.
+func addressType(address btcutil.Address) ScriptType {
+	switch address.(type) {
+	case *btcutil.AddressPubKey:
+		return ScriptTypeP2PK
+	case *btcutil.AddressPubKeyHash:
+		return ScriptTypeP2PKH
+	case *btcutil.AddressScriptHash:
+		return ScriptTypeP2SH
+	case *btcutil.AddressWitnessPubKeyHash:
+		return ScriptTypeP2WPKH
+	case *btcutil.AddressWitnessScriptHash:
+		return ScriptTypeP2WSH
+	case *btcutil.AddressTaproot:
+		return ScriptTypeP2TR
+	case *btcutil.AddressPayToAnchor:
+		return ScriptTypeP2A
+	default:
+		return ""
+	}
+}
+
+// witnessVersion returns the witness version of the script type, it is -1 for script types that are not segwit or of which the version is not known.
+func witnessVersion(scriptType ScriptType) int {
+	switch scriptType {
+	case ScriptTypeP2WPKH, ScriptTypeP2WSH:
+		return 0
+	case ScriptTypeP2TR, ScriptTypeP2A:
+		return 1
+	case ScriptTypeP2PK, ScriptTypeP2PKH, ScriptTypeP2SH, ScriptTypeWitnessUnknown:
+	}
+
+	return noWitnessVersion
+}
+
+// checkBech32Network ensures the human-readable part of a bech32 encoded address belongs to the passed network.
+func checkBech32Network(address string, net *chaincfg.Params) error {
+	hrp, _, _, err := bech32.DecodeGeneric(address)
+	if err != nil {
+		return fmt.Errorf("could not decode address: %w", err)
+	}
+
+	if !strings.EqualFold(hrp, net.Bech32HRPSegwit) {
+		return fmt.Errorf("address '%s' is not valid for network '%s'", address, net.Name)
+	}
+
+	return nil
+}
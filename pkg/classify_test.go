@@ -0,0 +1,141 @@
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type ClassifyTestSuite struct {
+	suite.Suite
+}
+
+func TestClassifyTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ClassifyTestSuite))
+}
+
+func (s *ClassifyTestSuite) TestClassifyAddress() {
+	tests := map[string]struct {
+		address  string
+		net      *chaincfg.Params
+		expected verifier.AddressClassification
+	}{
+		"p2pk": {
+			address: "02c7f12003196442943d8588e01aee840423cc54fc1521526a3b85c2b0cbd58872",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2PK, WitnessVersion: -1, Network: "mainnet", Formats: []verifier.Format{verifier.FormatBIP322Full},
+			},
+		},
+		"p2pkh": {
+			address: "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2PKH, WitnessVersion: -1, Network: "mainnet", Formats: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Full},
+			},
+		},
+		"p2sh": {
+			address: "3L6TyTisPBmrDAj6RoKmDzNnj4eQi54gD2",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2SH, WitnessVersion: -1, Network: "mainnet", Formats: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Full},
+			},
+		},
+		"p2wpkh": {
+			address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2WPKH, WitnessVersion: 0, Network: "mainnet", Formats: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Simple, verifier.FormatBIP322Full},
+			},
+		},
+		"p2wpkh - testnet": {
+			address: "tb1qnzwefk7wzphlc4xeawf8p4yqtcwzdgsvukwma8",
+			net:     &chaincfg.TestNet3Params,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2WPKH, WitnessVersion: 0, Network: "testnet3", Formats: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Simple, verifier.FormatBIP322Full},
+			},
+		},
+		// Taken from https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki#examples
+		"p2wsh": {
+			address: "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2WSH, WitnessVersion: 0, Network: "mainnet", Formats: []verifier.Format{verifier.FormatBIP322Full},
+			},
+		},
+		"p2tr": {
+			address: "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2TR, WitnessVersion: 1, Network: "mainnet", Formats: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Simple, verifier.FormatBIP322Full},
+			},
+		},
+		"p2a": {
+			address: "bc1pfeessrawgf",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeP2A, WitnessVersion: 1, Network: "mainnet", Formats: []verifier.Format{},
+			},
+		},
+		// Taken from https://github.com/bitcoin/bips/blob/master/bip-0350.mediawiki#test-vectors-for-v0-v16-native-segregated-witness-addresses
+		"witness version 2": {
+			address: "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeWitnessUnknown, WitnessVersion: 2, Network: "mainnet", Formats: []verifier.Format{},
+			},
+		},
+		"witness version 16": {
+			address: "BC1SW50QGDZ25J",
+			net:     &chaincfg.MainNetParams,
+			expected: verifier.AddressClassification{
+				ScriptType: verifier.ScriptTypeWitnessUnknown, WitnessVersion: 16, Network: "mainnet", Formats: []verifier.Format{},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			classification, err := verifier.ClassifyAddress(tt.address, tt.net)
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expected, classification)
+		})
+	}
+}
+
+func (s *ClassifyTestSuite) TestClassifyAddressErrors() {
+	tests := map[string]struct {
+		address       string
+		net           *chaincfg.Params
+		expectedError string
+	}{
+		"invalid": {
+			address:       "INVALID",
+			net:           &chaincfg.MainNetParams,
+			expectedError: "could not decode address: decoded address is of unknown format",
+		},
+		"wrong network": {
+			address:       "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			net:           &chaincfg.TestNet3Params,
+			expectedError: "address 'bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l' is not valid for network 'testnet3'",
+		},
+		"witness version 2 - wrong network": {
+			address:       "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs",
+			net:           &chaincfg.TestNet3Params,
+			expectedError: "address 'bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs' is not valid for network 'testnet3'",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			_, err := verifier.ClassifyAddress(tt.address, tt.net)
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package conformance_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/conformance"
+)
+
+type ConformanceTestSuite struct {
+	suite.Suite
+}
+
+func TestConformanceTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ConformanceTestSuite))
+}
+
+func (s *ConformanceTestSuite) TestVectors() {
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	// Every format, outcome and network that is tagged is covered
+	s.Require().ElementsMatch(
+		[]conformance.Format{conformance.FormatLegacy, conformance.FormatBIP137, conformance.FormatBIP322Simple, conformance.FormatBIP322Full},
+		lo.Uniq(lo.Map(vectors, func(vector conformance.Vector, _ int) conformance.Format { return vector.Format })),
+	)
+	s.Require().ElementsMatch(
+		[]conformance.Outcome{conformance.OutcomeValid, conformance.OutcomeInvalid, conformance.OutcomeInconclusive},
+		lo.Uniq(lo.Map(vectors, func(vector conformance.Vector, _ int) conformance.Outcome { return vector.Expected })),
+	)
+	s.Require().ElementsMatch(
+		[]string{"mainnet", "testnet3"},
+		lo.Uniq(lo.Map(vectors, func(vector conformance.Vector, _ int) string { return vector.Network })),
+	)
+
+	// The fixtures are published as is
+	fromFS, err := conformance.Load(conformance.FS(), "vectors/*.json")
+	s.Require().NoError(err)
+	s.Require().Equal(vectors, fromFS)
+}
+
+func (s *ConformanceTestSuite) TestLibrary() {
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	// The unsupported vectors must still fail, so they are removed once supported
+	library := conformance.Library()
+	unsupported, conforming := lo.FilterReject(vectors, func(vector conformance.Vector, _ int) bool {
+		return lo.Contains(conformance.LibraryUnsupported(), vector.Name)
+	})
+	s.Require().Len(unsupported, len(conformance.LibraryUnsupported()))
+
+	for _, vector := range unsupported {
+		s.Require().Error(conformance.Check(library, vector), vector.Name)
+	}
+
+	conformance.Run(s.T(), library, conforming)
+}
+
+func (s *ConformanceTestSuite) TestLibraryProfile() {
+	library := conformance.Library(verifier.WithProfile(verifier.BitcoinCore()))
+
+	// Bitcoin Core does not verify BIP-322 signatures
+	result := library.Verify(conformance.Input{
+		Network:   "mainnet",
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World",
+		Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	})
+	s.Require().Equal(conformance.OutcomeInvalid, result.Outcome)
+	s.Require().Equal(verifier.ErrorClassFormat, result.ErrorClass)
+	s.Require().EqualError(result.Err, "BIP-322 signatures are not allowed by profile 'bitcoin-core'")
+
+	result = library.Verify(conformance.Input{Network: "unknown", Address: "", Message: "", Signature: ""})
+	s.Require().Equal(conformance.OutcomeInconclusive, result.Outcome)
+	s.Require().EqualError(result.Err, "unknown network 'unknown', expected one of: mainnet, testnet3, testnet4, signet, regtest, simnet")
+}
+
+func (s *ConformanceTestSuite) TestLibraryInconclusive() {
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	profiles := []verifier.Profile{verifier.Permissive(), verifier.ElectrumCompatible(), verifier.BitcoinCore(), verifier.StrictBIP322()}
+	valid := conformance.Result{Outcome: conformance.OutcomeValid, ErrorClass: verifier.ErrorClassNone, Err: nil}
+	invalid := func(errorClass verifier.ErrorClass) conformance.Result {
+		return conformance.Result{Outcome: conformance.OutcomeInvalid, ErrorClass: errorClass, Err: nil}
+	}
+
+	// The outcomes of the library for every profile, in the order of the profiles above
+	electrumFlags := []conformance.Result{valid, valid, invalid(verifier.ErrorClassFormat), invalid(verifier.ErrorClassFormat)}
+	expected := map[string][]conformance.Result{
+		"btclib - p2pkh - untrimmed":                       {valid, valid, invalid(verifier.ErrorClassInvalid), invalid(verifier.ErrorClassInvalid)},
+		"electrum - p2sh-p2wpkh":                           electrumFlags,
+		"electrum - p2wpkh":                                electrumFlags,
+		"electrum - p2wpkh - testnet":                      electrumFlags,
+		"electrum - p2wpkh - testnet - untrimmed":          electrumFlags,
+		"coinomi - p2sh-p2wpkh":                            electrumFlags,
+		"coinomi - p2wpkh":                                 electrumFlags,
+		"mycelium - p2sh-p2wpkh":                           electrumFlags,
+		"mycelium - p2wpkh":                                electrumFlags,
+		"samourai - p2wpkh":                                electrumFlags,
+		"unisat - p2sh-p2wpkh":                             electrumFlags,
+		"unisat - p2wpkh":                                  electrumFlags,
+		"unisat - p2tr":                                    electrumFlags,
+		"bip-322 - p2wpkh - test vector #0 - smp prefixed": {valid, invalid(verifier.ErrorClassSignature), invalid(verifier.ErrorClassSignature), invalid(verifier.ErrorClassSignature)},
+		"bip322-js - p2sh-p2wpkh":                          {invalid(verifier.ErrorClassInvalid), invalid(verifier.ErrorClassInvalid), invalid(verifier.ErrorClassFormat), invalid(verifier.ErrorClassFormat)},
+	}
+
+	inconclusive := lo.Filter(vectors, func(vector conformance.Vector, _ int) bool { return vector.Expected == conformance.OutcomeInconclusive })
+	s.Require().ElementsMatch(lo.Keys(expected), lo.Map(inconclusive, func(vector conformance.Vector, _ int) string { return vector.Name }))
+
+	for _, vector := range inconclusive {
+		for i, profile := range profiles {
+			s.Run(vector.Name+" - "+profile.Name, func() {
+				result := conformance.Library(verifier.WithProfile(profile)).Verify(vector.Input)
+				s.Require().Equal(expected[vector.Name][i].Outcome, result.Outcome, result.Err)
+				s.Require().Equal(expected[vector.Name][i].ErrorClass, result.ErrorClass, result.Err)
+			})
+		}
+	}
+}
+
+func (s *ConformanceTestSuite) TestCheck() {
+	valid := conformance.Vector{Name: "valid", Expected: conformance.OutcomeValid}
+	invalid := conformance.Vector{Name: "invalid", Expected: conformance.OutcomeInvalid, ErrorClass: verifier.ErrorClassAddress}
+	inconclusive := conformance.Vector{Name: "inconclusive", Expected: conformance.OutcomeInconclusive}
+
+	tests := map[string]struct {
+		vector        conformance.Vector
+		result        conformance.Result
+		expectedError string
+	}{
+		"valid": {
+			vector: valid,
+			result: conformance.Result{Outcome: conformance.OutcomeValid},
+		},
+		"valid - invalid": {
+			vector:        valid,
+			result:        conformance.Result{Outcome: conformance.OutcomeInvalid, Err: errors.New("failed")},
+			expectedError: "vector 'valid': expected outcome 'valid', got 'invalid' (error: failed)",
+		},
+		"valid - inconclusive": {
+			vector:        valid,
+			result:        conformance.Result{Outcome: conformance.OutcomeInconclusive},
+			expectedError: "vector 'valid': expected outcome 'valid', got 'inconclusive' (error: <nil>)",
+		},
+		"invalid": {
+			vector: invalid,
+			result: conformance.Result{Outcome: conformance.OutcomeInvalid, ErrorClass: verifier.ErrorClassAddress},
+		},
+		"invalid - not classified": {
+			vector: invalid,
+			result: conformance.Result{Outcome: conformance.OutcomeInvalid},
+		},
+		"invalid - other error class": {
+			vector:        invalid,
+			result:        conformance.Result{Outcome: conformance.OutcomeInvalid, ErrorClass: verifier.ErrorClassSignature, Err: errors.New("failed")},
+			expectedError: "vector 'invalid': expected error class 'address', got 'signature' (error: failed)",
+		},
+		"invalid - valid": {
+			vector:        invalid,
+			result:        conformance.Result{Outcome: conformance.OutcomeValid},
+			expectedError: "vector 'invalid': expected outcome 'invalid', got 'valid' (error: <nil>)",
+		},
+		"inconclusive - valid": {
+			vector: inconclusive,
+			result: conformance.Result{Outcome: conformance.OutcomeValid},
+		},
+		"inconclusive - invalid": {
+			vector: inconclusive,
+			result: conformance.Result{Outcome: conformance.OutcomeInvalid},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			err := conformance.Check(conformance.VerifierFunc(func(conformance.Input) conformance.Result { return tt.result }), tt.vector)
+			if tt.expectedError != "" {
+				s.Require().EqualError(err, tt.expectedError)
+
+				return
+			}
+
+			s.Require().NoError(err)
+		})
+	}
+}
+
+func (s *ConformanceTestSuite) TestLoadIncorrect() {
+	vector := `{"name": "vector", "source": "test", "format": "legacy", "network": "mainnet", "address": "", "message": "", "signature": "", "expected": "valid"}`
+
+	tests := map[string]struct {
+		fixtures      []string
+		expectedError string
+	}{
+		"malformed": {
+			fixtures:      []string{`{}`},
+			expectedError: "could not decode vectors in 'vectors/0.json': json: cannot unmarshal object into Go value of type []conformance.Vector",
+		},
+		"duplicate": {
+			fixtures:      []string{`[` + vector + `]`, `[` + vector + `]`},
+			expectedError: "duplicate vector 'vector' in 'vectors/1.json'",
+		},
+		"no name": {
+			fixtures:      []string{`[{"source": "test"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': vector name is empty",
+		},
+		"no source": {
+			fixtures:      []string{`[{"name": "vector"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': vector 'vector' has no source",
+		},
+		"no network": {
+			fixtures:      []string{`[{"name": "vector", "source": "test"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': vector 'vector' has no network",
+		},
+		"unknown format": {
+			fixtures:      []string{`[{"name": "vector", "source": "test", "network": "mainnet", "format": "unknown"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': vector 'vector' has unknown format 'unknown'",
+		},
+		"unknown outcome": {
+			fixtures:      []string{`[{"name": "vector", "source": "test", "network": "mainnet", "format": "legacy", "expected": "unknown"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': vector 'vector' has unknown expected outcome 'unknown'",
+		},
+		"valid - error class": {
+			fixtures:      []string{`[{"name": "vector", "source": "test", "network": "mainnet", "format": "legacy", "expected": "valid", "errorClass": "address"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': valid vector 'vector' has error class 'address'",
+		},
+		"invalid - no error class": {
+			fixtures:      []string{`[{"name": "vector", "source": "test", "network": "mainnet", "format": "legacy", "expected": "invalid"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': invalid vector 'vector' has no error class",
+		},
+		"inconclusive - no comment": {
+			fixtures:      []string{`[{"name": "vector", "source": "test", "network": "mainnet", "format": "legacy", "expected": "inconclusive"}]`},
+			expectedError: "invalid vector in 'vectors/0.json': inconclusive vector 'vector' does not explain why",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			fsys := fstest.MapFS{}
+			for i, fixture := range tt.fixtures {
+				fsys["vectors/"+strconv.Itoa(i)+".json"] = &fstest.MapFile{Data: []byte(fixture)}
+			}
+
+			_, err := conformance.Load(fsys, "vectors/*.json")
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
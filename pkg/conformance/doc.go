@@ -0,0 +1,12 @@
+// Package conformance holds test vectors for Bitcoin message signing and a harness that runs them against any implementation.
+//
+// The vectors are JSON fixtures in the vectors directory, collected from wallets, libraries and specifications.
+// Every vector is tagged with its source, format and network and declares the expected outcome, see Vector.
+// Run the vectors against an implementation of Verifier with Run in a test, or with Check elsewhere.
+// Library adapts this library to a Verifier.
+//
+// Vectors that the specifications leave open are inconclusive, their comment explains why.
+// Most are legacy signatures of segwit addresses with the recovery flags of a compressed P2PKH key (31 to 34), as introduced by Electrum,
+// instead of the flags BIP-137 assigns to P2SH-P2WPKH (35 to 38) and P2WPKH (39 to 42) addresses.
+// Bitcoin Core rejects these signatures, Electrum and most wallets accept them.
+package conformance
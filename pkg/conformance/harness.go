@@ -0,0 +1,73 @@
+package conformance
+
+import (
+	"fmt"
+	"testing"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Verifier is an implementation of Bitcoin message signing that is run against the vectors, usually an adapter around a library or service.
+type Verifier interface {
+	// Verify verifies the signed message of a vector. It must be safe for concurrent use, as vectors are run in parallel.
+	Verify(input Input) Result
+}
+
+// VerifierFunc adapts a function to a Verifier.
+type VerifierFunc func(input Input) Result
+
+// Verify calls f(input).
+func (f VerifierFunc) Verify(input Input) Result {
+	return f(input)
+}
+
+// Result is the outcome an implementation reports for a vector.
+type Result struct {
+	// Outcome of the verification, OutcomeInconclusive when the implementation could not come to an outcome.
+	Outcome Outcome
+	// ErrorClass is the optional kind of failure of an invalid outcome, it is only compared when it is reported.
+	// Implementations that do not classify their failures leave it empty.
+	ErrorClass verifier.ErrorClass
+	// Err is the optional error of the implementation, which is included in the reported mismatch.
+	Err error
+}
+
+// Check runs a single vector against the verifier, it returns an error when the outcome does not match the expected outcome.
+// Inconclusive vectors never fail, as implementations are allowed to disagree on them.
+func Check(v Verifier, vector Vector) error {
+	result := v.Verify(vector.Input)
+
+	switch {
+	case vector.Expected == OutcomeInconclusive:
+		return nil
+	case result.Outcome != vector.Expected:
+		return fmt.Errorf("vector '%s': expected outcome '%s', got '%s' (error: %v)", vector.Name, vector.Expected, result.Outcome, result.Err)
+	case vector.Expected == OutcomeInvalid && result.ErrorClass != "" && result.ErrorClass != vector.ErrorClass:
+		return fmt.Errorf("vector '%s': expected error class '%s', got '%s' (error: %v)", vector.Name, vector.ErrorClass, result.ErrorClass, result.Err)
+	default:
+		return nil
+	}
+}
+
+// Run runs the vectors against the verifier in parallel subtests, which are named after the vectors.
+// The outcomes of inconclusive vectors are logged, so the behavior of the implementation can be reviewed with `go test -v`.
+func Run(t *testing.T, v Verifier, vectors []Vector) {
+	t.Helper()
+
+	for _, vector := range vectors {
+		t.Run(vector.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if vector.Expected == OutcomeInconclusive {
+				result := v.Verify(vector.Input)
+				t.Logf("inconclusive vector, got outcome '%s' (error: %v): %s", result.Outcome, result.Err, vector.Comment)
+
+				return
+			}
+
+			if err := Check(v, vector); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
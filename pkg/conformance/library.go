@@ -0,0 +1,39 @@
+package conformance
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Library returns a Verifier that verifies the vectors with this library, configured by the passed options.
+func Library(options ...verifier.Option) Verifier {
+	return VerifierFunc(func(input Input) Result {
+		net, err := input.Params()
+		if err != nil {
+			return Result{Outcome: OutcomeInconclusive, ErrorClass: "", Err: err}
+		}
+
+		result, err := verifier.NewVerifier(net, options...).Verify(verifier.SignedMessage{
+			Address:   input.Address,
+			Message:   input.Message,
+			Signature: input.Signature,
+		})
+		if result.Valid {
+			return Result{Outcome: OutcomeValid, ErrorClass: verifier.ErrorClassNone, Err: nil}
+		}
+
+		return Result{Outcome: OutcomeInvalid, ErrorClass: result.ErrorClass, Err: err}
+	})
+}
+
+// LibraryUnsupported returns the names of the vectors that Library does not verify as expected, because this library does not support them.
+// Simple signatures are only verified for single key addresses, P2WSH addresses need a full signature.
+func LibraryUnsupported() []string {
+	return []string{"bitcoin core - p2wsh - 3-of-3 multisig"}
+}
+
+// Params returns the parameters of the network of the input.
+func (i Input) Params() (*chaincfg.Params, error) {
+	return verifier.NetworkByName(i.Network)
+}
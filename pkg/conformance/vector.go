@@ -0,0 +1,158 @@
+package conformance
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Outcome is the outcome of the verification of a vector.
+type Outcome string
+
+const (
+	// OutcomeValid is used when the signature is valid for the address and message.
+	OutcomeValid Outcome = "valid"
+	// OutcomeInvalid is used when the signature is not valid for the address and message.
+	OutcomeInvalid Outcome = "invalid"
+	// OutcomeInconclusive is used for vectors that implementations disagree on, for example because the specifications do not cover them.
+	// An implementation reports it when it can not come to an outcome.
+	OutcomeInconclusive Outcome = "inconclusive"
+)
+
+// Format is the format of the signature of a vector.
+type Format string
+
+const (
+	// FormatLegacy is a legacy signature, including the signatures Electrum creates for segwit addresses.
+	FormatLegacy Format = "legacy"
+	// FormatBIP137 is a legacy signature with the recovery flags of BIP-137 for segwit addresses, as created by Trezor.
+	FormatBIP137 Format = "bip137"
+	// FormatBIP322Simple is a BIP-322 simple signature.
+	FormatBIP322Simple Format = "bip322-simple"
+	// FormatBIP322Full is a BIP-322 full signature.
+	FormatBIP322Full Format = "bip322-full"
+)
+
+// Input is the signed message of a vector, as it is passed to a Verifier.
+type Input struct {
+	// Network is the name of the network of the address, for example 'mainnet' or 'testnet3'.
+	Network string `json:"network"`
+	// Address that was used to sign the message with.
+	Address string `json:"address"`
+	// Message that has been signed.
+	Message string `json:"message"`
+	// Signature as it was produced by the source, usually base64 encoded.
+	Signature string `json:"signature"`
+}
+
+// Vector is a signed message with the outcome that is expected of its verification.
+type Vector struct {
+	Input
+
+	// Name uniquely identifies the vector.
+	Name string `json:"name"`
+	// Source is the wallet, library or specification that produced the vector, for example 'bip-322' or 'electrum'.
+	Source string `json:"source"`
+	// Reference is an optional link to the origin of the vector.
+	Reference string `json:"reference,omitempty"`
+	// Format is the format of the signature.
+	Format Format `json:"format"`
+	// Expected is the outcome that is expected of the verification.
+	Expected Outcome `json:"expected"`
+	// ErrorClass is the kind of failure of invalid vectors, see verifier.ErrorClass.
+	ErrorClass verifier.ErrorClass `json:"errorClass,omitempty"`
+	// Comment optionally explains the vector, it is set for every inconclusive vector.
+	Comment string `json:"comment,omitempty"`
+}
+
+//go:embed vectors/*.json
+var vectors embed.FS
+
+// FS returns the file system that holds the JSON fixtures of the vectors, which are also published as pkg/conformance/vectors.
+func FS() fs.FS {
+	return vectors
+}
+
+// Vectors returns all vectors of the package.
+func Vectors() ([]Vector, error) {
+	return Load(vectors, "vectors/*.json")
+}
+
+// Load reads the vectors from the JSON fixtures in the file system that match the pattern, which allows running additional vectors.
+// Every fixture holds an array of vectors, the vectors are validated and their names must be unique.
+func Load(fsys fs.FS, pattern string) ([]Vector, error) {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("could not find vectors: %w", err)
+	}
+
+	loaded := []Vector{}
+	names := map[string]bool{}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read vectors: %w", err)
+		}
+
+		var fileVectors []Vector
+		if err := json.Unmarshal(data, &fileVectors); err != nil {
+			return nil, fmt.Errorf("could not decode vectors in '%s': %w", file, err)
+		}
+
+		for _, vector := range fileVectors {
+			if err := vector.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid vector in '%s': %w", file, err)
+			}
+
+			if names[vector.Name] {
+				return nil, fmt.Errorf("duplicate vector '%s' in '%s'", vector.Name, file)
+			}
+			names[vector.Name] = true
+		}
+
+		loaded = append(loaded, fileVectors...)
+	}
+
+	return loaded, nil
+}
+
+// Validate ensures the vector is complete and consistent.
+func (v Vector) Validate() error {
+	switch {
+	case v.Name == "":
+		return errors.New("vector name is empty")
+	case v.Source == "":
+		return fmt.Errorf("vector '%s' has no source", v.Name)
+	case v.Network == "":
+		return fmt.Errorf("vector '%s' has no network", v.Name)
+	}
+
+	switch v.Format {
+	case FormatLegacy, FormatBIP137, FormatBIP322Simple, FormatBIP322Full:
+	default:
+		return fmt.Errorf("vector '%s' has unknown format '%s'", v.Name, v.Format)
+	}
+
+	switch v.Expected {
+	case OutcomeValid:
+		if v.ErrorClass != "" {
+			return fmt.Errorf("valid vector '%s' has error class '%s'", v.Name, v.ErrorClass)
+		}
+	case OutcomeInvalid:
+		if v.ErrorClass == "" || v.ErrorClass == verifier.ErrorClassNone {
+			return fmt.Errorf("invalid vector '%s' has no error class", v.Name)
+		}
+	case OutcomeInconclusive:
+		if v.Comment == "" {
+			return fmt.Errorf("inconclusive vector '%s' does not explain why", v.Name)
+		}
+	default:
+		return fmt.Errorf("vector '%s' has unknown expected outcome '%s'", v.Name, v.Expected)
+	}
+
+	return nil
+}
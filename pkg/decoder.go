@@ -0,0 +1,185 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+)
+
+// ErrUnsupportedEnvelope is returned by a SignatureDecoder when the signature is not wrapped in the envelope it decodes.
+var ErrUnsupportedEnvelope = errors.New("unsupported signature envelope")
+
+// EncodingPSBT is a BIP-322 full signature wrapped in a base64 or hex encoded PSBT.
+const EncodingPSBT Encoding = "psbt"
+
+// Prefixes that identify the built-in envelopes.
+const (
+	smpPrefix       = "smp"
+	hexPrefix       = "0x"
+	psbtBase64Magic = "cHNidP8"
+	psbtHexMagic    = "70736274ff"
+)
+
+// DecodedSignature is a signature that has been taken out of its envelope.
+type DecodedSignature struct {
+	// Payload is the decoded signature.
+	Payload []byte
+	// Format is the proof format declared by the envelope, it is empty when the format follows from the payload and address.
+	Format Format
+	// Encoding is the text encoding the signature was provided in.
+	Encoding Encoding
+}
+
+// SignatureDecoder turns raw user input into a decoded signature.
+type SignatureDecoder interface {
+	// Decode decodes the signature. ErrUnsupportedEnvelope is returned when the signature is not wrapped in the envelope of the decoder,
+	// any other error means the envelope was recognized but is invalid.
+	Decode(signature string) (DecodedSignature, error)
+}
+
+// SignatureDecoderFunc adapts a function to a SignatureDecoder.
+type SignatureDecoderFunc func(signature string) (DecodedSignature, error)
+
+// Decode calls the function.
+func (f SignatureDecoderFunc) Decode(signature string) (DecodedSignature, error) {
+	return f(signature)
+}
+
+// DecoderRegistry holds the signature decoders that are tried, in order, until one recognizes the envelope of the signature.
+type DecoderRegistry struct {
+	decoders []SignatureDecoder
+}
+
+// NewDecoderRegistry creates a registry with the passed decoders, which are tried in the passed order.
+func NewDecoderRegistry(decoders ...SignatureDecoder) *DecoderRegistry {
+	return &DecoderRegistry{decoders: decoders}
+}
+
+// DefaultDecoders creates a registry with the built-in decoders: PSBT, hex with a 0x prefix, smp and base64 (see DecodeSignature).
+// The base64 decoder recognizes any signature, so it is always tried last.
+func DefaultDecoders() *DecoderRegistry {
+	return NewDecoderRegistry(PSBTDecoder{}, HexDecoder{}, SMPDecoder{}, Base64Decoder{})
+}
+
+// Register adds a decoder that is tried before all decoders that are already in the registry, so it can override them.
+// A registry is not safe for concurrent use while decoders are registered.
+func (r *DecoderRegistry) Register(decoder SignatureDecoder) {
+	r.decoders = append([]SignatureDecoder{decoder}, r.decoders...)
+}
+
+// Decode decodes the signature with the first decoder that recognizes its envelope.
+func (r *DecoderRegistry) Decode(signature string) (DecodedSignature, error) {
+	return r.decode(signature, Permissive())
+}
+
+// decode decodes the signature with the first decoder that recognizes its envelope, skipping the envelopes that are not allowed by the profile.
+func (r *DecoderRegistry) decode(signature string, profile Profile) (DecodedSignature, error) {
+	for _, decoder := range r.decoders {
+		decoded, err := decoder.Decode(signature)
+		if errors.Is(err, ErrUnsupportedEnvelope) || (err == nil && decoded.Encoding == EncodingSMP && !profile.AllowSMPPrefix) {
+			continue
+		}
+
+		return decoded, err
+	}
+
+	return DecodedSignature{}, fmt.Errorf("could not decode signature: %w", ErrUnsupportedEnvelope)
+}
+
+// Base64Decoder decodes signatures without an envelope, in any of the encodings supported by DecodeSignature. It recognizes any signature.
+type Base64Decoder struct{}
+
+// Decode decodes the signature with DecodeSignature.
+func (Base64Decoder) Decode(signature string) (DecodedSignature, error) {
+	payload, encoding, err := DecodeSignature(signature)
+	if err != nil {
+		return DecodedSignature{}, err
+	}
+
+	return DecodedSignature{Payload: payload, Format: "", Encoding: encoding}, nil
+}
+
+// SMPDecoder decodes signatures that are prefixed with 'smp', as some wallets do.
+// Signatures that can be decoded without removing the prefix are not recognized.
+type SMPDecoder struct{}
+
+// Decode removes the prefix and decodes the rest of the signature with DecodeSignature.
+func (SMPDecoder) Decode(signature string) (DecodedSignature, error) {
+	if !strings.HasPrefix(signature, smpPrefix) {
+		return DecodedSignature{}, ErrUnsupportedEnvelope
+	}
+
+	// The prefix consists of valid base64 characters, so only strip it when needed
+	if _, _, err := DecodeSignature(signature); err == nil {
+		return DecodedSignature{}, ErrUnsupportedEnvelope
+	}
+
+	payload, _, err := DecodeSignature(signature[len(smpPrefix):])
+	if err != nil {
+		return DecodedSignature{}, ErrUnsupportedEnvelope
+	}
+
+	return DecodedSignature{Payload: payload, Format: "", Encoding: EncodingSMP}, nil
+}
+
+// HexDecoder decodes hex encoded signatures that are prefixed with '0x'.
+type HexDecoder struct{}
+
+// Decode removes the prefix and decodes the rest of the signature as hex.
+func (HexDecoder) Decode(signature string) (DecodedSignature, error) {
+	signature = strings.TrimSpace(signature)
+	if len(signature) < len(hexPrefix) || !strings.EqualFold(signature[:len(hexPrefix)], hexPrefix) {
+		return DecodedSignature{}, ErrUnsupportedEnvelope
+	}
+
+	payload, err := hex.DecodeString(signature[len(hexPrefix):])
+	if err != nil {
+		return DecodedSignature{}, fmt.Errorf("could not decode signature: %w", err)
+	}
+
+	return DecodedSignature{Payload: payload, Format: "", Encoding: EncodingHex}, nil
+}
+
+// PSBTDecoder decodes BIP-322 full signatures that are wrapped in a base64 or hex encoded PSBT (BIP-174).
+// The PSBT has to be finalized, the payload is the signed toSign transaction.
+type PSBTDecoder struct{}
+
+// Decode extracts the signed toSign transaction from the PSBT.
+func (PSBTDecoder) Decode(signature string) (DecodedSignature, error) {
+	signature = strings.TrimSpace(signature)
+
+	var raw []byte
+	switch {
+	case strings.HasPrefix(signature, psbtBase64Magic):
+		raw = []byte(signature)
+	case strings.HasPrefix(strings.ToLower(signature), psbtHexMagic):
+		decoded, err := hex.DecodeString(signature)
+		if err != nil {
+			return DecodedSignature{}, fmt.Errorf("could not decode PSBT: %w", err)
+		}
+		raw = decoded
+	default:
+		return DecodedSignature{}, ErrUnsupportedEnvelope
+	}
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(raw), strings.HasPrefix(signature, psbtBase64Magic))
+	if err != nil {
+		return DecodedSignature{}, fmt.Errorf("could not decode PSBT: %w", err)
+	}
+
+	toSign, err := psbt.Extract(packet)
+	if err != nil {
+		return DecodedSignature{}, fmt.Errorf("could not extract transaction from PSBT: %w", err)
+	}
+
+	var payload bytes.Buffer
+	if err := toSign.Serialize(&payload); err != nil {
+		return DecodedSignature{}, fmt.Errorf("could not serialize transaction from PSBT: %w", err)
+	}
+
+	return DecodedSignature{Payload: payload.Bytes(), Format: FormatBIP322Full, Encoding: EncodingPSBT}, nil
+}
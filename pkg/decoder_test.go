@@ -0,0 +1,249 @@
+package verifier_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Generated with btcd, using the private key of the BIP-322 test vectors.
+const (
+	fullSignature         = "AAAAAAABASs1A9aiYU3q8XFsIzJcU+BRS0r8mBAcdxdSrUBnGZ23AAAAAAAAAAAAAQAAAAAAAAAAAWoCSDBFAiEA7PLKeWq33eU4omv7CabEh6ez//M/OX22og65r3fA7owCIGLmfkTIBw9Jw6N/WUCohQhC2vfMo15q9hpsfJHx4aGjASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHIAAAAA"
+	fullSignaturePSBT     = "cHNidP8BAD0AAAAAASs1A9aiYU3q8XFsIzJcU+BRS0r8mBAcdxdSrUBnGZ23AAAAAAAAAAAAAQAAAAAAAAAAAWoAAAAAAAEIbAJIMEUCIQDs8sp5arfd5Tiia/sJpsSHp7P/8z85fbaiDrmvd8DujAIgYuZ+RMgHD0nDo39ZQKiFCELa98yjXmr2Gmx8kfHhoaMBIQLH8SADGWRClD2FiOAa7oQEI8xU/BUhUmo7hcKwy9WIcgAA"
+	fullSignaturePSBTHex  = "70736274ff01003d00000000012b3503d6a2614deaf1716c23325c53e0514b4afc98101c771752ad4067199db7000000000000000000010000000000000000016a000000000001086c02483045022100ecf2ca796ab7dde538a26bfb09a6c487a7b3fff33f397db6a20eb9af77c0ee8c022062e67e44c8070f49c3a37f5940a8850842daf7cca35e6af61a6c7c91f1e1a1a3012102c7f12003196442943d8588e01aee840423cc54fc1521526a3b85c2b0cbd588720000"
+	unsignedSignaturePSBT = "cHNidP8BAD0AAAAAASs1A9aiYU3q8XFsIzJcU+BRS0r8mBAcdxdSrUBnGZ23AAAAAAAAAAAAAQAAAAAAAAAAAWoAAAAAAAAA"
+	fullSignatureP2PKH    = "AAAAAAHZIvdvR4fompS+lLTvaKJgjitVabp8CizknOvglZs2XgAAAABqRzBEAiB3hjKYQcm/KGTsalB3I4kixH3+uDyHQzt1PN5cBGJsvQIgJnRxSVWIbijmMST7VnxGpI8OOCU/tky8Pg7UH5HgSt4BIQLH8SADGWRClD2FiOAa7oQEI8xU/BUhUmo7hcKwy9WIcgAAAAABAAAAAAAAAAABagAAAAA="
+	simpleSignature       = "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+)
+
+type DecoderTestSuite struct {
+	suite.Suite
+}
+
+func TestDecoderTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(DecoderTestSuite))
+}
+
+func (s *DecoderTestSuite) TestDecode() {
+	simpleDecoded, err := base64.StdEncoding.DecodeString(simpleSignature)
+	s.Require().NoError(err)
+
+	fullDecoded, err := base64.StdEncoding.DecodeString(fullSignature)
+	s.Require().NoError(err)
+
+	tests := map[string]struct {
+		signature string
+		expected  verifier.DecodedSignature
+	}{
+		"base64": {
+			signature: simpleSignature,
+			expected:  verifier.DecodedSignature{Payload: simpleDecoded, Format: "", Encoding: verifier.EncodingBase64},
+		},
+		"hex with prefix": {
+			signature: "0x" + hex.EncodeToString(simpleDecoded),
+			expected:  verifier.DecodedSignature{Payload: simpleDecoded, Format: "", Encoding: verifier.EncodingHex},
+		},
+		"smp": {
+			signature: "smp" + simpleSignature,
+			expected:  verifier.DecodedSignature{Payload: simpleDecoded, Format: "", Encoding: verifier.EncodingSMP},
+		},
+		"psbt - base64": {
+			signature: fullSignaturePSBT,
+			expected:  verifier.DecodedSignature{Payload: fullDecoded, Format: verifier.FormatBIP322Full, Encoding: verifier.EncodingPSBT},
+		},
+		"psbt - hex": {
+			signature: fullSignaturePSBTHex,
+			expected:  verifier.DecodedSignature{Payload: fullDecoded, Format: verifier.FormatBIP322Full, Encoding: verifier.EncodingPSBT},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			decoded, err := verifier.DefaultDecoders().Decode(tt.signature)
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expected, decoded)
+		})
+	}
+}
+
+func (s *DecoderTestSuite) TestDecodeIncorrect() {
+	tests := map[string]struct {
+		signature     string
+		expectedError string
+	}{
+		"hex with prefix - invalid": {
+			signature:     "0xzz",
+			expectedError: "could not decode signature: encoding/hex: invalid byte: U+007A 'z'",
+		},
+		"psbt - not finalized": {
+			signature:     unsignedSignaturePSBT,
+			expectedError: "could not extract transaction from PSBT: PSBT cannot be extracted as it is incomplete",
+		},
+		"psbt - truncated": {
+			signature:     fullSignaturePSBTHex[:40],
+			expectedError: "could not decode PSBT: unexpected EOF",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			_, err := verifier.DefaultDecoders().Decode(tt.signature)
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
+
+func (s *DecoderTestSuite) TestVerifyFull() {
+	tests := map[string]struct {
+		signedMessage    verifier.SignedMessage
+		expectedEncoding verifier.Encoding
+	}{
+		"p2wpkh": {
+			signedMessage:    verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: fullSignature},
+			expectedEncoding: verifier.EncodingBase64,
+		},
+		"p2pkh": {
+			signedMessage:    verifier.SignedMessage{Address: "14vV3aCHBeStb5bkenkNHbe2YAFinYdXgc", Message: "Hello World", Signature: fullSignatureP2PKH},
+			expectedEncoding: verifier.EncodingBase64,
+		},
+		"psbt - base64": {
+			signedMessage:    verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: fullSignaturePSBT},
+			expectedEncoding: verifier.EncodingPSBT,
+		},
+		"psbt - hex": {
+			signedMessage:    verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: fullSignaturePSBTHex},
+			expectedEncoding: verifier.EncodingPSBT,
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(tt.signedMessage)
+			s.Require().NoError(err)
+			s.Require().True(result.Valid)
+			s.Require().Equal(verifier.FormatBIP322Full, result.Format)
+			s.Require().Equal(tt.expectedEncoding, result.Encoding)
+		})
+	}
+}
+
+func (s *DecoderTestSuite) TestVerifyFullIncorrect() {
+	_, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(verifier.SignedMessage{
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World - This should fail",
+		Signature: fullSignaturePSBT,
+	})
+	s.Require().EqualError(err, "invalid toSign transaction format: first input does not spend the toSpend transaction")
+
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithProfile(verifier.BitcoinCore())).Verify(verifier.SignedMessage{
+		Address:   "14vV3aCHBeStb5bkenkNHbe2YAFinYdXgc",
+		Message:   "Hello World",
+		Signature: fullSignatureP2PKH,
+	})
+	s.Require().EqualError(err, "BIP-322 signatures are not allowed by profile 'bitcoin-core'")
+}
+
+func (s *DecoderTestSuite) TestSMPProfile() {
+	signedMessage := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: "smp" + simpleSignature}
+
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+	s.Require().Equal(verifier.EncodingSMP, result.Encoding)
+
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithProfile(verifier.StrictBIP322())).Verify(signedMessage)
+	s.Require().ErrorContains(err, "could not decode signature: ")
+}
+
+func (s *DecoderTestSuite) TestRegister() {
+	// A custom envelope, which wraps the signature in a JSON object
+	jsonDecoder := verifier.SignatureDecoderFunc(func(signature string) (verifier.DecodedSignature, error) {
+		var envelope struct {
+			Signature string `json:"signature"`
+		}
+		if err := json.Unmarshal([]byte(signature), &envelope); err != nil {
+			return verifier.DecodedSignature{}, verifier.ErrUnsupportedEnvelope
+		}
+
+		return verifier.DefaultDecoders().Decode(envelope.Signature)
+	})
+
+	registry := verifier.DefaultDecoders()
+	registry.Register(jsonDecoder)
+
+	signedMessage := verifier.SignedMessage{
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World",
+		Signature: `{"signature": "` + simpleSignature + `"}`,
+	}
+
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithDecoders(registry)).Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+	s.Require().Equal(verifier.FormatBIP322Simple, result.Format)
+	s.Require().Equal(verifier.EncodingBase64, result.Encoding)
+
+	// The default registry does not know the envelope
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams).Verify(signedMessage)
+	s.Require().Error(err)
+
+	// Signatures that are not wrapped are still decoded by the built-in decoders
+	signedMessage.Signature = simpleSignature
+	result, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithDecoders(registry)).Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+}
+
+func (s *DecoderTestSuite) TestEmptyRegistry() {
+	_, err := verifier.NewDecoderRegistry().Decode(simpleSignature)
+	s.Require().EqualError(err, "could not decode signature: unsupported signature envelope")
+	s.Require().ErrorIs(err, verifier.ErrUnsupportedEnvelope)
+}
+
+func FuzzBase64Decoder(f *testing.F) {
+	fuzzDecoder(f, verifier.Base64Decoder{}, simpleSignature, fullSignature, "")
+}
+
+func FuzzSMPDecoder(f *testing.F) {
+	fuzzDecoder(f, verifier.SMPDecoder{}, "smp"+simpleSignature, "smp", "")
+}
+
+func FuzzHexDecoder(f *testing.F) {
+	fuzzDecoder(f, verifier.HexDecoder{}, "0x"+fullSignaturePSBTHex, "0xzz", "")
+}
+
+func FuzzPSBTDecoder(f *testing.F) {
+	fuzzDecoder(f, verifier.PSBTDecoder{}, fullSignaturePSBT, fullSignaturePSBTHex, unsignedSignaturePSBT, "")
+}
+
+// fuzzDecoder ensures a decoder never panics and that decoded signatures can be verified without panicking.
+func fuzzDecoder(f *testing.F, decoder verifier.SignatureDecoder, seeds ...string) {
+	f.Helper()
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, signature string) {
+		decoded, err := decoder.Decode(signature)
+		if err != nil {
+			return
+		}
+
+		address, err := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+		require.NoError(t, err)
+
+		_, _ = verifier.VerifyRaw(address, []byte("Hello World"), decoded.Payload, &chaincfg.MainNetParams)
+	})
+}
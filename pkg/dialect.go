@@ -0,0 +1,183 @@
+package verifier
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/samber/lo"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+)
+
+// Dialect is a wallet that produces signatures in a recognizable way.
+type Dialect string
+
+const (
+	// DialectBitcoinCore is Bitcoin Core, and wallets that sign like it.
+	DialectBitcoinCore Dialect = "bitcoin-core"
+	// DialectElectrum is Electrum.
+	DialectElectrum Dialect = "electrum"
+	// DialectCoinomi is Coinomi, which signs segwit addresses like Electrum.
+	DialectCoinomi Dialect = "coinomi"
+	// DialectSamourai is Samourai, which signs segwit addresses like Electrum.
+	DialectSamourai Dialect = "samourai"
+	// DialectMycelium is Mycelium, which signs segwit addresses like Electrum.
+	DialectMycelium Dialect = "mycelium"
+	// DialectTrezor is Trezor, and other wallets that follow BIP-137.
+	DialectTrezor Dialect = "trezor"
+	// DialectUniSat is UniSat.
+	DialectUniSat Dialect = "unisat"
+)
+
+// Values used to recognize DER encoded ECDSA signatures.
+const (
+	derRLengthIndex = 3
+	lowRMaxLength   = 32
+)
+
+// confidencePrecision rounds the confidence of a DialectMatch to two decimals.
+const confidencePrecision = 100
+
+// DialectMatch is a wallet that probably produced a signature.
+type DialectMatch struct {
+	// Dialect is the wallet.
+	Dialect Dialect
+	// Confidence is a heuristic score between 0 and 1, it is not a probability.
+	Confidence float64
+	// Reasons describe the signals that point to the wallet.
+	Reasons []string
+}
+
+// dialectSignal is an observation about a signature, with the weight it adds to each wallet it points to.
+type dialectSignal struct {
+	reason  string
+	weights map[Dialect]float64
+}
+
+// WithDialects enables fingerprinting, which fills Result.Dialects with the wallets that probably produced the signature.
+func WithDialects() Option {
+	return func(v *Verifier) {
+		v.dialects = true
+	}
+}
+
+// fingerprint guesses which wallets produced a signature, based on the recovery flag, the trimming of the message and the R value of ECDSA signatures.
+// The signals are heuristics, so the matches are ranked by confidence and only meant to guide troubleshooting and analysis.
+// Every signal is based on a documented or observed behavior of the wallets it points to, the source is referenced with the signal.
+// The 'smp' prefix and the shape and sighash byte of a BIP-322 witness are not used, as no such behavior of the wallets is known.
+func fingerprint(address btcutil.Address, signature DecodedSignature, format Format, messageTrimmed bool) []DialectMatch {
+	signals := []dialectSignal{}
+
+	// Electrum strips the message before signing, see do_sign in https://github.com/spesmilo/electrum/blob/4.5.5/electrum/gui/qt/main_window.py
+	if messageTrimmed {
+		signals = append(signals, dialectSignal{reason: "message was trimmed, as Electrum does before signing", weights: map[Dialect]float64{DialectElectrum: 0.7}})
+	}
+
+	switch format {
+	case FormatLegacy:
+		signals = append(signals, legacySignals(address, signature.Payload)...)
+	case FormatBIP322Simple:
+		if witness, err := bip322.SimpleSigToWitness(signature.Payload); err == nil {
+			signals = append(signals, witnessSignals(witness)...)
+		}
+	case FormatBIP322Full:
+		if toSign, err := bip322.DecodeFull(signature.Payload); err == nil && len(toSign.TxIn) > 0 {
+			signals = append(signals, witnessSignals(toSign.TxIn[0].Witness)...)
+		}
+	}
+
+	return rankDialects(signals)
+}
+
+// legacySignals returns the signals of the recovery flag of a legacy or BIP-137 signature.
+func legacySignals(address btcutil.Address, signatureDecoded []byte) []dialectSignal {
+	if len(signatureDecoded) == 0 {
+		return []dialectSignal{}
+	}
+
+	recoveryFlag := int(signatureDecoded[0])
+	_, isP2PKH := address.(*btcutil.AddressPubKeyHash)
+	_, isP2TR := address.(*btcutil.AddressTaproot)
+
+	switch {
+	// Trezor signs segwit addresses with the flags of BIP-137, see https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+	case lo.Contains(flags.Trezor(), recoveryFlag):
+		return []dialectSignal{{reason: "recovery flag is a BIP-137 (Trezor) flag", weights: map[Dialect]float64{DialectTrezor: 0.8}}}
+	// Bitcoin Core only uses compressed keys since 0.6.0, see https://github.com/bitcoin/bitcoin/blob/master/doc/release-notes/release-notes-0.6.0.md
+	case lo.Contains(flags.Uncompressed(), recoveryFlag):
+		if !isP2PKH {
+			return []dialectSignal{}
+		}
+
+		return []dialectSignal{{reason: "recovery flag is uncompressed, as used by older wallets", weights: map[Dialect]float64{DialectBitcoinCore: 0.3}}}
+	// UniSat signs with a legacy signature by default, also for taproot addresses, see the 'unisat - p2tr' vector in pkg/conformance/vectors/legacy.json
+	case isP2TR:
+		return []dialectSignal{{reason: "legacy signature for a taproot address, as UniSat does", weights: map[Dialect]float64{DialectUniSat: 0.7}}}
+	// Bitcoin Core only signs for P2PKH addresses, see https://github.com/bitcoin/bitcoin/blob/v27.0/src/wallet/rpc/signmessage.cpp
+	case isP2PKH:
+		return []dialectSignal{{reason: "recovery flag is compressed for a P2PKH address", weights: map[Dialect]float64{DialectBitcoinCore: 0.4, DialectElectrum: 0.3}}}
+	// The wallets that sign segwit addresses like Electrum, see the electrum, coinomi, samourai and mycelium vectors in pkg/conformance/vectors/legacy.json
+	default:
+		return []dialectSignal{{
+			reason:  "recovery flag is compressed for a segwit address, as Electrum does",
+			weights: map[Dialect]float64{DialectElectrum: 0.6, DialectCoinomi: 0.3, DialectSamourai: 0.3, DialectMycelium: 0.3},
+		}}
+	}
+}
+
+// witnessSignals returns the signals of the signatures in a BIP-322 witness stack.
+func witnessSignals(witness [][]byte) []dialectSignal {
+	signals := []dialectSignal{}
+
+	for _, item := range classifyWitness(witness) {
+		// Bitcoin Core grinds the nonce until R fits in 32 bytes since 0.17.0, which only happens by chance for half of the signatures of other wallets.
+		// See https://github.com/bitcoin/bitcoin/pull/13666
+		if item.Kind == WitnessItemECDSASignature && item.SigHashType == txscript.SigHashAll && item.Data[derRLengthIndex] <= lowRMaxLength {
+			signals = append(signals, dialectSignal{reason: "ECDSA signature has a low R value", weights: map[Dialect]float64{DialectBitcoinCore: 0.3}})
+		}
+	}
+
+	return signals
+}
+
+// rankDialects combines the signals per wallet and ranks the wallets by confidence, the highest first.
+// Signals are treated as independent evidence: the confidence is 1 - (1 - w1) * (1 - w2) * ...
+func rankDialects(signals []dialectSignal) []DialectMatch {
+	matches := map[Dialect]*DialectMatch{}
+	for _, signal := range signals {
+		for dialect, weight := range signal.weights {
+			match, found := matches[dialect]
+			if !found {
+				match = &DialectMatch{Dialect: dialect, Confidence: 0, Reasons: []string{}}
+				matches[dialect] = match
+			}
+
+			match.Confidence = 1 - (1-match.Confidence)*(1-weight)
+			match.Reasons = append(match.Reasons, signal.reason)
+		}
+	}
+
+	// Round the confidence, so the ranking is stable and readable
+	ranked := lo.Map(lo.Values(matches), func(match *DialectMatch, _ int) DialectMatch {
+		match.Confidence = math.Round(match.Confidence*confidencePrecision) / confidencePrecision
+
+		return *match
+	})
+	slices.SortFunc(ranked, func(a, b DialectMatch) int {
+		if a.Confidence != b.Confidence {
+			if a.Confidence > b.Confidence {
+				return -1
+			}
+
+			return 1
+		}
+
+		return strings.Compare(string(a.Dialect), string(b.Dialect))
+	})
+
+	return ranked
+}
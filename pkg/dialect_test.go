@@ -0,0 +1,122 @@
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type DialectTestSuite struct {
+	suite.Suite
+}
+
+func TestDialectTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(DialectTestSuite))
+}
+
+func (s *DialectTestSuite) TestDialects() {
+	tests := map[string]struct {
+		signedMessage verifier.SignedMessage
+		expected      []verifier.Dialect
+		expectedTop   verifier.DialectMatch
+	}{
+		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+		"trezor - segwit native": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+				Message:   "This is an example of a signed message.",
+				Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
+			},
+			expected:    []verifier.Dialect{verifier.DialectTrezor},
+			expectedTop: verifier.DialectMatch{Dialect: verifier.DialectTrezor, Confidence: 0.8, Reasons: []string{"recovery flag is a BIP-137 (Trezor) flag"}},
+		},
+		// Same signature as the Electrum test of the generic package
+		"electrum - segwit native": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "bc1qsdjne3y6ljndzvg9z9qrhje8k7p2m5yas704hn",
+				Message:   "Integer can be encoded depending on the represented value to save space. Variable length integers always precede an array/vector of a type of data that may vary in length. Longer numbers are encoded in little endian. If you're reading the Satoshi client code (BitcoinQT) it refers to this encoding as a \"CompactSize\". Modern Bitcoin Core also has the VARINT macro which implements an even more compact integer for the purpose of local storage (which is incompatible with \"CompactSize\" described here). VARINT is not a part of the protocol.",
+				Signature: "H3TkHAXCKRfyDowCra5YRDF/Vkk2HQCel/pgEgTj9LYaWpnviSRcuYtv/CZk7NTyHsJnYP56bqbvuU3PejwLCnA=",
+			},
+			expected: []verifier.Dialect{verifier.DialectElectrum, verifier.DialectCoinomi, verifier.DialectMycelium, verifier.DialectSamourai},
+			expectedTop: verifier.DialectMatch{
+				Dialect: verifier.DialectElectrum, Confidence: 0.6, Reasons: []string{"recovery flag is compressed for a segwit address, as Electrum does"},
+			},
+		},
+		// Generated via https://demo.unisat.io/
+		"unisat - taproot": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "bc1pgc9k3vdmr9aecmwj09qg5qv550qyyrydufyfmxrsvk5474rxenuqrq4lcz",
+				Message:   "hello world",
+				Signature: "H/KLWcCfl/P34V9TdPzcSlG3sdhllArBXjypbz9BBY1GXDRCwYogO50Crznm8I9P/JAfhnojgbV5vPYSAhWA1p0=",
+			},
+			expected:    []verifier.Dialect{verifier.DialectUniSat},
+			expectedTop: verifier.DialectMatch{Dialect: verifier.DialectUniSat, Confidence: 0.7, Reasons: []string{"legacy signature for a taproot address, as UniSat does"}},
+		},
+		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
+		"legacy - compressed - untrimmed": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+				Message:   "  test message  ",
+				Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			},
+			expected: []verifier.Dialect{verifier.DialectElectrum, verifier.DialectBitcoinCore},
+			expectedTop: verifier.DialectMatch{
+				Dialect: verifier.DialectElectrum, Confidence: 0.79, Reasons: []string{"message was trimmed, as Electrum does before signing", "recovery flag is compressed for a P2PKH address"},
+			},
+		},
+		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+		"bip-322 - native segwit - low r": {
+			signedMessage: verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature},
+			expected:      []verifier.Dialect{verifier.DialectBitcoinCore},
+			expectedTop:   verifier.DialectMatch{Dialect: verifier.DialectBitcoinCore, Confidence: 0.3, Reasons: []string{"ECDSA signature has a low R value"}},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithDialects()).Verify(tt.signedMessage)
+			s.Require().NoError(err)
+			s.Require().True(result.Valid)
+			s.Require().Equal(tt.expected, lo.Map(result.Dialects, func(match verifier.DialectMatch, _ int) verifier.Dialect { return match.Dialect }))
+			s.Require().Equal(tt.expectedTop, result.Dialects[0])
+		})
+	}
+}
+
+func (s *DialectTestSuite) TestDialectsInvalid() {
+	// Dialects are also reported when the signature is not valid, but not when it can not be decoded
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithDialects()).Verify(verifier.SignedMessage{
+		Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+		Message:   "This is not the signed message.",
+		Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
+	})
+	s.Require().Error(err)
+	s.Require().False(result.Valid)
+	s.Require().Equal(verifier.DialectTrezor, result.Dialects[0].Dialect)
+
+	result, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithDialects()).Verify(verifier.SignedMessage{
+		Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+		Message:   "This is an example of a signed message.",
+		Signature: "not a signature",
+	})
+	s.Require().Error(err)
+	s.Require().Empty(result.Dialects)
+}
+
+func (s *DialectTestSuite) TestDialectsNotRequested() {
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(verifier.SignedMessage{
+		Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+		Message:   "This is an example of a signed message.",
+		Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
+	})
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+	s.Require().Empty(result.Dialects)
+}
@@ -0,0 +1,48 @@
+package verifier
+
+import (
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/bitonicnl/verify-signed-message/internal"
+)
+
+// MessageDigest holds the hashes of a message that signatures are made over.
+// It allows verifying a signature without having access to the message itself.
+type MessageDigest struct {
+	// Legacy is the double SHA-256 hash of the magic message, which legacy and BIP-137 signatures are made over.
+	Legacy []byte
+	// BIP322 is the BIP-322 tagged hash of the message, which is committed to in the toSpend transaction.
+	BIP322 []byte
+}
+
+// NewMessageDigest computes both hashes of a message of arbitrary bytes.
+func NewMessageDigest(message []byte) MessageDigest {
+	return MessageDigest{Legacy: LegacyMessageHash(message), BIP322: BIP322MessageHash(message)}
+}
+
+// LegacyMessageHash returns the double SHA-256 hash of the magic message, which legacy and BIP-137 signatures are made over.
+func LegacyMessageHash(message []byte) []byte {
+	return internal.HashMagicMessage(message)
+}
+
+// BIP322MessageHash returns the BIP-322 tagged hash of the message, which is committed to in the toSpend transaction.
+func BIP322MessageHash(message []byte) []byte {
+	messageHash := internal.CreateMagicMessageBIP322(message)
+
+	return messageHash[:]
+}
+
+// VerifyDigest will verify a decoded signature for a decoded address and the digest of a message on the passed network.
+// Only the hash that is required for the signature has to be set in the digest, the compatibilities of Permissive that operate on decoded values are applied.
+// The signature is bounded by DefaultLimits, the message does not have to be known at all.
+//
+// The digest and signature are never modified, so it is safe to call VerifyDigest concurrently on shared buffers.
+func VerifyDigest(address btcutil.Address, digest MessageDigest, signature []byte, net *chaincfg.Params) (bool, error) {
+	handler, format, err := resolveDecoded(address, signature, net)
+	if err != nil {
+		return false, err
+	}
+
+	return handler.Verify(address, digest, signature, format, net)
+}
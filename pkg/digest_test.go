@@ -0,0 +1,136 @@
+package verifier_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type DigestTestSuite struct {
+	suite.Suite
+}
+
+func TestDigestTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(DigestTestSuite))
+}
+
+// Test vectors taken from https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#message-hashing
+func (s *DigestTestSuite) TestNewMessageDigest() {
+	digest := verifier.NewMessageDigest([]byte("Hello World"))
+	s.Require().Equal("f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a", hex.EncodeToString(digest.BIP322))
+	s.Require().Equal(verifier.LegacyMessageHash([]byte("Hello World")), digest.Legacy)
+}
+
+func (s *DigestTestSuite) TestVerifyDigest() {
+	tests := map[string]verifier.SignedMessage{
+		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
+		"generic - legacy - compressed": {
+			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			Message:   "test message",
+			Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+		},
+		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+		"bip-322 - native segwit - test vector #0": {
+			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			Message:   "Hello World",
+			Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+		},
+		// Single key taproot bip-322 signature (created with the buidl-python library)
+		// Taken from: https://github.com/luke-jr/bitcoin/blob/9ab7b8ada61a5f558c92c3eb9fd3cd3625d8cc09/src/test/util_tests.cpp#L1754
+		"buidl-python - taproot": {
+			Address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+			Message:   "Hello World",
+			Signature: "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			address, err := btcutil.DecodeAddress(tt.Address, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+
+			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.Signature)
+			s.Require().NoError(err)
+
+			// Only the hashes are passed, never the message itself
+			valid, err := verifier.VerifyDigest(address, verifier.NewMessageDigest([]byte(tt.Message)), signatureDecoded, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+			s.True(valid)
+		})
+	}
+}
+
+func (s *DigestTestSuite) TestVerifyDigestIncorrect() {
+	legacyAddress, err := btcutil.DecodeAddress("1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	legacySignature, err := base64.StdEncoding.DecodeString("IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=")
+	s.Require().NoError(err)
+
+	bip322Address, err := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	bip322Signature, err := base64.StdEncoding.DecodeString("AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=")
+	s.Require().NoError(err)
+
+	tests := map[string]struct {
+		address       btcutil.Address
+		digest        verifier.MessageDigest
+		signature     []byte
+		expectedError string
+	}{
+		"legacy - missing hash": {
+			address:       legacyAddress,
+			digest:        verifier.MessageDigest{BIP322: verifier.BIP322MessageHash([]byte("test message"))},
+			signature:     legacySignature,
+			expectedError: "legacy hash should be 32 bytes, got 0",
+		},
+		"bip-322 - missing hash": {
+			address:       bip322Address,
+			digest:        verifier.MessageDigest{Legacy: verifier.LegacyMessageHash([]byte("Hello World"))},
+			signature:     bip322Signature,
+			expectedError: "BIP-322 hash should be 32 bytes, got 0",
+		},
+		"bip-322 - wrong hash": {
+			address:       bip322Address,
+			digest:        verifier.MessageDigest{BIP322: verifier.BIP322MessageHash([]byte("Hello World - This should fail"))},
+			signature:     bip322Signature,
+			expectedError: "script execution failed: signature not empty on failed checksig",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			valid, err := verifier.VerifyDigest(tt.address, tt.digest, tt.signature, &chaincfg.MainNetParams)
+			s.Require().EqualError(err, tt.expectedError)
+			s.False(valid)
+		})
+	}
+}
+
+func (s *DigestTestSuite) TestVerifyBinaryMessage() {
+	privateKey, _ := btcec.PrivKeyFromBytes([]byte("binary message signing test key!"))
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(privateKey.PubKey().SerializeCompressed()), &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	// Not valid UTF-8
+	message := []byte{0x00, 0xff, 0xfe, 0x80, 0x0a}
+	signature := ecdsa.SignCompact(privateKey, verifier.LegacyMessageHash(message), true)
+
+	valid, err := verifier.VerifyRaw(address, message, signature, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	s.True(valid)
+
+	valid, err = verifier.VerifyDigest(address, verifier.MessageDigest{Legacy: verifier.LegacyMessageHash(message)}, signature, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	s.True(valid)
+}
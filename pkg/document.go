@@ -0,0 +1,253 @@
+package verifier
+
+import (
+	"bytes"
+	_ "embed" // Required to embed the JSON Schema
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+)
+
+// DocumentVersion is the version of the proof document that is created and accepted.
+const DocumentVersion = 1
+
+// MessageEncoding is the encoding of the message in a Document.
+type MessageEncoding string
+
+const (
+	// MessageEncodingUTF8 is a message that is included as is, it must be valid UTF-8.
+	MessageEncodingUTF8 MessageEncoding = "utf8"
+	// MessageEncodingHex is a hex encoded message, which allows for binary messages.
+	MessageEncodingHex MessageEncoding = "hex"
+)
+
+//go:embed schema/document-v1.schema.json
+var documentSchema []byte
+
+// DocumentSchema returns the JSON Schema of the proof document, which is also published as pkg/schema/document-v1.schema.json.
+func DocumentSchema() []byte {
+	return bytes.Clone(documentSchema)
+}
+
+// Document is a versioned proof document, meant to store and exchange proofs without losing information.
+// It is (un)marshalled as JSON, following the schema returned by DocumentSchema.
+type Document struct {
+	// Version of the document, this is always DocumentVersion.
+	Version int
+	// Address that was used to sign the message with.
+	Address string
+	// Message that has been signed by the Address, encoded as described by MessageEncoding.
+	Message string
+	// MessageEncoding is the encoding of the Message.
+	MessageEncoding MessageEncoding
+	// Signature that should be valid against the Address and Message.
+	Signature string
+	// Network is the name of the network of the Address, for example 'mainnet' or 'testnet3'.
+	Network string
+	// Format is the declared proof format of the Signature.
+	Format Format
+	// CreatedAt is the optional moment the proof was created.
+	CreatedAt *time.Time
+}
+
+// documentJSON is the JSON representation of a Document, pointers are used to detect missing fields.
+type documentJSON struct {
+	Version         *int             `json:"version"`
+	Address         *string          `json:"address"`
+	Message         *string          `json:"message"`
+	MessageEncoding *MessageEncoding `json:"messageEncoding"`
+	Signature       *string          `json:"signature"`
+	Network         *string          `json:"network"`
+	Format          *Format          `json:"format"`
+	CreatedAt       *time.Time       `json:"createdAt,omitempty"`
+}
+
+// NewDocument creates a Document for a SignedMessage. The message is hex encoded when it is not valid UTF-8.
+func NewDocument(signedMessage SignedMessage, net *chaincfg.Params, format Format) Document {
+	message, messageEncoding := signedMessage.Message, MessageEncodingUTF8
+	if !utf8.ValidString(message) {
+		message, messageEncoding = hex.EncodeToString([]byte(message)), MessageEncodingHex
+	}
+
+	return Document{
+		Version:         DocumentVersion,
+		Address:         signedMessage.Address,
+		Message:         message,
+		MessageEncoding: messageEncoding,
+		Signature:       signedMessage.Signature,
+		Network:         net.Name,
+		Format:          format,
+		CreatedAt:       nil,
+	}
+}
+
+// Validate ensures the document is complete and consistent, the signature itself is not verified.
+func (d Document) Validate() error {
+	if d.Version != DocumentVersion {
+		return fmt.Errorf("unsupported document version %d, expected %d", d.Version, DocumentVersion)
+	}
+
+	if d.Address == "" {
+		return errors.New("document address is empty")
+	}
+
+	if d.Signature == "" {
+		return errors.New("document signature is empty")
+	}
+
+	if _, err := d.decodeMessage(); err != nil {
+		return err
+	}
+
+	if _, err := d.Net(); err != nil {
+		return err
+	}
+
+	if !lo.Contains(documentFormats(), d.Format) {
+		return fmt.Errorf("unknown document format '%s'", d.Format)
+	}
+
+	return nil
+}
+
+// Net returns the parameters of the network of the document.
+func (d Document) Net() (*chaincfg.Params, error) {
+	return NetworkByName(d.Network)
+}
+
+// SignedMessage returns the SignedMessage of the document, with the message decoded.
+func (d Document) SignedMessage() (SignedMessage, error) {
+	message, err := d.decodeMessage()
+	if err != nil {
+		return SignedMessage{}, err
+	}
+
+	return SignedMessage{Address: d.Address, Message: message, Signature: d.Signature}, nil
+}
+
+// MarshalJSON validates the document and marshals it as JSON.
+func (d Document) MarshalJSON() ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	return json.Marshal(documentJSON{
+		Version:         &d.Version,
+		Address:         &d.Address,
+		Message:         &d.Message,
+		MessageEncoding: &d.MessageEncoding,
+		Signature:       &d.Signature,
+		Network:         &d.Network,
+		Format:          &d.Format,
+		CreatedAt:       d.CreatedAt,
+	})
+}
+
+// UnmarshalJSON strictly unmarshals and validates a document: unknown fields, missing fields and trailing data are rejected.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var raw documentJSON
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid document: %w", err)
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid document: unexpected data after the document")
+	}
+
+	fields := []struct {
+		name    string
+		missing bool
+	}{
+		{name: "version", missing: raw.Version == nil},
+		{name: "address", missing: raw.Address == nil},
+		{name: "message", missing: raw.Message == nil},
+		{name: "messageEncoding", missing: raw.MessageEncoding == nil},
+		{name: "signature", missing: raw.Signature == nil},
+		{name: "network", missing: raw.Network == nil},
+		{name: "format", missing: raw.Format == nil},
+	}
+	for _, field := range fields {
+		if field.missing {
+			return fmt.Errorf("invalid document: missing field '%s'", field.name)
+		}
+	}
+
+	document := Document{
+		Version:         *raw.Version,
+		Address:         *raw.Address,
+		Message:         *raw.Message,
+		MessageEncoding: *raw.MessageEncoding,
+		Signature:       *raw.Signature,
+		Network:         *raw.Network,
+		Format:          *raw.Format,
+		CreatedAt:       raw.CreatedAt,
+	}
+	if err := document.Validate(); err != nil {
+		return fmt.Errorf("invalid document: %w", err)
+	}
+
+	*d = document
+
+	return nil
+}
+
+// VerifyDocument validates and verifies a Document on its own network.
+// Besides being valid, the signature has to match the format declared in the document.
+func VerifyDocument(document Document, options ...Option) (Result, error) {
+	if err := document.Validate(); err != nil {
+		return Result{Valid: false, Format: "", Encoding: "", MessageTrimmed: false, Dialects: []DialectMatch{}, Trace: nil, ErrorClass: ErrorClassFormat}, fmt.Errorf("invalid document: %w", err)
+	}
+
+	// Both have been validated above
+	net, _ := document.Net()
+	signedMessage, _ := document.SignedMessage()
+
+	result, err := NewVerifier(net, options...).Verify(signedMessage)
+	if err != nil {
+		return result, err
+	}
+
+	if result.Format != document.Format {
+		result.Valid, result.ErrorClass = false, ErrorClassFormat
+
+		return result, fmt.Errorf("signature was verified as format '%s', but the document declares '%s'", result.Format, document.Format)
+	}
+
+	return result, nil
+}
+
+// decodeMessage decodes the message according to its encoding.
+func (d Document) decodeMessage() (string, error) {
+	switch d.MessageEncoding {
+	case MessageEncodingUTF8:
+		if !utf8.ValidString(d.Message) {
+			return "", errors.New("document message is not valid UTF-8")
+		}
+
+		return d.Message, nil
+	case MessageEncodingHex:
+		message, err := hex.DecodeString(d.Message)
+		if err != nil {
+			return "", fmt.Errorf("could not decode document message: %w", err)
+		}
+
+		return string(message), nil
+	default:
+		return "", fmt.Errorf("unknown document message encoding '%s'", d.MessageEncoding)
+	}
+}
+
+// documentFormats returns the formats that can be declared in a Document.
+func documentFormats() []Format {
+	return []Format{FormatLegacy, FormatBIP322Simple, FormatBIP322Full}
+}
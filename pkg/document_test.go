@@ -0,0 +1,199 @@
+package verifier_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type DocumentTestSuite struct {
+	suite.Suite
+}
+
+func TestDocumentTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(DocumentTestSuite))
+}
+
+func (s *DocumentTestSuite) document() verifier.Document {
+	return verifier.NewDocument(verifier.SignedMessage{
+		Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		Message:   "test message",
+		Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+	}, &chaincfg.MainNetParams, verifier.FormatLegacy)
+}
+
+func (s *DocumentTestSuite) TestNewDocument() {
+	s.Require().Equal(verifier.Document{
+		Version:         1,
+		Address:         "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		Message:         "test message",
+		MessageEncoding: verifier.MessageEncodingUTF8,
+		Signature:       "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+		Network:         "mainnet",
+		Format:          verifier.FormatLegacy,
+		CreatedAt:       nil,
+	}, s.document())
+
+	binary := verifier.NewDocument(verifier.SignedMessage{Address: "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", Message: "\xff\x00", Signature: "sig"}, &chaincfg.TestNet3Params, verifier.FormatLegacy)
+	s.Require().Equal("ff00", binary.Message)
+	s.Require().Equal(verifier.MessageEncodingHex, binary.MessageEncoding)
+	s.Require().Equal("testnet3", binary.Network)
+}
+
+func (s *DocumentTestSuite) TestMarshalJSON() {
+	document := s.document()
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	document.CreatedAt = &createdAt
+
+	encoded, err := json.Marshal(document)
+	s.Require().NoError(err)
+	s.Require().JSONEq(`{
+		"version": 1,
+		"address": "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		"message": "test message",
+		"messageEncoding": "utf8",
+		"signature": "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+		"network": "mainnet",
+		"format": "legacy",
+		"createdAt": "2024-01-02T03:04:05Z"
+	}`, string(encoded))
+
+	var decoded verifier.Document
+	s.Require().NoError(json.Unmarshal(encoded, &decoded))
+	s.Require().Equal(document, decoded)
+
+	// Without the optional field
+	encoded, err = json.Marshal(s.document())
+	s.Require().NoError(err)
+	s.Require().NotContains(string(encoded), "createdAt")
+
+	// Invalid documents can not be marshalled
+	document.Network = "unknown"
+	_, err = json.Marshal(document)
+	s.Require().EqualError(err, "json: error calling MarshalJSON for type *verifier.Document: invalid document: unknown network 'unknown', expected one of: mainnet, testnet3, testnet4, signet, regtest, simnet")
+}
+
+func (s *DocumentTestSuite) TestUnmarshalJSONErrors() {
+	tests := map[string]struct {
+		json          string
+		expectedError string
+	}{
+		"unknown field": {
+			json:          `{"version":1,"address":"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5","message":"","messageEncoding":"utf8","signature":"sig","network":"mainnet","format":"legacy","extra":true}`,
+			expectedError: `invalid document: json: unknown field "extra"`,
+		},
+		"missing field": {
+			json:          `{"version":1,"address":"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5","message":"","signature":"sig","network":"mainnet","format":"legacy"}`,
+			expectedError: "invalid document: missing field 'messageEncoding'",
+		},
+		"null field": {
+			json:          `{"version":1,"address":null,"message":"","messageEncoding":"utf8","signature":"sig","network":"mainnet","format":"legacy"}`,
+			expectedError: "invalid document: missing field 'address'",
+		},
+		"unsupported version": {
+			json:          `{"version":2,"address":"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5","message":"","messageEncoding":"utf8","signature":"sig","network":"mainnet","format":"legacy"}`,
+			expectedError: "invalid document: unsupported document version 2, expected 1",
+		},
+		"invalid hex message": {
+			json:          `{"version":1,"address":"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5","message":"xyz","messageEncoding":"hex","signature":"sig","network":"mainnet","format":"legacy"}`,
+			expectedError: "invalid document: could not decode document message: encoding/hex: invalid byte: U+0078 'x'",
+		},
+		"unknown message encoding": {
+			json:          `{"version":1,"address":"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5","message":"","messageEncoding":"base64","signature":"sig","network":"mainnet","format":"legacy"}`,
+			expectedError: "invalid document: unknown document message encoding 'base64'",
+		},
+		"unknown format": {
+			json:          `{"version":1,"address":"1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5","message":"","messageEncoding":"utf8","signature":"sig","network":"mainnet","format":"unknown"}`,
+			expectedError: "invalid document: unknown document format 'unknown'",
+		},
+		"empty address": {
+			json:          `{"version":1,"address":"","message":"","messageEncoding":"utf8","signature":"sig","network":"mainnet","format":"legacy"}`,
+			expectedError: "invalid document: document address is empty",
+		},
+		"wrong type": {
+			json:          `{"version":"1"}`,
+			expectedError: "invalid document: json: cannot unmarshal string into Go struct field documentJSON.version of type int",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			var document verifier.Document
+			s.Require().EqualError(document.UnmarshalJSON([]byte(tt.json)), tt.expectedError)
+		})
+	}
+}
+
+func (s *DocumentTestSuite) TestVerifyDocument() {
+	result, err := verifier.VerifyDocument(s.document())
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+
+	// Hex encoded messages are decoded before verifying
+	document := s.document()
+	document.Message, document.MessageEncoding = "74657374206d657373616765", verifier.MessageEncodingHex
+	result, err = verifier.VerifyDocument(document)
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+
+	// The declared format has to match
+	document = s.document()
+	document.Format = verifier.FormatBIP322Simple
+	result, err = verifier.VerifyDocument(document)
+	s.Require().EqualError(err, "signature was verified as format 'legacy', but the document declares 'bip322-simple'")
+	s.Require().False(result.Valid)
+	s.Require().Equal(verifier.ErrorClassFormat, result.ErrorClass)
+
+	// The document is verified on its own network
+	document = s.document()
+	document.Network = "testnet3"
+	result, err = verifier.VerifyDocument(document)
+	s.Require().EqualError(err, "could not decode address: unknown address type")
+	s.Require().Equal(verifier.ErrorClassAddress, result.ErrorClass)
+
+	// Invalid documents are not verified
+	document = s.document()
+	document.Version = 0
+	result, err = verifier.VerifyDocument(document)
+	s.Require().EqualError(err, "invalid document: unsupported document version 0, expected 1")
+	s.Require().Equal(verifier.ErrorClassFormat, result.ErrorClass)
+}
+
+func (s *DocumentTestSuite) TestDocumentSchema() {
+	var schema struct {
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Const *int     `json:"const"`
+			Enum  []string `json:"enum"`
+		} `json:"properties"`
+	}
+	s.Require().NoError(json.Unmarshal(verifier.DocumentSchema(), &schema))
+
+	// The schema has to describe what MarshalJSON produces
+	encoded, err := json.Marshal(s.document())
+	s.Require().NoError(err)
+
+	var fields map[string]any
+	s.Require().NoError(json.Unmarshal(encoded, &fields))
+	s.Require().ElementsMatch(schema.Required, lo.Keys(fields))
+	s.Require().Contains(schema.Properties, "createdAt")
+	s.Require().Equal(verifier.DocumentVersion, *schema.Properties["version"].Const)
+	s.Require().Equal([]string{string(verifier.MessageEncodingUTF8), string(verifier.MessageEncodingHex)}, schema.Properties["messageEncoding"].Enum)
+	s.Require().Equal([]string{string(verifier.FormatLegacy), string(verifier.FormatBIP322Simple), string(verifier.FormatBIP322Full)}, schema.Properties["format"].Enum)
+
+	// Every network in the schema has to be accepted
+	for _, network := range schema.Properties["network"].Enum {
+		document := s.document()
+		document.Network = network
+		s.Require().NoError(document.Validate())
+	}
+}
@@ -0,0 +1,124 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/samber/lo"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+	"github.com/bitonicnl/verify-signed-message/internal/generic"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+)
+
+// Encoding is the text encoding a signature was provided in.
+type Encoding string
+
+const (
+	// EncodingBase64 is standard base64 with padding, as specified by all signing methods.
+	EncodingBase64 Encoding = "base64"
+	// EncodingBase64URL is URL-safe base64 with padding.
+	EncodingBase64URL Encoding = "base64url"
+	// EncodingBase64Unpadded is standard base64 without padding.
+	EncodingBase64Unpadded Encoding = "base64-unpadded"
+	// EncodingBase64URLUnpadded is URL-safe base64 without padding.
+	EncodingBase64URLUnpadded Encoding = "base64url-unpadded"
+	// EncodingHex is hexadecimal, as printed by some hardware wallet tools.
+	EncodingHex Encoding = "hex"
+	// EncodingSMP is base64 prefixed with 'smp'.
+	EncodingSMP Encoding = "smp"
+)
+
+// signatureEncoding couples an Encoding to the function that decodes it.
+type signatureEncoding struct {
+	encoding Encoding
+	decode   func(string) ([]byte, error)
+}
+
+// signatureEncodings returns all supported encodings, in the order they are tried.
+func signatureEncodings() []signatureEncoding {
+	return []signatureEncoding{
+		{encoding: EncodingBase64, decode: base64.StdEncoding.DecodeString},
+		{encoding: EncodingBase64URL, decode: base64.URLEncoding.DecodeString},
+		{encoding: EncodingBase64Unpadded, decode: base64.RawStdEncoding.DecodeString},
+		{encoding: EncodingBase64URLUnpadded, decode: base64.RawURLEncoding.DecodeString},
+		{encoding: EncodingHex, decode: hex.DecodeString},
+	}
+}
+
+// DecodeSignature decodes a signature of which the encoding is not known, any whitespace in the signature is ignored.
+//
+// All encodings are tried in a defined order: base64, base64url, base64 without padding, base64url without padding and hex.
+// The encoding that results in something that looks like a legacy or BIP-322 signature is used, if there is exactly one.
+// If there are more, the signature is ambiguous and rejected, even when one of them is standard base64.
+// If there are none, standard base64 is used when it decodes, so the verification reports what is wrong with the signature.
+func DecodeSignature(signature string) ([]byte, Encoding, error) {
+	// Remove line breaks and other whitespace, which are common in copied signatures
+	signature = strings.Join(strings.FieldsFunc(signature, unicode.IsSpace), "")
+
+	var (
+		standard    []byte
+		standardErr error
+		decoded     []decodedSignature
+	)
+
+	for _, candidate := range signatureEncodings() {
+		payload, err := candidate.decode(signature)
+		if candidate.encoding == EncodingBase64 {
+			standard, standardErr = payload, err
+		}
+
+		// Skip failures and payloads we have already seen in an earlier encoding
+		if err != nil || lo.ContainsBy(decoded, func(item decodedSignature) bool { return bytes.Equal(item.payload, payload) }) {
+			continue
+		}
+
+		decoded = append(decoded, decodedSignature{payload: payload, encoding: candidate.encoding})
+	}
+
+	plausible := lo.Filter(decoded, func(item decodedSignature, _ int) bool { return isPlausibleSignature(item.payload) })
+	switch {
+	case len(plausible) == 1:
+		return plausible[0].payload, plausible[0].encoding, nil
+	case len(plausible) > 1:
+		return nil, "", fmt.Errorf("could not decode signature: ambiguous encoding, could be any of %s", joinEncodings(plausible))
+	case standardErr == nil:
+		// Let the verification report what is wrong with the signature
+		return standard, EncodingBase64, nil
+	case len(decoded) > 1:
+		return nil, "", fmt.Errorf("could not decode signature: ambiguous encoding, could be any of %s", joinEncodings(decoded))
+	default:
+		return nil, "", fmt.Errorf("could not decode signature: %w", standardErr)
+	}
+}
+
+// decodedSignature is the result of decoding a signature in a single encoding.
+type decodedSignature struct {
+	payload  []byte
+	encoding Encoding
+}
+
+// joinEncodings lists the encodings of the decoded signatures, for use in errors.
+func joinEncodings(decoded []decodedSignature) string {
+	return strings.Join(lo.Map(decoded, func(item decodedSignature, _ int) string { return string(item.encoding) }), ", ")
+}
+
+// isPlausibleSignature reports whether the decoded signature looks like a legacy signature, a BIP-322 simple signature or a BIP-322 full signature.
+func isPlausibleSignature(signatureDecoded []byte) bool {
+	if len(signatureDecoded) == generic.ExpectedSignatureLength {
+		return lo.Contains[int](flags.All(), int(signatureDecoded[0]))
+	}
+
+	witness, trailing, err := bip322.SimpleSigToWitnessWithTrailing(signatureDecoded)
+	if err == nil && len(witness) > 0 && len(trailing) == 0 {
+		return true
+	}
+
+	_, err = bip322.DecodeFull(signatureDecoded)
+
+	return err == nil
+}
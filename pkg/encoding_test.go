@@ -0,0 +1,109 @@
+package verifier_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type EncodingTestSuite struct {
+	suite.Suite
+}
+
+func TestEncodingTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(EncodingTestSuite))
+}
+
+func (s *EncodingTestSuite) TestDecodeSignature() {
+	// Contains both '+' and '/', so the base64url encoding differs
+	legacy, err := base64.StdEncoding.DecodeString("IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=")
+	s.Require().NoError(err)
+
+	// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+	bip322, err := base64.StdEncoding.DecodeString("AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=")
+	s.Require().NoError(err)
+
+	tests := map[string]struct {
+		signature        string
+		expected         []byte
+		expectedEncoding verifier.Encoding
+	}{
+		"legacy - base64":                 {signature: base64.StdEncoding.EncodeToString(legacy), expected: legacy, expectedEncoding: verifier.EncodingBase64},
+		"legacy - base64url":              {signature: base64.URLEncoding.EncodeToString(legacy), expected: legacy, expectedEncoding: verifier.EncodingBase64URL},
+		"legacy - base64 - unpadded":      {signature: base64.RawStdEncoding.EncodeToString(legacy), expected: legacy, expectedEncoding: verifier.EncodingBase64Unpadded},
+		"legacy - base64url - unpadded":   {signature: base64.RawURLEncoding.EncodeToString(legacy), expected: legacy, expectedEncoding: verifier.EncodingBase64URLUnpadded},
+		"legacy - hex":                    {signature: hex.EncodeToString(legacy), expected: legacy, expectedEncoding: verifier.EncodingHex},
+		"legacy - hex - uppercase":        {signature: strings.ToUpper(hex.EncodeToString(legacy)), expected: legacy, expectedEncoding: verifier.EncodingHex},
+		"legacy - base64 - line breaks":   {signature: " IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBCh\r\nF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=\n", expected: legacy, expectedEncoding: verifier.EncodingBase64},
+		"bip-322 - base64":                {signature: base64.StdEncoding.EncodeToString(bip322), expected: bip322, expectedEncoding: verifier.EncodingBase64},
+		"bip-322 - base64url - unpadded":  {signature: base64.RawURLEncoding.EncodeToString(bip322), expected: bip322, expectedEncoding: verifier.EncodingBase64URLUnpadded},
+		"bip-322 - hex":                   {signature: hex.EncodeToString(bip322), expected: bip322, expectedEncoding: verifier.EncodingHex},
+		"bip-322 - hex - embedded spaces": {signature: "0247 3044 " + hex.EncodeToString(bip322)[8:], expected: bip322, expectedEncoding: verifier.EncodingHex},
+		"not a signature - base64":        {signature: "VGhpcyBpcyBub3QgdmFsaWQ=", expected: []byte("This is not valid"), expectedEncoding: verifier.EncodingBase64},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			decoded, encoding, err := verifier.DecodeSignature(tt.signature)
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expected, decoded)
+			s.Require().Equal(tt.expectedEncoding, encoding)
+		})
+	}
+}
+
+func (s *EncodingTestSuite) TestDecodeSignatureIncorrect() {
+	tests := map[string]struct {
+		signature     string
+		expectedError string
+	}{
+		"invalid": {
+			signature:     "INVALID",
+			expectedError: "could not decode signature: illegal base64 data at input byte 4",
+		},
+		"ambiguous": {
+			signature:     "deadbeefab",
+			expectedError: "could not decode signature: ambiguous encoding, could be any of base64-unpadded, hex",
+		},
+		// Uppercase hex that is also standard base64, both decode to a plausible BIP-322 simple signature
+		"ambiguous - base64 and hex": {
+			signature:     "01C2" + strings.Repeat("A", 107) + "C" + strings.Repeat("A", 280),
+			expectedError: "could not decode signature: ambiguous encoding, could be any of base64, hex",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			decoded, encoding, err := verifier.DecodeSignature(tt.signature)
+			s.Require().EqualError(err, tt.expectedError)
+			s.Require().Nil(decoded)
+			s.Require().Empty(encoding)
+		})
+	}
+}
+
+func FuzzDecodeSignature(f *testing.F) {
+	f.Add(simpleSignature)
+	f.Add(fullSignature)
+	f.Add("IFqUo4_sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, signature string) {
+		payload, encoding, err := verifier.DecodeSignature(signature)
+		if err != nil {
+			return
+		}
+
+		require.NotEmpty(t, encoding)
+		require.NotNil(t, payload)
+	})
+}
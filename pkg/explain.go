@@ -0,0 +1,362 @@
+package verifier
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/samber/lo"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/bitonicnl/verify-signed-message/internal/generic"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+)
+
+// Mistake is a common mistake that makes an otherwise valid signature fail verification, see Explain.
+type Mistake string
+
+const (
+	// MistakeNetwork is an address that is verified on the wrong network.
+	MistakeNetwork Mistake = "network"
+	// MistakeTrimmedMessage is a message that was signed without its leading and trailing whitespace.
+	MistakeTrimmedMessage Mistake = "trimmed-message"
+	// MistakeLineEndings is a message that was signed with other line endings, CRLF instead of LF or the other way around.
+	MistakeLineEndings Mistake = "line-endings"
+	// MistakeUnicodeNormalization is a message that was signed in Unicode normalization form NFC.
+	MistakeUnicodeNormalization Mistake = "unicode-normalization"
+	// MistakeTrailingNewline is a message that was signed with a trailing newline added or removed.
+	MistakeTrailingNewline Mistake = "trailing-newline"
+	// MistakeHexMessage is a message in hexadecimal that was signed as the bytes it encodes.
+	MistakeHexMessage Mistake = "hex-message"
+	// MistakeAddressType is a signature for another address type of the same key, for example a P2PKH address instead of a P2WPKH address.
+	MistakeAddressType Mistake = "address-type"
+	// MistakeRecoveryFlag is a legacy signature with a recovery flag of another family, for example an Electrum flag instead of a BIP-137 (Trezor) flag.
+	MistakeRecoveryFlag Mistake = "recovery-flag"
+)
+
+// Fix is a common mistake that, once corrected, makes the signature valid.
+type Fix struct {
+	// Mistake is the mistake that was corrected.
+	Mistake Mistake
+	// Summary describes the mistake in a way that can be passed on to the person that signed the message.
+	Summary string
+	// SignedMessage is the corrected signed message, which is valid on Networks.
+	SignedMessage SignedMessage
+	// Networks are the names of the networks on which the corrected signed message is valid.
+	// Networks that share their address prefixes, like the testnets and signet, are listed in a single fix.
+	Networks []string
+	// Result is the result of verifying the corrected signed message.
+	Result Result
+}
+
+// Explanation is the outcome of Explain.
+type Explanation struct {
+	// Result is the result of verifying the signed message as is.
+	Result Result
+	// Err is the error of verifying the signed message as is, nil if it succeeded.
+	Err error
+	// Summary describes the outcome in a single sentence.
+	Summary string
+	// Fixes are the common mistakes that explain why the signature is not valid, the most likely mistake first.
+	// It is empty when the signature is valid, or when none of the common mistakes explain it.
+	Fixes []Fix
+}
+
+// messageVariant is the message as it would be after making a common mistake.
+type messageVariant struct {
+	mistake Mistake
+	message string
+	summary string
+}
+
+// keyAddress is an address that is derived from a public key.
+type keyAddress struct {
+	name    string
+	address btcutil.Address
+}
+
+// Explain verifies a SignedMessage and, when it is not valid, verifies it again with common mistakes corrected, to explain what went wrong.
+// All compatibilities are applied, see Permissive.
+func Explain(signedMessage SignedMessage, net *chaincfg.Params) Explanation {
+	return NewVerifier(net).Explain(signedMessage)
+}
+
+// Explain verifies a SignedMessage and, when it is not valid, verifies it again with common mistakes corrected, to explain what went wrong.
+// The corrections are verified with the profile, registries and limits of the Verifier, they are never cached or observed.
+//
+// Every fix corrects a single mistake, a signature with multiple mistakes is not explained.
+func (v *Verifier) Explain(signedMessage SignedMessage) Explanation {
+	result, err := v.Verify(signedMessage)
+	explanation := Explanation{Result: result, Err: err, Summary: "", Fixes: []Fix{}}
+
+	switch {
+	case err == nil && result.Valid:
+		explanation.Summary = "The signature is valid"
+
+		return explanation
+	case errors.Is(err, ErrLimitExceeded):
+		// Every correction would exceed the limits as well
+		explanation.Summary = fmt.Sprintf("The signature is not verified: %s", err)
+
+		return explanation
+	}
+
+	// Corrections are verified without the cache and observer, they are not verifications that were asked for
+	corrector := *v
+	corrector.cache, corrector.observer = nil, NopObserver{}
+
+	explanation.Fixes = append(explanation.Fixes, corrector.networkFixes(signedMessage)...)
+	explanation.Fixes = append(explanation.Fixes, corrector.messageFixes(signedMessage)...)
+	explanation.Fixes = append(explanation.Fixes, corrector.keyFixes(signedMessage)...)
+
+	reason := "it is not valid"
+	if err != nil {
+		reason = err.Error()
+	}
+
+	if len(explanation.Fixes) == 0 {
+		explanation.Summary = fmt.Sprintf("The signature is not valid and none of the common mistakes explain it: %s", reason)
+	} else {
+		explanation.Summary = fmt.Sprintf("The signature is not valid (%s), the most likely mistake: %s", reason, explanation.Fixes[0].Summary)
+	}
+
+	return explanation
+}
+
+// networkFixes verifies the signed message on the other known networks, networks with the same outcome are merged into a single fix.
+func (v *Verifier) networkFixes(signedMessage SignedMessage) []Fix {
+	fixes := []Fix{}
+
+	for _, net := range Networks() {
+		if net.Name == v.net.Name {
+			continue
+		}
+
+		other := *v
+		other.net = net
+
+		result, valid := other.verifyCorrection(signedMessage)
+		if !valid {
+			continue
+		}
+
+		// The signed message is the same on every network, so only the outcome can differ
+		_, index, found := lo.FindIndexOf(fixes, func(fix Fix) bool {
+			return fix.Result.Format == result.Format && fix.Result.Encoding == result.Encoding && fix.Result.MessageTrimmed == result.MessageTrimmed
+		})
+		if found {
+			fixes[index].Networks = append(fixes[index].Networks, net.Name)
+
+			continue
+		}
+
+		fixes = append(fixes, Fix{Mistake: MistakeNetwork, Summary: "", SignedMessage: signedMessage, Networks: []string{net.Name}, Result: result})
+	}
+
+	for i, fix := range fixes {
+		networks := lo.Map(fix.Networks, func(name string, _ int) string { return fmt.Sprintf("'%s'", name) })
+		fixes[i].Summary = fmt.Sprintf("The signature is valid on %s %s, not on network '%s'", lo.Ternary(len(networks) == 1, "network", "networks"), joinNames(networks), v.net.Name)
+	}
+
+	return fixes
+}
+
+// joinNames joins names as a list in a sentence, like "a, b and c".
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// messageFixes verifies the signed message with the message as it would be after making a common mistake.
+func (v *Verifier) messageFixes(signedMessage SignedMessage) []Fix {
+	fixes := []Fix{}
+
+	// Different mistakes can result in the same message, only the first one is verified
+	seen := map[string]bool{signedMessage.Message: true}
+
+	for _, variant := range messageVariants(signedMessage.Message) {
+		if seen[variant.message] {
+			continue
+		}
+
+		seen[variant.message] = true
+
+		corrected := SignedMessage{Address: signedMessage.Address, Message: variant.message, Signature: signedMessage.Signature}
+		if result, valid := v.verifyCorrection(corrected); valid {
+			fixes = append(fixes, Fix{Mistake: variant.mistake, Summary: variant.summary, SignedMessage: corrected, Networks: []string{v.net.Name}, Result: result})
+		}
+	}
+
+	return fixes
+}
+
+// messageVariants returns the message as it would be after making each of the common mistakes, variants can be equal to the message.
+func messageVariants(message string) []messageVariant {
+	lf := strings.ReplaceAll(message, "\r\n", "\n")
+
+	variants := []messageVariant{
+		{mistake: MistakeTrimmedMessage, message: strings.TrimSpace(message), summary: "The message was signed without its leading and trailing whitespace"},
+		{mistake: MistakeLineEndings, message: lf, summary: "The message was signed with LF line endings instead of CRLF"},
+		{mistake: MistakeLineEndings, message: strings.ReplaceAll(lf, "\n", "\r\n"), summary: "The message was signed with CRLF line endings instead of LF"},
+		{mistake: MistakeUnicodeNormalization, message: norm.NFC.String(message), summary: "The message was signed in Unicode normalization form NFC"},
+	}
+
+	if withoutNewline, found := strings.CutSuffix(message, "\n"); found {
+		variants = append(variants, messageVariant{mistake: MistakeTrailingNewline, message: withoutNewline, summary: "The message was signed without its trailing newline"})
+	} else {
+		variants = append(variants, messageVariant{mistake: MistakeTrailingNewline, message: message + "\n", summary: "The message was signed with a trailing newline"})
+	}
+
+	if decoded, err := hex.DecodeString(strings.TrimSpace(message)); err == nil && len(decoded) > 0 {
+		variants = append(variants, messageVariant{
+			mistake: MistakeHexMessage,
+			message: string(decoded),
+			summary: fmt.Sprintf("The message was signed as the %d bytes it encodes in hexadecimal, not as text", len(decoded)),
+		})
+	}
+
+	return variants
+}
+
+// keyFixes corrects the address type and recovery flag of a legacy signature.
+// Any legacy signature recovers a public key, so only keys that belong to the address are considered.
+func (v *Verifier) keyFixes(signedMessage SignedMessage) []Fix {
+	address, err := decodeAddress(signedMessage.Address, v.net)
+	if err != nil {
+		return []Fix{}
+	}
+
+	signature, err := v.decoders.decode(signedMessage.Signature, v.profile)
+	if err != nil || len(signature.Payload) != generic.ExpectedSignatureLength || !lo.Contains(flags.All(), int(signature.Payload[0])) {
+		return []Fix{}
+	}
+
+	_, publicKey, err := generic.RecoverPublicKey(LegacyMessageHash([]byte(signedMessage.Message)), signature.Payload)
+	if err != nil {
+		return []Fix{}
+	}
+
+	keyAddresses, err := deriveKeyAddresses(publicKey, v.net)
+	if err != nil || !lo.ContainsBy(keyAddresses, func(keyAddress keyAddress) bool { return keyAddress.address.EncodeAddress() == address.EncodeAddress() }) {
+		return []Fix{}
+	}
+
+	fixes := []Fix{}
+
+	if fix, found := v.recoveryFlagFix(signedMessage, signature.Payload); found {
+		fixes = append(fixes, fix)
+	}
+
+	for _, keyAddress := range keyAddresses {
+		if keyAddress.address.EncodeAddress() == address.EncodeAddress() {
+			continue
+		}
+
+		corrected := SignedMessage{Address: keyAddress.address.EncodeAddress(), Message: signedMessage.Message, Signature: signedMessage.Signature}
+		if result, valid := v.verifyCorrection(corrected); valid {
+			fixes = append(fixes, Fix{
+				Mistake:       MistakeAddressType,
+				Summary:       fmt.Sprintf("The signature is valid for the %s address '%s' of the same key, not for address '%s'", keyAddress.name, corrected.Address, signedMessage.Address),
+				SignedMessage: corrected,
+				Networks:      []string{v.net.Name},
+				Result:        result,
+			})
+		}
+	}
+
+	return fixes
+}
+
+// recoveryFlagFix verifies a legacy signature with the recovery flag of each other family, the first flag that is valid is returned.
+// The recovery flag does not change the recovered point, only how it is serialized and which address types it allows.
+func (v *Verifier) recoveryFlagFix(signedMessage SignedMessage, signatureDecoded []byte) (Fix, bool) {
+	recoveryFlag := int(signatureDecoded[0])
+
+	for _, family := range flags.DistinctFamilies() {
+		correctedFlag := family.BaseFlag() + flags.GetKeyID(recoveryFlag)
+		if correctedFlag == recoveryFlag {
+			continue
+		}
+
+		var correctedSignature [generic.ExpectedSignatureLength]byte
+		copy(correctedSignature[:], signatureDecoded)
+		correctedSignature[0] = byte(correctedFlag)
+
+		corrected := SignedMessage{Address: signedMessage.Address, Message: signedMessage.Message, Signature: base64.StdEncoding.EncodeToString(correctedSignature[:])}
+		if result, valid := v.verifyCorrection(corrected); valid {
+			return Fix{
+				Mistake: MistakeRecoveryFlag,
+				Summary: fmt.Sprintf(
+					"The signature is valid with recovery flag %d (%s) instead of %d (%s)",
+					correctedFlag, flags.Families(correctedFlag)[0], recoveryFlag, flags.Families(recoveryFlag)[0],
+				),
+				SignedMessage: corrected,
+				Networks:      []string{v.net.Name},
+				Result:        result,
+			}, true
+		}
+	}
+
+	return Fix{}, false
+}
+
+// verifyCorrection verifies a corrected signed message, it reports whether the correction is valid.
+func (v *Verifier) verifyCorrection(signedMessage SignedMessage) (Result, bool) {
+	result, err := v.Verify(signedMessage)
+
+	return result, err == nil && result.Valid
+}
+
+// deriveKeyAddresses derives the address of every type that a legacy signature can be verified for from a public key.
+func deriveKeyAddresses(publicKey *btcec.PublicKey, net *chaincfg.Params) ([]keyAddress, error) {
+	uncompressedHash := btcutil.Hash160(publicKey.SerializeUncompressed())
+	compressedHash := btcutil.Hash160(publicKey.SerializeCompressed())
+
+	p2pkhUncompressed, err := btcutil.NewAddressPubKeyHash(uncompressedHash, net)
+	if err != nil {
+		return nil, err
+	}
+
+	p2pkh, err := btcutil.NewAddressPubKeyHash(compressedHash, net)
+	if err != nil {
+		return nil, err
+	}
+
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(compressedHash, net)
+	if err != nil {
+		return nil, err
+	}
+
+	witnessScript, err := txscript.PayToAddrScript(p2wpkh)
+	if err != nil {
+		return nil, err
+	}
+
+	p2shP2wpkh, err := btcutil.NewAddressScriptHash(witnessScript, net)
+	if err != nil {
+		return nil, err
+	}
+
+	p2tr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(publicKey)), net)
+	if err != nil {
+		return nil, err
+	}
+
+	return []keyAddress{
+		{name: "P2PKH (uncompressed)", address: p2pkhUncompressed},
+		{name: "P2PKH", address: p2pkh},
+		{name: "P2SH-P2WPKH", address: p2shP2wpkh},
+		{name: "P2WPKH", address: p2wpkh},
+		{name: "P2TR", address: p2tr},
+	}, nil
+}
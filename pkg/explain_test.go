@@ -0,0 +1,220 @@
+package verifier_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type ExplainTestSuite struct {
+	suite.Suite
+
+	privateKey *btcec.PrivateKey
+}
+
+func TestExplainTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ExplainTestSuite))
+}
+
+func (s *ExplainTestSuite) SetupTest() {
+	s.privateKey, _ = btcec.PrivKeyFromBytes([]byte("explain common mistakes test key"))
+}
+
+func (s *ExplainTestSuite) TestExplainValid() {
+	explanation := verifier.Explain(verifier.SignedMessage{Address: s.p2pkh(), Message: "Hello World", Signature: s.sign("Hello World", true)}, &chaincfg.MainNetParams)
+	s.Require().NoError(explanation.Err)
+	s.Require().True(explanation.Result.Valid)
+	s.Require().Equal("The signature is valid", explanation.Summary)
+	s.Require().Empty(explanation.Fixes)
+}
+
+func (s *ExplainTestSuite) TestExplainMessage() {
+	tests := map[string]struct {
+		signed          string
+		provided        string
+		profile         verifier.Profile
+		expectedMistake verifier.Mistake
+		expectedSummary string
+	}{
+		"trimmed": {
+			signed:          "Hello World",
+			provided:        " Hello World\n",
+			profile:         verifier.BitcoinCore(),
+			expectedMistake: verifier.MistakeTrimmedMessage,
+			expectedSummary: "The message was signed without its leading and trailing whitespace",
+		},
+		"lf": {
+			signed:          "Hello\nWorld",
+			provided:        "Hello\r\nWorld",
+			profile:         verifier.Permissive(),
+			expectedMistake: verifier.MistakeLineEndings,
+			expectedSummary: "The message was signed with LF line endings instead of CRLF",
+		},
+		"crlf": {
+			signed:          "Hello\r\nWorld",
+			provided:        "Hello\nWorld",
+			profile:         verifier.Permissive(),
+			expectedMistake: verifier.MistakeLineEndings,
+			expectedSummary: "The message was signed with CRLF line endings instead of LF",
+		},
+		"nfc": {
+			signed:          "caf\u00e9",
+			provided:        "cafe\u0301",
+			profile:         verifier.Permissive(),
+			expectedMistake: verifier.MistakeUnicodeNormalization,
+			expectedSummary: "The message was signed in Unicode normalization form NFC",
+		},
+		"trailing newline added": {
+			signed:          "Hello World\n",
+			provided:        "Hello World",
+			profile:         verifier.Permissive(),
+			expectedMistake: verifier.MistakeTrailingNewline,
+			expectedSummary: "The message was signed with a trailing newline",
+		},
+		"trailing newline removed": {
+			signed:          "Hello World",
+			provided:        "Hello World\n",
+			profile:         verifier.BitcoinCore(),
+			expectedMistake: verifier.MistakeTrimmedMessage,
+			expectedSummary: "The message was signed without its leading and trailing whitespace",
+		},
+		"hex": {
+			signed:          "Hello",
+			provided:        hex.EncodeToString([]byte("Hello")),
+			profile:         verifier.Permissive(),
+			expectedMistake: verifier.MistakeHexMessage,
+			expectedSummary: "The message was signed as the 5 bytes it encodes in hexadecimal, not as text",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			signedMessage := verifier.SignedMessage{Address: s.p2pkh(), Message: tt.provided, Signature: s.sign(tt.signed, true)}
+
+			explanation := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithProfile(tt.profile)).Explain(signedMessage)
+			s.Require().False(explanation.Result.Valid)
+			s.Require().Len(explanation.Fixes, 1)
+			s.Require().Equal(tt.expectedMistake, explanation.Fixes[0].Mistake)
+			s.Require().Equal(tt.expectedSummary, explanation.Fixes[0].Summary)
+			s.Require().Equal(tt.signed, explanation.Fixes[0].SignedMessage.Message)
+			s.Require().Equal([]string{"mainnet"}, explanation.Fixes[0].Networks)
+			s.Require().True(explanation.Fixes[0].Result.Valid)
+			s.Require().Contains(explanation.Summary, tt.expectedSummary)
+		})
+	}
+}
+
+func (s *ExplainTestSuite) TestExplainNetwork() {
+	address, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(s.privateKey.PubKey().SerializeCompressed()), &chaincfg.TestNet3Params)
+	s.Require().NoError(err)
+
+	explanation := verifier.Explain(verifier.SignedMessage{Address: address.EncodeAddress(), Message: "Hello World", Signature: s.sign("Hello World", true)}, &chaincfg.MainNetParams)
+	s.Require().ErrorContains(explanation.Err, "is not valid for network 'mainnet'")
+
+	// The testnets and signet share their address prefixes, so they are listed in a single fix
+	s.Require().Len(explanation.Fixes, 1)
+	s.Require().Equal(verifier.MistakeNetwork, explanation.Fixes[0].Mistake)
+	s.Require().Equal([]string{"testnet3", "testnet4", "signet"}, explanation.Fixes[0].Networks)
+	s.Require().Equal("The signature is valid on networks 'testnet3', 'testnet4' and 'signet', not on network 'mainnet'", explanation.Fixes[0].Summary)
+}
+
+func (s *ExplainTestSuite) TestExplainRecoveryFlag() {
+	// An uncompressed signature for a P2WPKH address, which requires a compressed key
+	signedMessage := verifier.SignedMessage{Address: s.p2wpkh(), Message: "Hello World", Signature: s.sign("Hello World", false)}
+
+	explanation := verifier.Explain(signedMessage, &chaincfg.MainNetParams)
+	s.Require().EqualError(explanation.Err, "cannot use P2WPKH for recovery flag 'P2PKH uncompressed'")
+	s.Require().Len(explanation.Fixes, 3)
+
+	signatureDecoded, err := base64.StdEncoding.DecodeString(signedMessage.Signature)
+	s.Require().NoError(err)
+
+	flag := int(signatureDecoded[0])
+	s.Require().Equal(verifier.MistakeRecoveryFlag, explanation.Fixes[0].Mistake)
+	s.Require().Equal(fmt.Sprintf("The signature is valid with recovery flag %d (compressed) instead of %d (uncompressed)", flag+4, flag), explanation.Fixes[0].Summary)
+
+	correctedDecoded, err := base64.StdEncoding.DecodeString(explanation.Fixes[0].SignedMessage.Signature)
+	s.Require().NoError(err)
+	s.Require().Equal(flag+4, int(correctedDecoded[0]))
+	s.Require().Equal(signatureDecoded[1:], correctedDecoded[1:])
+
+	// The signature is valid as is for the uncompressed P2PKH and the P2TR address of the same key
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(s.privateKey.PubKey().SerializeUncompressed()), &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	s.Require().Equal(verifier.MistakeAddressType, explanation.Fixes[1].Mistake)
+	s.Require().Equal(address.EncodeAddress(), explanation.Fixes[1].SignedMessage.Address)
+	s.Require().Equal(verifier.MistakeAddressType, explanation.Fixes[2].Mistake)
+	s.Require().Contains(explanation.Fixes[2].Summary, "The signature is valid for the P2TR address 'bc1p")
+}
+
+func (s *ExplainTestSuite) TestExplainAddressType() {
+	// Bitcoin Core only allows legacy signatures for P2PKH addresses
+	signedMessage := verifier.SignedMessage{Address: s.p2wpkh(), Message: "Hello World", Signature: s.sign("Hello World", true)}
+
+	explanation := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithProfile(verifier.BitcoinCore())).Explain(signedMessage)
+	s.Require().EqualError(explanation.Err, "legacy signatures are only allowed for P2PKH addresses by profile 'bitcoin-core'")
+	s.Require().Len(explanation.Fixes, 1)
+	s.Require().Equal(verifier.MistakeAddressType, explanation.Fixes[0].Mistake)
+	s.Require().Equal(s.p2pkh(), explanation.Fixes[0].SignedMessage.Address)
+	s.Require().Equal("The signature is valid for the P2PKH address '"+s.p2pkh()+"' of the same key, not for address '"+s.p2wpkh()+"'", explanation.Fixes[0].Summary)
+}
+
+func (s *ExplainTestSuite) TestExplainUnexplained() {
+	tests := map[string]verifier.SignedMessage{
+		"other message": {Address: s.p2pkh(), Message: "Goodbye World", Signature: s.sign("Hello World", true)},
+		// Any legacy signature recovers a key, so addresses of keys that do not belong to the address are never suggested
+		"other key": {Address: "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", Message: "Hello World", Signature: s.sign("Hello World", true)},
+		"bip-322":   {Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World - This should fail", Signature: simpleSignature},
+	}
+
+	for name, signedMessage := range tests {
+		s.Run(name, func() {
+			explanation := verifier.Explain(signedMessage, &chaincfg.MainNetParams)
+			s.Require().Error(explanation.Err)
+			s.Require().Empty(explanation.Fixes)
+			s.Require().Contains(explanation.Summary, "The signature is not valid and none of the common mistakes explain it: ")
+		})
+	}
+}
+
+func (s *ExplainTestSuite) TestExplainLimitExceeded() {
+	signedMessage := verifier.SignedMessage{Address: s.p2pkh(), Message: "Hello World ", Signature: s.sign("Hello World", true)}
+
+	explanation := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithLimits(verifier.Limits{MaxMessageBytes: 5})).Explain(signedMessage)
+	s.Require().ErrorIs(explanation.Err, verifier.ErrLimitExceeded)
+	s.Require().Empty(explanation.Fixes)
+	s.Require().Equal("The signature is not verified: limit exceeded: message is 12 bytes, at most 5 are allowed", explanation.Summary)
+}
+
+// sign creates a legacy signature of the message with the test key.
+func (s *ExplainTestSuite) sign(message string, compressed bool) string {
+	return base64.StdEncoding.EncodeToString(ecdsa.SignCompact(s.privateKey, verifier.LegacyMessageHash([]byte(message)), compressed))
+}
+
+// p2pkh returns the compressed P2PKH address of the test key.
+func (s *ExplainTestSuite) p2pkh() string {
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(s.privateKey.PubKey().SerializeCompressed()), &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	return address.EncodeAddress()
+}
+
+// p2wpkh returns the P2WPKH address of the test key.
+func (s *ExplainTestSuite) p2wpkh() string {
+	address, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(s.privateKey.PubKey().SerializeCompressed()), &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	return address.EncodeAddress()
+}
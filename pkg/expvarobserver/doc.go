@@ -0,0 +1,4 @@
+// Package expvarobserver publishes the outcomes of verifications as expvar variables, see verifier.WithObserver.
+//
+// It is a separate package, so the verifier package does not import expvar, which registers a handler on http.DefaultServeMux.
+package expvarobserver
@@ -0,0 +1,95 @@
+package expvarobserver
+
+import (
+	"expvar"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// unknown is the key that is used for empty values, for example the format of a signature that could not be decoded.
+const unknown = "unknown"
+
+// Observer is a verifier.Observer that counts verifications in an expvar.Map. It is safe for concurrent use.
+//
+// The map holds the following variables:
+//   - started, finished, valid and cached: the amount of verifications.
+//   - duration_ns: the total duration of the finished verifications.
+//   - networks, address_types, formats and error_classes: the finished verifications by network, address type, format and error class.
+//   - stages, stage_errors and stage_duration_ns: the completed stages, the stages that failed and their total duration, by stage.
+type Observer struct {
+	vars            *expvar.Map
+	networks        *expvar.Map
+	addressTypes    *expvar.Map
+	formats         *expvar.Map
+	errorClasses    *expvar.Map
+	stages          *expvar.Map
+	stageErrors     *expvar.Map
+	stageDurationNS *expvar.Map
+}
+
+// New creates an Observer that publishes its variables in the passed map, for example expvar.NewMap("verifier").
+func New(vars *expvar.Map) *Observer {
+	o := &Observer{
+		vars:            vars,
+		networks:        new(expvar.Map).Init(),
+		addressTypes:    new(expvar.Map).Init(),
+		formats:         new(expvar.Map).Init(),
+		errorClasses:    new(expvar.Map).Init(),
+		stages:          new(expvar.Map).Init(),
+		stageErrors:     new(expvar.Map).Init(),
+		stageDurationNS: new(expvar.Map).Init(),
+	}
+
+	vars.Set("networks", o.networks)
+	vars.Set("address_types", o.addressTypes)
+	vars.Set("formats", o.formats)
+	vars.Set("error_classes", o.errorClasses)
+	vars.Set("stages", o.stages)
+	vars.Set("stage_errors", o.stageErrors)
+	vars.Set("stage_duration_ns", o.stageDurationNS)
+
+	return o
+}
+
+// VerifyStarted counts the started verifications.
+func (o *Observer) VerifyStarted(verifier.StartEvent) {
+	o.vars.Add("started", 1)
+}
+
+// StageCompleted counts the completed stages, their errors and duration.
+func (o *Observer) StageCompleted(event verifier.StageEvent) {
+	o.stages.Add(string(event.Stage), 1)
+	o.stageDurationNS.Add(string(event.Stage), event.Duration.Nanoseconds())
+
+	if event.Err != nil {
+		o.stageErrors.Add(string(event.Stage), 1)
+	}
+}
+
+// VerifyFinished counts the finished verifications by their outcome.
+func (o *Observer) VerifyFinished(event verifier.FinishEvent) {
+	o.vars.Add("finished", 1)
+	o.vars.Add("duration_ns", event.Duration.Nanoseconds())
+
+	if event.Valid {
+		o.vars.Add("valid", 1)
+	}
+
+	if event.Cached {
+		o.vars.Add("cached", 1)
+	}
+
+	o.networks.Add(key(event.Network), 1)
+	o.addressTypes.Add(key(string(event.AddressType)), 1)
+	o.formats.Add(key(string(event.Format)), 1)
+	o.errorClasses.Add(key(string(event.ErrorClass)), 1)
+}
+
+// key returns the key of a value in a map, empty values are counted as unknown.
+func key(value string) string {
+	if value == "" {
+		return unknown
+	}
+
+	return value
+}
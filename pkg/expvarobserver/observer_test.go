@@ -0,0 +1,51 @@
+package expvarobserver_test
+
+import (
+	"expvar"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/expvarobserver"
+)
+
+type ObserverTestSuite struct {
+	suite.Suite
+}
+
+func TestObserverTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ObserverTestSuite))
+}
+
+func (s *ObserverTestSuite) TestObserver() {
+	vars := new(expvar.Map).Init()
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithObserver(expvarobserver.New(vars)))
+
+	_, err := v.Verify(verifier.SignedMessage{
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World",
+		Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	})
+	s.Require().NoError(err)
+
+	_, err = v.Verify(verifier.SignedMessage{Address: "INVALID", Message: "Hello World", Signature: "!"})
+	s.Require().Error(err)
+
+	s.Require().Equal("2", vars.Get("started").String())
+	s.Require().Equal("2", vars.Get("finished").String())
+	s.Require().Equal("1", vars.Get("valid").String())
+	s.Require().Nil(vars.Get("cached"))
+	s.Require().NotNil(vars.Get("duration_ns"))
+
+	s.Require().JSONEq(`{"mainnet": 2}`, vars.Get("networks").String())
+	s.Require().JSONEq(`{"p2wpkh": 1, "unknown": 1}`, vars.Get("address_types").String())
+	s.Require().JSONEq(`{"bip322-simple": 1, "unknown": 1}`, vars.Get("formats").String())
+	s.Require().JSONEq(`{"none": 1, "address": 1}`, vars.Get("error_classes").String())
+	s.Require().JSONEq(`{"address-decode": 2, "signature-decode": 1, "script-execution": 1}`, vars.Get("stages").String())
+	s.Require().JSONEq(`{"address-decode": 1}`, vars.Get("stage_errors").String())
+}
@@ -0,0 +1,145 @@
+package verifier
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/samber/lo"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+	"github.com/bitonicnl/verify-signed-message/internal/generic"
+)
+
+// AddressHandler verifies signatures for a single type of address.
+type AddressHandler interface {
+	// Formats returns the proof formats that can be verified for the address type.
+	// Signatures without a declared format are verified as BIP-322 full when they decode as a transaction, as legacy when they consist of 65 bytes
+	// and as BIP-322 simple otherwise. The first format is used when the detected format is not supported.
+	Formats() []Format
+	// Verify verifies a decoded signature in one of the supported formats for the address and the digest of the message.
+	// The address is valid for the network, only the hash that is required for the format is set in the digest.
+	Verify(address btcutil.Address, digest MessageDigest, signature []byte, format Format, net *chaincfg.Params) (bool, error)
+}
+
+// AddressHandlerRegistry holds the address handlers, keyed by the Go type of the address.
+type AddressHandlerRegistry struct {
+	handlers map[reflect.Type]AddressHandler
+}
+
+// NewAddressHandlerRegistry creates an empty registry, in which no address type is supported.
+func NewAddressHandlerRegistry() *AddressHandlerRegistry {
+	return &AddressHandlerRegistry{handlers: map[reflect.Type]AddressHandler{}}
+}
+
+// DefaultAddressHandlers creates a registry with the built-in handlers for P2PK, P2PKH, P2SH, P2WPKH, P2WSH and P2TR addresses.
+// Use ClassifyAddress to find out which formats are supported for an address.
+func DefaultAddressHandlers() *AddressHandlerRegistry {
+	registry := NewAddressHandlerRegistry()
+	registry.Register(&btcutil.AddressPubKey{}, builtinHandler{formats: []Format{FormatBIP322Full}, validate: nil})
+	registry.Register(&btcutil.AddressPubKeyHash{}, builtinHandler{formats: []Format{FormatLegacy, FormatBIP322Full}, validate: publicKeyHashValidator(generic.ValidateP2PKH)})
+	registry.Register(&btcutil.AddressScriptHash{}, builtinHandler{formats: []Format{FormatLegacy, FormatBIP322Full}, validate: publicKeyHashValidator(generic.ValidateP2SH)})
+	registry.Register(&btcutil.AddressWitnessPubKeyHash{}, builtinHandler{
+		formats:  []Format{FormatLegacy, FormatBIP322Simple, FormatBIP322Full},
+		validate: publicKeyHashValidator(generic.ValidateP2WPKH),
+	})
+	registry.Register(&btcutil.AddressWitnessScriptHash{}, builtinHandler{formats: []Format{FormatBIP322Full}, validate: nil})
+	registry.Register(&btcutil.AddressTaproot{}, builtinHandler{formats: []Format{FormatLegacy, FormatBIP322Simple, FormatBIP322Full}, validate: generic.ValidateP2TR})
+
+	return registry
+}
+
+// Register sets the handler for the type of the passed address, replacing any handler that is already registered for it.
+// Only the type of the address is used, so a zero value can be passed. A registry is not safe for concurrent use while handlers are registered.
+func (r *AddressHandlerRegistry) Register(address btcutil.Address, handler AddressHandler) {
+	r.handlers[reflect.TypeOf(address)] = handler
+}
+
+// Handler returns the handler for the type of the address, which allows a custom handler to delegate to a built-in one.
+func (r *AddressHandlerRegistry) Handler(address btcutil.Address) (AddressHandler, bool) {
+	handler, found := r.handlers[reflect.TypeOf(address)]
+
+	return handler, found
+}
+
+// Formats returns the proof formats that can be verified for the address, it is empty if the address type is not supported.
+func (r *AddressHandlerRegistry) Formats(address btcutil.Address) []Format {
+	handler, found := r.Handler(address)
+	if !found {
+		return []Format{}
+	}
+
+	return handler.Formats()
+}
+
+// handler returns the handler for the address, or an error when the address type is not supported.
+func (r *AddressHandlerRegistry) handler(address btcutil.Address) (AddressHandler, error) {
+	handler, found := r.Handler(address)
+	if !found || len(handler.Formats()) == 0 {
+		return nil, fmt.Errorf("unsupported address type '%s'", reflect.TypeOf(address))
+	}
+
+	return handler, nil
+}
+
+// legacyValidator ensures the public key recovered from a legacy signature belongs to the address.
+type legacyValidator func(recoveryFlag int, publicKey *btcec.PublicKey, address btcutil.Address, net *chaincfg.Params) (bool, error)
+
+// publicKeyHashValidator adapts a validation function that works on the hash of the public key to a legacyValidator.
+func publicKeyHashValidator(validate func(recoveryFlag int, publicKeyHash []byte, address btcutil.Address, net *chaincfg.Params) (bool, error)) legacyValidator {
+	return func(recoveryFlag int, publicKey *btcec.PublicKey, address btcutil.Address, net *chaincfg.Params) (bool, error) {
+		return validate(recoveryFlag, generic.GeneratePublicKeyHash(recoveryFlag, publicKey), address, net)
+	}
+}
+
+// builtinHandler verifies the formats of the built-in address types.
+type builtinHandler struct {
+	formats []Format
+	// validate is only used for legacy signatures.
+	validate legacyValidator
+}
+
+// Formats returns the formats of the address type.
+func (h builtinHandler) Formats() []Format {
+	return h.formats
+}
+
+// Verify verifies the signature with the implementation of the format.
+func (h builtinHandler) Verify(address btcutil.Address, digest MessageDigest, signatureDecoded []byte, format Format, net *chaincfg.Params) (bool, error) {
+	if !lo.Contains(h.formats, format) {
+		return false, fmt.Errorf("%s signatures are not supported for address type '%s'", format, reflect.TypeOf(address))
+	}
+
+	// Handle generic/BIP-137 signature
+	if format == FormatLegacy {
+		if len(digest.Legacy) != chainhash.HashSize {
+			return false, fmt.Errorf("legacy hash should be %d bytes, got %d", chainhash.HashSize, len(digest.Legacy))
+		}
+
+		recoveryFlag, publicKey, err := generic.RecoverPublicKey(digest.Legacy, signatureDecoded)
+		if err != nil {
+			return false, err
+		}
+
+		return h.validate(recoveryFlag, publicKey, address, net)
+	}
+
+	// Otherwise, verify it as BIP-322
+	if len(digest.BIP322) != chainhash.HashSize {
+		return false, fmt.Errorf("BIP-322 hash should be %d bytes, got %d", chainhash.HashSize, len(digest.BIP322))
+	}
+
+	if format == FormatBIP322Full {
+		toSign, err := bip322.DecodeFull(signatureDecoded)
+		if err != nil {
+			return false, err
+		}
+
+		return bip322.VerifyFull(address, [chainhash.HashSize]byte(digest.BIP322), toSign)
+	}
+
+	return bip322.VerifyHash(address, [chainhash.HashSize]byte(digest.BIP322), signatureDecoded)
+}
@@ -0,0 +1,129 @@
+package verifier_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Generated with btcd, using the private key of the BIP-322 test vectors and the witness script '<public key> OP_CHECKSIG'.
+const (
+	fullSignatureP2WSH = "AAAAAAABAb8mAku4GJhDeznJQuTLFXVlSjQ1jg57Vc93O8N2agmNAAAAAAAAAAAAAQAAAAAAAAAAAWoCRzBEAiBa5SsHAW44ECzep3cWekdtUOtTt7F63jngCWfP5PhYIQIgBVS2lxq49HBBnHdkcOBvVlvy7Of1U4NepdkNrA59WxIBIyECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHKsAAAAAA=="
+	addressP2WSH       = "bc1qumw0r30366ya78zvcv6tyr0ffgtqg9vm9jt6mud7slmehggpd0jqucu77z"
+)
+
+// policyHandler only accepts P2WSH signatures that use the allowed witness script, the signature itself is verified by the built-in handler.
+type policyHandler struct {
+	builtin       verifier.AddressHandler
+	allowedScript []byte
+}
+
+func (h policyHandler) Formats() []verifier.Format {
+	return []verifier.Format{verifier.FormatBIP322Full}
+}
+
+func (h policyHandler) Verify(address btcutil.Address, digest verifier.MessageDigest, signature []byte, format verifier.Format, net *chaincfg.Params) (bool, error) {
+	var toSign wire.MsgTx
+	if err := toSign.Deserialize(bytes.NewReader(signature)); err != nil || len(toSign.TxIn) == 0 {
+		return false, errors.New("could not decode transaction")
+	}
+
+	witness := toSign.TxIn[0].Witness
+	if len(witness) == 0 || !bytes.Equal(witness[len(witness)-1], h.allowedScript) {
+		return false, errors.New("witness script is not allowed by the policy")
+	}
+
+	return h.builtin.Verify(address, digest, signature, format, net)
+}
+
+type HandlerTestSuite struct {
+	suite.Suite
+}
+
+func TestHandlerTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(HandlerTestSuite))
+}
+
+func (s *HandlerTestSuite) TestDefaultAddressHandlers() {
+	tests := map[string]struct {
+		address  btcutil.Address
+		expected []verifier.Format
+	}{
+		"p2pkh": {address: &btcutil.AddressPubKeyHash{}, expected: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Full}},
+		"p2wsh": {address: &btcutil.AddressWitnessScriptHash{}, expected: []verifier.Format{verifier.FormatBIP322Full}},
+		"p2tr":  {address: &btcutil.AddressTaproot{}, expected: []verifier.Format{verifier.FormatLegacy, verifier.FormatBIP322Simple, verifier.FormatBIP322Full}},
+		"p2a":   {address: &btcutil.AddressPayToAnchor{}, expected: []verifier.Format{}},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			s.Require().Equal(tt.expected, verifier.DefaultAddressHandlers().Formats(tt.address))
+		})
+	}
+}
+
+func (s *HandlerTestSuite) TestRegister() {
+	builtin, found := verifier.DefaultAddressHandlers().Handler(&btcutil.AddressWitnessScriptHash{})
+	s.Require().True(found)
+
+	signatureDecoded, err := base64.StdEncoding.DecodeString(fullSignatureP2WSH)
+	s.Require().NoError(err)
+	var toSign wire.MsgTx
+	s.Require().NoError(toSign.Deserialize(bytes.NewReader(signatureDecoded)))
+	witnessScript := toSign.TxIn[0].Witness[1]
+
+	signedMessage := verifier.SignedMessage{Address: addressP2WSH, Message: "Hello World", Signature: fullSignatureP2WSH}
+
+	// The policy allows the script
+	handlers := verifier.DefaultAddressHandlers()
+	handlers.Register(&btcutil.AddressWitnessScriptHash{}, policyHandler{builtin: builtin, allowedScript: witnessScript})
+
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithAddressHandlers(handlers)).Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+	s.Require().Equal(verifier.FormatBIP322Full, result.Format)
+
+	// The policy rejects the script
+	handlers.Register(&btcutil.AddressWitnessScriptHash{}, policyHandler{builtin: builtin, allowedScript: []byte{0x51}})
+
+	result, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithAddressHandlers(handlers)).Verify(signedMessage)
+	s.Require().EqualError(err, "witness script is not allowed by the policy")
+	s.Require().False(result.Valid)
+}
+
+func (s *HandlerTestSuite) TestUnsupportedAddressType() {
+	signedMessage := verifier.SignedMessage{Address: addressP2WSH, Message: "Hello World", Signature: fullSignatureP2WSH}
+
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithAddressHandlers(verifier.NewAddressHandlerRegistry())).Verify(signedMessage)
+	s.Require().EqualError(err, "unsupported address type '*btcutil.AddressWitnessScriptHash'")
+	s.Require().False(result.Valid)
+}
+
+func (s *HandlerTestSuite) TestUnsupportedFormat() {
+	signedMessage := verifier.SignedMessage{Address: addressP2WSH, Message: "Hello World", Signature: simpleSignature}
+
+	// Simple signatures are not supported for P2WSH addresses, so it is verified as a full signature
+	_, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(signedMessage)
+	s.Require().ErrorContains(err, "could not decode transaction: ")
+
+	// A declared format has to be supported by the handler
+	handler, found := verifier.DefaultAddressHandlers().Handler(&btcutil.AddressWitnessScriptHash{})
+	s.Require().True(found)
+
+	address, err := btcutil.DecodeAddress(addressP2WSH, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	_, err = handler.Verify(address, verifier.NewMessageDigest([]byte("Hello World")), []byte{}, verifier.FormatLegacy, &chaincfg.MainNetParams)
+	s.Require().EqualError(err, "legacy signatures are not supported for address type '*btcutil.AddressWitnessScriptHash'")
+}
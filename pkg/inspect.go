@@ -0,0 +1,267 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/samber/lo"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+	"github.com/bitonicnl/verify-signed-message/internal/generic"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/signature"
+)
+
+// RecoveryFlagFamily describes which kind of address a recovery flag was created for.
+type RecoveryFlagFamily = flags.Family
+
+const (
+	// FamilyUncompressed is used for P2PKH addresses with an uncompressed public key.
+	FamilyUncompressed = flags.FamilyUncompressed
+	// FamilyCompressed is used for P2PKH addresses with a compressed public key.
+	FamilyCompressed = flags.FamilyCompressed
+	// FamilyElectrumSegwit is used by Electrum for P2WPKH and P2SH-P2WPKH addresses, it shares its flags with FamilyCompressed.
+	FamilyElectrumSegwit = flags.FamilyElectrumSegwit
+	// FamilyTrezorP2SHAndP2WPKH is used by Trezor (BIP-137) for P2SH-P2WPKH addresses.
+	FamilyTrezorP2SHAndP2WPKH = flags.FamilyTrezorP2SHAndP2WPKH
+	// FamilyTrezorP2WPKH is used by Trezor (BIP-137) for P2WPKH addresses.
+	FamilyTrezorP2WPKH = flags.FamilyTrezorP2WPKH
+)
+
+// WitnessItemKind is the classification of an item of a BIP-322 witness stack.
+type WitnessItemKind string
+
+const (
+	// WitnessItemEmpty is an empty item, as used for dummy elements of multisig scripts.
+	WitnessItemEmpty WitnessItemKind = "empty"
+	// WitnessItemECDSASignature is a DER encoded ECDSA signature followed by a sighash type.
+	WitnessItemECDSASignature WitnessItemKind = "ecdsa-signature"
+	// WitnessItemSchnorrSignature is a BIP-340 Schnorr signature, optionally followed by a sighash type.
+	WitnessItemSchnorrSignature WitnessItemKind = "schnorr-signature"
+	// WitnessItemPublicKey is a compressed or uncompressed public key.
+	WitnessItemPublicKey WitnessItemKind = "public-key"
+	// WitnessItemControlBlock is a taproot control block, which is the last item when spending through a tapscript.
+	WitnessItemControlBlock WitnessItemKind = "control-block"
+	// WitnessItemScript is a witness script or tapscript.
+	WitnessItemScript WitnessItemKind = "script"
+	// WitnessItemUnknown is an item that could not be classified.
+	WitnessItemUnknown WitnessItemKind = "unknown"
+)
+
+// Values used to classify witness items.
+const (
+	schnorrSignatureLength          = 64
+	controlBlockBaseLength          = 33
+	controlBlockNodeLength          = 32
+	controlBlockLeafVersionMask     = 0xfe
+	minECDSASignatureWithSigHashLen = 9
+	uncompressedPublicKeyLength     = 65
+)
+
+// Inspection holds the decoded contents of a signature.
+type Inspection struct {
+	// Format is the proof format the signature was decoded as.
+	Format Format
+	// Encoding is the text encoding the signature was provided in.
+	Encoding Encoding
+	// Legacy holds the details of a legacy or BIP-137 signature, it is nil for other formats.
+	Legacy *LegacyInspection
+	// BIP322 holds the details of a BIP-322 signature, it is nil for other formats.
+	BIP322 *BIP322Inspection
+}
+
+// LegacyInspection holds the decoded contents of a legacy or BIP-137 signature.
+type LegacyInspection struct {
+	// RecoveryFlag is the first byte of the signature.
+	RecoveryFlag int
+	// Families lists the kinds of addresses the recovery flag is used for.
+	Families []RecoveryFlagFamily
+	// KeyID selects which of the possible public keys is recovered.
+	KeyID int
+	// R is the 32-byte big-endian R component of the signature.
+	R []byte
+	// S is the 32-byte big-endian S component of the signature.
+	S []byte
+	// LowS is true when S is at most half of the curve order, as required by BIP-62 and Bitcoin Core.
+	LowS bool
+}
+
+// BIP322Inspection holds the decoded contents of a BIP-322 signature, for full signatures this is the witness of the first input.
+type BIP322Inspection struct {
+	// Witness is the decoded witness stack.
+	Witness []WitnessItem
+	// Trailing holds the bytes that remain after the witness stack, which should be empty.
+	Trailing []byte
+}
+
+// WitnessItem is a classified item of a witness stack.
+type WitnessItem struct {
+	// Kind is the classification of the item.
+	Kind WitnessItemKind
+	// Data is the raw item.
+	Data []byte
+	// SigHashType is the sighash type of a signature item, it is zero for other items and for Schnorr signatures without an explicit sighash type.
+	SigHashType txscript.SigHashType
+}
+
+// Inspect decodes a signature without verifying it, which is useful to find out why a signature does not verify.
+//
+// The signature is decoded like Verify does, see DefaultDecoders. 65-byte signatures with a known recovery flag are decoded as legacy signatures,
+// BIP-322 full signatures are decoded as the witness stack of the first input and any other signature is decoded as a BIP-322 witness stack.
+// The length of the signature is bounded by the MaxSignatureBytes of DefaultLimits, nothing is executed so the other limits do not apply.
+func Inspect(signature string) (Inspection, error) {
+	inspection := Inspection{Format: "", Encoding: "", Legacy: nil, BIP322: nil}
+
+	if err := DefaultLimits().checkInput(SignedMessage{Address: "", Message: "", Signature: signature}); err != nil {
+		return inspection, err
+	}
+
+	decoded, err := DefaultDecoders().Decode(signature)
+	if err != nil {
+		return inspection, err
+	}
+	inspection.Encoding = decoded.Encoding
+
+	signatureDecoded := decoded.Payload
+	if decoded.Format == FormatBIP322Full || (decoded.Format == "" && isFullSignature(signatureDecoded)) {
+		toSign, err := bip322.DecodeFull(signatureDecoded)
+		if err != nil {
+			return inspection, err
+		}
+
+		if len(toSign.TxIn) == 0 {
+			return inspection, errors.New("could not decode transaction: no inputs")
+		}
+		inspection.Format = FormatBIP322Full
+		inspection.BIP322 = &BIP322Inspection{Witness: classifyWitness(toSign.TxIn[0].Witness), Trailing: []byte{}}
+
+		return inspection, nil
+	}
+
+	if len(signatureDecoded) == generic.ExpectedSignatureLength && lo.Contains(flags.All(), int(signatureDecoded[0])) {
+		legacy, err := inspectLegacy(signatureDecoded)
+		if err != nil {
+			return inspection, err
+		}
+		inspection.Format, inspection.Legacy = FormatLegacy, &legacy
+
+		return inspection, nil
+	}
+
+	witness, trailing, err := bip322.SimpleSigToWitnessWithTrailing(signatureDecoded)
+	if err != nil {
+		return inspection, fmt.Errorf("could not decode witness: %w", err)
+	}
+	inspection.Format = FormatBIP322Simple
+	inspection.BIP322 = &BIP322Inspection{Witness: classifyWitness(witness), Trailing: trailing}
+
+	return inspection, nil
+}
+
+// inspectLegacy decodes a 65-byte signature.
+func inspectLegacy(signatureDecoded []byte) (LegacyInspection, error) {
+	recoveryFlag := int(signatureDecoded[0])
+
+	// ParseCompact does not know the BIP-137 (Trezor) flags, so validate R and S with the equivalent minimal flag
+	if _, err := signature.ParseCompact(append([]byte{byte(flags.Minimal(recoveryFlag))}, signatureDecoded[1:]...)); err != nil {
+		return LegacyInspection{}, fmt.Errorf("could not parse signature: %w", err)
+	}
+
+	lowS, err := signature.IsLowS(signatureDecoded)
+	if err != nil {
+		return LegacyInspection{}, fmt.Errorf("could not parse signature: %w", err)
+	}
+
+	return LegacyInspection{
+		RecoveryFlag: recoveryFlag,
+		Families:     flags.Families(recoveryFlag),
+		KeyID:        flags.GetKeyID(recoveryFlag),
+		R:            append([]byte{}, signatureDecoded[1:33]...),
+		S:            append([]byte{}, signatureDecoded[33:]...),
+		LowS:         lowS,
+	}, nil
+}
+
+// classifyWitness classifies every item of the witness stack.
+// Scripts are only recognized where they are expected: as the last item, or before a control block.
+func classifyWitness(witness [][]byte) []WitnessItem {
+	items := make([]WitnessItem, len(witness))
+	for i, data := range witness {
+		items[i] = classifyWitnessItem(data)
+	}
+
+	scriptIndex := len(items) - 1
+	if scriptIndex > 0 && isControlBlock(witness[scriptIndex]) {
+		items[scriptIndex].Kind = WitnessItemControlBlock
+		scriptIndex--
+	}
+
+	if scriptIndex >= 0 && items[scriptIndex].Kind == WitnessItemUnknown && isScript(witness[scriptIndex]) {
+		items[scriptIndex].Kind = WitnessItemScript
+	}
+
+	return items
+}
+
+// classifyWitnessItem classifies a single witness item, without taking its position into account.
+func classifyWitnessItem(data []byte) WitnessItem {
+	item := WitnessItem{Kind: WitnessItemUnknown, Data: data, SigHashType: 0}
+
+	switch {
+	case len(data) == 0:
+		item.Kind = WitnessItemEmpty
+	case len(data) >= minECDSASignatureWithSigHashLen && isECDSASignature(data[:len(data)-1]):
+		item.Kind, item.SigHashType = WitnessItemECDSASignature, txscript.SigHashType(data[len(data)-1])
+	case isPublicKey(data):
+		item.Kind = WitnessItemPublicKey
+	case len(data) == schnorrSignatureLength && isSchnorrSignature(data):
+		item.Kind = WitnessItemSchnorrSignature
+	case len(data) == schnorrSignatureLength+1 && isSchnorrSignature(data[:schnorrSignatureLength]):
+		item.Kind, item.SigHashType = WitnessItemSchnorrSignature, txscript.SigHashType(data[schnorrSignatureLength])
+	}
+
+	return item
+}
+
+// isECDSASignature reports whether the data is a strictly DER encoded ECDSA signature.
+func isECDSASignature(data []byte) bool {
+	_, err := ecdsa.ParseDERSignature(data)
+
+	return err == nil
+}
+
+// isSchnorrSignature reports whether the data is a valid BIP-340 Schnorr signature.
+func isSchnorrSignature(data []byte) bool {
+	_, err := schnorr.ParseSignature(data)
+
+	return err == nil
+}
+
+// isPublicKey reports whether the data is a valid compressed or uncompressed public key.
+func isPublicKey(data []byte) bool {
+	if len(data) != btcec.PubKeyBytesLenCompressed && len(data) != uncompressedPublicKeyLength {
+		return false
+	}
+
+	_, err := btcec.ParsePubKey(data)
+
+	return err == nil
+}
+
+// isControlBlock reports whether the data has the structure of a taproot control block with a tapscript leaf version.
+func isControlBlock(data []byte) bool {
+	return len(data) >= controlBlockBaseLength &&
+		(len(data)-controlBlockBaseLength)%controlBlockNodeLength == 0 &&
+		data[0]&controlBlockLeafVersionMask == byte(txscript.BaseLeafVersion)
+}
+
+// isScript reports whether the data can be parsed as a script.
+func isScript(data []byte) bool {
+	_, err := txscript.DisasmString(data)
+
+	return err == nil
+}
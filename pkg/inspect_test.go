@@ -0,0 +1,200 @@
+package verifier_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type InspectTestSuite struct {
+	suite.Suite
+}
+
+func TestInspectTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(InspectTestSuite))
+}
+
+func (s *InspectTestSuite) TestInspectLegacy() {
+	tests := map[string]struct {
+		signature string
+		expected  verifier.Inspection
+	}{
+		"compressed": {
+			signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			expected: verifier.Inspection{
+				Format:   verifier.FormatLegacy,
+				Encoding: verifier.EncodingBase64,
+				Legacy: &verifier.LegacyInspection{
+					RecoveryFlag: 32,
+					Families:     []verifier.RecoveryFlagFamily{verifier.FamilyCompressed, verifier.FamilyElectrumSegwit},
+					KeyID:        1,
+					R:            s.hex("5a94a38fecc4110591f2bc99979e379e95d7706a39cd03f74a0f042845de04ea"),
+					S:            s.hex("317b4d4824cdefd5310b7de467c322d1c1f2e336029d07c2c53c8ba4c55729e0"),
+					LowS:         true,
+				},
+			},
+		},
+		"trezor - high s": {
+			signature: "KFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqzoSyt9syECrO9IIbmDzdLPi7+bCsq5h4+pXS6ArfF2E=",
+			expected: verifier.Inspection{
+				Format:   verifier.FormatLegacy,
+				Encoding: verifier.EncodingBase64,
+				Legacy: &verifier.LegacyInspection{
+					RecoveryFlag: 40,
+					Families:     []verifier.RecoveryFlagFamily{verifier.FamilyTrezorP2WPKH},
+					KeyID:        1,
+					R:            s.hex("5a94a38fecc4110591f2bc99979e379e95d7706a39cd03f74a0f042845de04ea"),
+					S:            s.hex("ce84b2b7db32102acef4821b983cdd2cf8bbf9b0acab9878fa95d2e80adf1761"),
+					LowS:         false,
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			inspection, err := verifier.Inspect(tt.signature)
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expected, inspection)
+		})
+	}
+}
+
+func (s *InspectTestSuite) TestInspectBIP322() {
+	derSignature := s.hex("304402206517c8637a7bfc3a154edcba6196d64bbd5b73955cb7da7d1626bcdde466c364022022bf10d19fc0bb69b4596e306b362acaa835293cf693bb176f7324b531f5afec01")
+	publicKey := s.hex("02c7f12003196442943d8588e01aee840423cc54fc1521526a3b85c2b0cbd58872")
+	schnorrSignature := s.hex("ddebd3eb25012ffa82937d9f25f9644e047bb2f472ab6c5089bbb53588ada2884cb5bcc53911f32d8dcf9548733b694d120db6a4e485194559e8d8fe668d269f01")
+	script := s.hex("51")
+	controlBlock := s.hex("c1c7f12003196442943d8588e01aee840423cc54fc1521526a3b85c2b0cbd58872")
+
+	tests := map[string]struct {
+		signature string
+		expected  []verifier.WitnessItem
+		trailing  []byte
+	}{
+		"p2wpkh": {
+			signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemECDSASignature, Data: derSignature, SigHashType: txscript.SigHashAll},
+				{Kind: verifier.WitnessItemPublicKey, Data: publicKey, SigHashType: 0},
+			},
+			trailing: []byte{},
+		},
+		"p2wpkh - trailing bytes": {
+			signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHIAAQ==",
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemECDSASignature, Data: derSignature, SigHashType: txscript.SigHashAll},
+				{Kind: verifier.WitnessItemPublicKey, Data: publicKey, SigHashType: 0},
+			},
+			trailing: []byte{0x00, 0x01},
+		},
+		"taproot - key path": {
+			signature: s.witness(schnorrSignature),
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemSchnorrSignature, Data: schnorrSignature, SigHashType: txscript.SigHashAll},
+			},
+			trailing: []byte{},
+		},
+		"taproot - key path - default sighash": {
+			signature: s.witness(schnorrSignature[:64]),
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemSchnorrSignature, Data: schnorrSignature[:64], SigHashType: 0},
+			},
+			trailing: []byte{},
+		},
+		"taproot - script path": {
+			signature: s.witness(schnorrSignature[:64], script, controlBlock),
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemSchnorrSignature, Data: schnorrSignature[:64], SigHashType: 0},
+				{Kind: verifier.WitnessItemScript, Data: script, SigHashType: 0},
+				{Kind: verifier.WitnessItemControlBlock, Data: controlBlock, SigHashType: 0},
+			},
+			trailing: []byte{},
+		},
+		"p2wsh - multisig": {
+			signature: s.witness([]byte{}, derSignature, script),
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemEmpty, Data: []byte{}, SigHashType: 0},
+				{Kind: verifier.WitnessItemECDSASignature, Data: derSignature, SigHashType: txscript.SigHashAll},
+				{Kind: verifier.WitnessItemScript, Data: script, SigHashType: 0},
+			},
+			trailing: []byte{},
+		},
+		"unknown": {
+			signature: s.witness([]byte{0x01, 0x02}, script),
+			expected: []verifier.WitnessItem{
+				{Kind: verifier.WitnessItemUnknown, Data: []byte{0x01, 0x02}, SigHashType: 0},
+				{Kind: verifier.WitnessItemScript, Data: script, SigHashType: 0},
+			},
+			trailing: []byte{},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			inspection, err := verifier.Inspect(tt.signature)
+			s.Require().NoError(err)
+			s.Require().Equal(verifier.FormatBIP322Simple, inspection.Format)
+			s.Require().Nil(inspection.Legacy)
+			s.Require().NotNil(inspection.BIP322)
+			s.Require().Equal(tt.expected, inspection.BIP322.Witness)
+			s.Require().Equal(tt.trailing, inspection.BIP322.Trailing)
+		})
+	}
+}
+
+func (s *InspectTestSuite) TestInspectErrors() {
+	tests := map[string]struct {
+		signature     string
+		expectedError string
+	}{
+		"invalid encoding": {
+			signature:     "INVALID",
+			expectedError: "could not decode signature: illegal base64 data at input byte 4",
+		},
+		"legacy - r is zero": {
+			signature:     base64.StdEncoding.EncodeToString(append([]byte{31}, make([]byte, 64)...)),
+			expectedError: "could not parse signature: signature R is 0",
+		},
+		"witness - truncated": {
+			signature:     "AkcwRAIgZRfI",
+			expectedError: "could not decode witness: unexpected EOF",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			_, err := verifier.Inspect(tt.signature)
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
+
+// hex decodes a hex string, failing the test when it is invalid.
+func (s *InspectTestSuite) hex(value string) []byte {
+	decoded, err := hex.DecodeString(value)
+	s.Require().NoError(err)
+
+	return decoded
+}
+
+// witness serializes the items as a base64 encoded BIP-322 simple signature.
+func (s *InspectTestSuite) witness(items ...[]byte) string {
+	var buf bytes.Buffer
+	s.Require().NoError(wire.WriteVarInt(&buf, 0, uint64(len(items))))
+	for _, item := range items {
+		s.Require().NoError(wire.WriteVarBytes(&buf, 0, item))
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,191 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+)
+
+// ErrLimitExceeded is returned when a signed message exceeds one of the Limits of a Verifier.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limits bounds the resources that are spent on verifying a single signed message, to protect against untrusted input.
+// A limit of zero or less is not enforced.
+//
+// Regardless of the limits, decoding a signature never allocates more memory than its length allows.
+type Limits struct {
+	// MaxSignatureBytes is the maximum length of the signature as provided, before it is decoded.
+	MaxSignatureBytes int
+	// MaxMessageBytes is the maximum length of the message.
+	MaxMessageBytes int
+	// MaxWitnessItems is the maximum amount of items in a BIP-322 witness stack.
+	MaxWitnessItems int
+	// MaxWitnessItemBytes is the maximum length of a single item of a BIP-322 witness stack.
+	MaxWitnessItemBytes int
+	// MaxScriptOps is the maximum amount of non-push opcodes in the scripts of a BIP-322 signature, which bounds the work of the script engine.
+	MaxScriptOps int
+	// MaxSigOps is the maximum amount of signature checks in the scripts of a BIP-322 signature.
+	MaxSigOps int
+}
+
+// DefaultLimits returns the limits that are used by a Verifier unless WithLimits is passed, and by Verify, VerifyWithChain and VerifyWithProfile.
+// VerifyRaw, VerifyDigest and VerifyReader only apply the limits of the signature, Inspect only MaxSignatureBytes. They allow any signature that is standard on the network and messages of up to 1 MiB.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxSignatureBytes:   100_000,
+		MaxMessageBytes:     1 << 20,
+		MaxWitnessItems:     100,
+		MaxWitnessItemBytes: 10_000,
+		MaxScriptOps:        1_000,
+		MaxSigOps:           100,
+	}
+}
+
+// WithLimits sets the limits that protect the Verifier against untrusted input, the default is DefaultLimits.
+func WithLimits(limits Limits) Option {
+	return func(v *Verifier) {
+		v.limits = limits
+	}
+}
+
+// checkInput ensures the signature and message, as provided, are within the limits.
+func (l Limits) checkInput(signedMessage SignedMessage) error {
+	if exceeds(len(signedMessage.Signature), l.MaxSignatureBytes) {
+		return fmt.Errorf("%w: signature is %d bytes, at most %d are allowed", ErrLimitExceeded, len(signedMessage.Signature), l.MaxSignatureBytes)
+	}
+
+	if exceeds(len(signedMessage.Message), l.MaxMessageBytes) {
+		return fmt.Errorf("%w: message is %d bytes, at most %d are allowed", ErrLimitExceeded, len(signedMessage.Message), l.MaxMessageBytes)
+	}
+
+	return nil
+}
+
+// checkDecoded ensures a signature that is provided decoded, by the functions that verify decoded values, is within the limits.
+func (l Limits) checkDecoded(address btcutil.Address, signatureDecoded []byte, format Format) error {
+	if exceeds(len(signatureDecoded), l.MaxSignatureBytes) {
+		return fmt.Errorf("%w: signature is %d bytes, at most %d are allowed", ErrLimitExceeded, len(signatureDecoded), l.MaxSignatureBytes)
+	}
+
+	return l.checkSignature(address, signatureDecoded, format)
+}
+
+// checkSignature ensures the witness stacks and scripts of a BIP-322 signature are within the limits, before they are executed.
+// Signatures that can not be decoded are left to the verification, which reports what is wrong with them.
+func (l Limits) checkSignature(address btcutil.Address, signatureDecoded []byte, format Format) error {
+	// Only the signature script of a P2SH input contains a script that is executed, the redeem script
+	_, isP2SH := address.(*btcutil.AddressScriptHash)
+
+	switch format {
+	case FormatBIP322Simple:
+		if witness, err := bip322.SimpleSigToWitness(signatureDecoded); err == nil {
+			return l.checkScripts(witness, nil)
+		}
+	case FormatBIP322Full:
+		if toSign, err := bip322.DecodeFull(signatureDecoded); err == nil {
+			for _, input := range toSign.TxIn {
+				var redeemScript []byte
+				if isP2SH {
+					redeemScript = lastPush(input.SignatureScript)
+				}
+
+				if err := l.checkScripts(input.Witness, redeemScript); err != nil {
+					return err
+				}
+			}
+		}
+	case FormatLegacy:
+		// Legacy signatures have a fixed length and no scripts
+	}
+
+	return nil
+}
+
+// checkScripts ensures the witness stack and the scripts that are executed when spending an input are within the limits.
+// The redeem script is only set for P2SH inputs.
+func (l Limits) checkScripts(witness [][]byte, redeemScript []byte) error {
+	if exceeds(len(witness), l.MaxWitnessItems) {
+		return fmt.Errorf("%w: witness has %d items, at most %d are allowed", ErrLimitExceeded, len(witness), l.MaxWitnessItems)
+	}
+
+	for i, item := range witness {
+		if exceeds(len(item), l.MaxWitnessItemBytes) {
+			return fmt.Errorf("%w: witness item %d is %d bytes, at most %d are allowed", ErrLimitExceeded, i, len(item), l.MaxWitnessItemBytes)
+		}
+	}
+
+	// Scripts do not loop, so the opcodes they contain bound the opcodes that are executed
+	scripts := [][]byte{redeemScript}
+	for _, item := range classifyWitness(witness) {
+		if item.Kind == WitnessItemScript {
+			scripts = append(scripts, item.Data)
+		}
+	}
+
+	ops, sigOps := 0, 0
+	for _, script := range scripts {
+		scriptOps, scriptSigOps := countOps(script)
+		ops, sigOps = ops+scriptOps, sigOps+scriptSigOps
+	}
+
+	if exceeds(ops, l.MaxScriptOps) {
+		return fmt.Errorf("%w: scripts have %d opcodes, at most %d are allowed", ErrLimitExceeded, ops, l.MaxScriptOps)
+	}
+
+	if exceeds(sigOps, l.MaxSigOps) {
+		return fmt.Errorf("%w: scripts have %d signature operations, at most %d are allowed", ErrLimitExceeded, sigOps, l.MaxSigOps)
+	}
+
+	return nil
+}
+
+// countOps counts the non-push opcodes and the signature operations of a script, like the consensus rules do.
+// A CHECKMULTISIG counts as the amount of public keys when it is pushed right before it, or as the maximum amount of keys otherwise.
+func countOps(script []byte) (int, int) {
+	ops, sigOps := 0, 0
+	previous := byte(txscript.OP_INVALIDOPCODE)
+
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		opcode := tokenizer.Opcode()
+		if opcode > txscript.OP_16 {
+			ops++
+		}
+
+		switch opcode {
+		case txscript.OP_CHECKSIG, txscript.OP_CHECKSIGVERIFY, txscript.OP_CHECKSIGADD:
+			sigOps++
+		case txscript.OP_CHECKMULTISIG, txscript.OP_CHECKMULTISIGVERIFY:
+			if previous >= txscript.OP_1 && previous <= txscript.OP_16 {
+				sigOps += int(previous-txscript.OP_1) + 1
+			} else {
+				sigOps += txscript.MaxPubKeysPerMultiSig
+			}
+		}
+
+		previous = opcode
+	}
+
+	return ops, sigOps
+}
+
+// lastPush returns the data of the last push of a signature script, which is the redeem script of a P2SH input.
+func lastPush(signatureScript []byte) []byte {
+	var data []byte
+
+	tokenizer := txscript.MakeScriptTokenizer(0, signatureScript)
+	for tokenizer.Next() {
+		data = tokenizer.Data()
+	}
+
+	return data
+}
+
+// exceeds reports whether the value exceeds the limit, a limit of zero or less is not enforced.
+func exceeds(value int, limit int) bool {
+	return limit > 0 && value > limit
+}
@@ -0,0 +1,151 @@
+package verifier_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type LimitsTestSuite struct {
+	suite.Suite
+}
+
+func TestLimitsTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(LimitsTestSuite))
+}
+
+func (s *LimitsTestSuite) TestLimits() {
+	simple := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature}
+	multisig := verifier.SignedMessage{Address: addressP2WSH, Message: "Hello World", Signature: s.replaceWitnessScript(fullSignatureP2WSH, txscript.OP_DROP, txscript.OP_3, txscript.OP_CHECKMULTISIG)}
+
+	tests := map[string]struct {
+		signedMessage verifier.SignedMessage
+		limits        verifier.Limits
+		expectedError string
+	}{
+		"signature": {
+			signedMessage: simple,
+			limits:        verifier.Limits{MaxSignatureBytes: 100},
+			expectedError: "limit exceeded: signature is 144 bytes, at most 100 are allowed",
+		},
+		"message": {
+			signedMessage: simple,
+			limits:        verifier.Limits{MaxMessageBytes: 5},
+			expectedError: "limit exceeded: message is 11 bytes, at most 5 are allowed",
+		},
+		"witness items": {
+			signedMessage: simple,
+			limits:        verifier.Limits{MaxWitnessItems: 1},
+			expectedError: "limit exceeded: witness has 2 items, at most 1 are allowed",
+		},
+		"witness item bytes": {
+			signedMessage: simple,
+			limits:        verifier.Limits{MaxWitnessItemBytes: 40},
+			expectedError: "limit exceeded: witness item 0 is 71 bytes, at most 40 are allowed",
+		},
+		"script ops": {
+			signedMessage: multisig,
+			limits:        verifier.Limits{MaxScriptOps: 1},
+			expectedError: "limit exceeded: scripts have 2 opcodes, at most 1 are allowed",
+		},
+		"signature operations": {
+			signedMessage: multisig,
+			limits:        verifier.Limits{MaxSigOps: 2},
+			expectedError: "limit exceeded: scripts have 3 signature operations, at most 2 are allowed",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithLimits(tt.limits)).Verify(tt.signedMessage)
+			s.Require().EqualError(err, tt.expectedError)
+			s.Require().ErrorIs(err, verifier.ErrLimitExceeded)
+			s.Require().False(result.Valid)
+		})
+	}
+}
+
+func (s *LimitsTestSuite) TestDefaultLimits() {
+	// Signatures within the limits are verified as usual
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature})
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+
+	// The script is within the limits, so it is executed
+	multisig := verifier.SignedMessage{Address: addressP2WSH, Message: "Hello World", Signature: s.replaceWitnessScript(fullSignatureP2WSH, txscript.OP_DROP, txscript.OP_3, txscript.OP_CHECKMULTISIG)}
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams).Verify(multisig)
+	s.Require().ErrorContains(err, "script execution failed: ")
+
+	// Large messages are rejected by default, unless the limits are disabled
+	large := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: strings.Repeat("a", 2<<20), Signature: simpleSignature}
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams).Verify(large)
+	s.Require().EqualError(err, "limit exceeded: message is 2097152 bytes, at most 1048576 are allowed")
+
+	result, err = verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithLimits(verifier.Limits{})).Verify(large)
+	s.Require().Error(err)
+	s.Require().NotErrorIs(err, verifier.ErrLimitExceeded)
+	s.Require().False(result.Valid)
+}
+
+func (s *LimitsTestSuite) TestDecodedLimits() {
+	address, err := btcutil.DecodeAddress("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+
+	// A witness stack of 101 empty items, which exceeds MaxWitnessItems of the default limits
+	witness := append([]byte{101}, make([]byte, 101)...)
+	expectedError := "limit exceeded: witness has 101 items, at most 100 are allowed"
+
+	_, err = verifier.VerifyRaw(address, []byte("Hello World"), witness, &chaincfg.MainNetParams)
+	s.Require().EqualError(err, expectedError)
+
+	_, err = verifier.VerifyDigest(address, verifier.NewMessageDigest([]byte("Hello World")), witness, &chaincfg.MainNetParams)
+	s.Require().EqualError(err, expectedError)
+
+	_, err = verifier.VerifyReader(address, strings.NewReader("Hello World"), 11, witness, &chaincfg.MainNetParams)
+	s.Require().EqualError(err, expectedError)
+
+	_, err = verifier.VerifyRaw(address, []byte("Hello World"), make([]byte, 100_001), &chaincfg.MainNetParams)
+	s.Require().EqualError(err, "limit exceeded: signature is 100001 bytes, at most 100000 are allowed")
+
+	_, err = verifier.Inspect(strings.Repeat("A", 100_004))
+	s.Require().EqualError(err, "limit exceeded: signature is 100004 bytes, at most 100000 are allowed")
+}
+
+func (s *LimitsTestSuite) TestTrailingBytes() {
+	signatureDecoded, err := base64.StdEncoding.DecodeString(simpleSignature)
+	s.Require().NoError(err)
+
+	_, err = verifier.NewVerifier(&chaincfg.MainNetParams).Verify(verifier.SignedMessage{
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World",
+		Signature: base64.StdEncoding.EncodeToString(append(signatureDecoded, 0xde, 0xad)),
+	})
+	s.Require().EqualError(err, "error converting signature into witness: 2 trailing bytes after the witness stack")
+}
+
+// replaceWitnessScript replaces the witness script of a P2WSH full signature with a script of the passed opcodes.
+func (s *LimitsTestSuite) replaceWitnessScript(signature string, opcodes ...byte) string {
+	signatureDecoded, err := base64.StdEncoding.DecodeString(signature)
+	s.Require().NoError(err)
+
+	var toSign wire.MsgTx
+	s.Require().NoError(toSign.Deserialize(bytes.NewReader(signatureDecoded)))
+	toSign.TxIn[0].Witness[1] = opcodes
+
+	var buffer bytes.Buffer
+	s.Require().NoError(toSign.Serialize(&buffer))
+
+	return base64.StdEncoding.EncodeToString(buffer.Bytes())
+}
@@ -0,0 +1,33 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+)
+
+// Networks returns the networks that are known by name, for example in a Document.
+func Networks() []*chaincfg.Params {
+	return []*chaincfg.Params{
+		&chaincfg.MainNetParams,
+		&chaincfg.TestNet3Params,
+		&chaincfg.TestNet4Params,
+		&chaincfg.SigNetParams,
+		&chaincfg.RegressionNetParams,
+		&chaincfg.SimNetParams,
+	}
+}
+
+// NetworkByName returns the known network with the passed name, for example 'mainnet' or 'testnet3'.
+func NetworkByName(name string) (*chaincfg.Params, error) {
+	net, found := lo.Find(Networks(), func(net *chaincfg.Params) bool { return net.Name == name })
+	if !found {
+		names := lo.Map(Networks(), func(net *chaincfg.Params, _ int) string { return net.Name })
+
+		return nil, fmt.Errorf("unknown network '%s', expected one of: %s", name, strings.Join(names, ", "))
+	}
+
+	return net, nil
+}
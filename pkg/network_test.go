@@ -0,0 +1,41 @@
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type NetworkTestSuite struct {
+	suite.Suite
+}
+
+func TestNetworkTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(NetworkTestSuite))
+}
+
+func (s *NetworkTestSuite) TestNetworkByName() {
+	for _, net := range verifier.Networks() {
+		s.Run(net.Name, func() {
+			found, err := verifier.NetworkByName(net.Name)
+			s.Require().NoError(err)
+			s.Require().Same(net, found)
+		})
+	}
+
+	net, err := verifier.NetworkByName("simnet")
+	s.Require().NoError(err)
+	s.Require().Same(&chaincfg.SimNetParams, net)
+}
+
+func (s *NetworkTestSuite) TestNetworkByNameUnknown() {
+	net, err := verifier.NetworkByName("unknown")
+	s.Require().EqualError(err, "unknown network 'unknown', expected one of: mainnet, testnet3, testnet4, signet, regtest, simnet")
+	s.Require().Nil(net)
+}
@@ -0,0 +1,142 @@
+package verifier
+
+import (
+	"errors"
+	"time"
+)
+
+// Stage is a step of a verification, see Observer.
+type Stage string
+
+const (
+	// StageAddressDecode decodes the address and ensures it is valid for the network.
+	StageAddressDecode Stage = "address-decode"
+	// StageSignatureDecode takes the signature out of its envelope and decodes it.
+	StageSignatureDecode Stage = "signature-decode"
+	// StageNormalizationRetry verifies the signature against the trimmed message, see Profile.TrimWhitespace.
+	StageNormalizationRetry Stage = "normalization-retry"
+	// StageRecovery recovers the public key of a legacy signature and validates it against the address.
+	StageRecovery Stage = "recovery"
+	// StageScriptExecution executes the scripts of a BIP-322 signature.
+	StageScriptExecution Stage = "script-execution"
+	// StageHandler verifies a signature of a format of a custom AddressHandler.
+	StageHandler Stage = "handler"
+)
+
+// ErrorClass is the kind of failure of a verification, a small set of values that is suitable as a metric label.
+type ErrorClass string
+
+const (
+	// ErrorClassNone is used for valid signatures.
+	ErrorClassNone ErrorClass = "none"
+	// ErrorClassLimitExceeded is used when the input exceeds the Limits of the Verifier.
+	ErrorClassLimitExceeded ErrorClass = "limit-exceeded"
+	// ErrorClassAddress is used when the address could not be decoded or is not valid for the network.
+	ErrorClassAddress ErrorClass = "address"
+	// ErrorClassSignature is used when the signature could not be decoded.
+	ErrorClassSignature ErrorClass = "signature"
+	// ErrorClassFormat is used when the format of the signature is not supported for the address or not allowed by the profile.
+	ErrorClassFormat ErrorClass = "format"
+	// ErrorClassInvalid is used when the signature was verified, but is not valid for the address and message.
+	ErrorClassInvalid ErrorClass = "invalid"
+)
+
+// StartEvent is reported when a verification starts.
+type StartEvent struct {
+	// Network is the name of the network of the Verifier.
+	Network string
+}
+
+// StageEvent is reported when a stage of a verification completes.
+type StageEvent struct {
+	// Stage is the stage that completed.
+	Stage Stage
+	// Format is the format the signature is verified as, it is empty for the stages before it is known.
+	Format Format
+	// Duration is the time the stage took.
+	Duration time.Duration
+	// Err is the error of the stage, nil if it succeeded.
+	Err error
+}
+
+// FinishEvent is reported when a verification finishes.
+type FinishEvent struct {
+	// Network is the name of the network of the Verifier.
+	Network string
+	// AddressType is the script type of the address, it is empty if the address could not be decoded.
+	AddressType ScriptType
+	// Format is the format the signature was verified as, it is empty if it could not be determined.
+	Format Format
+	// Valid is true when the signature is valid.
+	Valid bool
+	// Cached is true when the outcome was taken from the cache of the Verifier.
+	Cached bool
+	// Duration is the time the verification took.
+	Duration time.Duration
+	// ErrorClass is the kind of failure, ErrorClassNone for valid signatures.
+	ErrorClass ErrorClass
+	// Err is the error of the verification, nil if it succeeded.
+	Err error
+}
+
+// Observer is notified of the verifications of a Verifier, to collect metrics or traces. See WithObserver.
+// Implementations must be safe for concurrent use and should return quickly, as they are called on the verification path.
+type Observer interface {
+	// VerifyStarted is called when a verification starts.
+	VerifyStarted(event StartEvent)
+	// StageCompleted is called when a stage of a verification completes. Stages that are skipped, for example because the outcome was cached, are not reported.
+	StageCompleted(event StageEvent)
+	// VerifyFinished is called when a verification finishes, it is called exactly once for every call to VerifyStarted.
+	VerifyFinished(event FinishEvent)
+}
+
+// NopObserver is an Observer that does nothing, it is the default of a Verifier.
+type NopObserver struct{}
+
+// VerifyStarted does nothing.
+func (NopObserver) VerifyStarted(StartEvent) {}
+
+// StageCompleted does nothing.
+func (NopObserver) StageCompleted(StageEvent) {}
+
+// VerifyFinished does nothing.
+func (NopObserver) VerifyFinished(FinishEvent) {}
+
+// WithObserver sets the observer that is notified of every verification, the default is NopObserver.
+func WithObserver(observer Observer) Option {
+	return func(v *Verifier) {
+		v.observer = observer
+	}
+}
+
+// observeStage reports a completed stage that started at the passed time.
+func (v *Verifier) observeStage(stage Stage, format Format, started time.Time, err error) {
+	v.observer.StageCompleted(StageEvent{Stage: stage, Format: format, Duration: time.Since(started), Err: err})
+}
+
+// formatStage returns the stage that verifies a signature of the format.
+func formatStage(format Format) Stage {
+	switch format {
+	case FormatLegacy:
+		return StageRecovery
+	case FormatBIP322Simple, FormatBIP322Full:
+		return StageScriptExecution
+	default:
+		return StageHandler
+	}
+}
+
+// classifyVerification returns the error class of the verification of a decoded signature.
+// The format is only determined when it is supported and allowed, so a result without a format failed on it.
+func classifyVerification(result Result, err error) ErrorClass {
+	switch {
+	case err == nil && result.Valid:
+		return ErrorClassNone
+	case errors.Is(err, ErrLimitExceeded):
+		return ErrorClassLimitExceeded
+	case result.Format == "":
+		return ErrorClassFormat
+	default:
+		return ErrorClassInvalid
+	}
+}
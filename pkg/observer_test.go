@@ -0,0 +1,186 @@
+package verifier_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type ObserverTestSuite struct {
+	suite.Suite
+}
+
+func TestObserverTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ObserverTestSuite))
+}
+
+func (s *ObserverTestSuite) TestObserver() {
+	tests := map[string]struct {
+		options            []verifier.Option
+		signedMessage      verifier.SignedMessage
+		expectedStages     []verifier.Stage
+		expectedStageError verifier.Stage
+		expectedFinish     verifier.FinishEvent
+	}{
+		"bip-322": {
+			signedMessage:  verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature},
+			expectedStages: []verifier.Stage{verifier.StageAddressDecode, verifier.StageSignatureDecode, verifier.StageScriptExecution},
+			expectedFinish: verifier.FinishEvent{Network: "mainnet", AddressType: verifier.ScriptTypeP2WPKH, Format: verifier.FormatBIP322Simple, Valid: true, ErrorClass: verifier.ErrorClassNone},
+		},
+		"legacy - trimmed": {
+			signedMessage:  verifier.SignedMessage{Address: "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", Message: " test message ", Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA="},
+			expectedStages: []verifier.Stage{verifier.StageAddressDecode, verifier.StageSignatureDecode, verifier.StageRecovery, verifier.StageNormalizationRetry},
+			expectedFinish: verifier.FinishEvent{Network: "mainnet", AddressType: verifier.ScriptTypeP2PKH, Format: verifier.FormatLegacy, Valid: true, ErrorClass: verifier.ErrorClassNone},
+		},
+		"limit exceeded": {
+			options:        []verifier.Option{verifier.WithLimits(verifier.Limits{MaxMessageBytes: 1})},
+			signedMessage:  verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature},
+			expectedStages: []verifier.Stage{},
+			expectedFinish: verifier.FinishEvent{Network: "mainnet", ErrorClass: verifier.ErrorClassLimitExceeded},
+		},
+		"address": {
+			signedMessage:      verifier.SignedMessage{Address: "INVALID", Message: "Hello World", Signature: simpleSignature},
+			expectedStages:     []verifier.Stage{verifier.StageAddressDecode},
+			expectedStageError: verifier.StageAddressDecode,
+			expectedFinish:     verifier.FinishEvent{Network: "mainnet", ErrorClass: verifier.ErrorClassAddress},
+		},
+		"signature": {
+			signedMessage:      verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: "!"},
+			expectedStages:     []verifier.Stage{verifier.StageAddressDecode, verifier.StageSignatureDecode},
+			expectedStageError: verifier.StageSignatureDecode,
+			expectedFinish:     verifier.FinishEvent{Network: "mainnet", AddressType: verifier.ScriptTypeP2WPKH, ErrorClass: verifier.ErrorClassSignature},
+		},
+		"format": {
+			options:        []verifier.Option{verifier.WithProfile(verifier.BitcoinCore())},
+			signedMessage:  verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature},
+			expectedStages: []verifier.Stage{verifier.StageAddressDecode, verifier.StageSignatureDecode},
+			expectedFinish: verifier.FinishEvent{Network: "mainnet", AddressType: verifier.ScriptTypeP2WPKH, ErrorClass: verifier.ErrorClassFormat},
+		},
+		"invalid": {
+			signedMessage:      verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World - This should fail", Signature: simpleSignature},
+			expectedStages:     []verifier.Stage{verifier.StageAddressDecode, verifier.StageSignatureDecode, verifier.StageScriptExecution},
+			expectedStageError: verifier.StageScriptExecution,
+			expectedFinish:     verifier.FinishEvent{Network: "mainnet", AddressType: verifier.ScriptTypeP2WPKH, Format: verifier.FormatBIP322Simple, ErrorClass: verifier.ErrorClassInvalid},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			observer := &recordingObserver{}
+			result, err := verifier.NewVerifier(&chaincfg.MainNetParams, append(tt.options, verifier.WithObserver(observer))...).Verify(tt.signedMessage)
+
+			s.Require().Equal([]verifier.StartEvent{{Network: "mainnet"}}, observer.started)
+			s.Require().Equal(tt.expectedStages, observer.stageNames())
+
+			for _, stage := range observer.stages {
+				if stage.Stage == tt.expectedStageError {
+					s.Require().Error(stage.Err)
+				} else {
+					s.Require().NoError(stage.Err)
+				}
+			}
+
+			s.Require().Len(observer.finished, 1)
+			finished := observer.finished[0]
+			s.Require().Positive(finished.Duration)
+			s.Require().Equal(err, finished.Err)
+			s.Require().Equal(result.Valid, finished.Valid)
+
+			// The duration and error are compared above
+			finished.Duration, finished.Err = 0, nil
+			s.Require().Equal(tt.expectedFinish, finished)
+		})
+	}
+}
+
+func (s *ObserverTestSuite) TestObserverCached() {
+	observer := &recordingObserver{}
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithObserver(observer), verifier.WithCache(verifier.NewMemoryCache(10, time.Hour, time.Hour)))
+	signedMessage := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature}
+
+	for range 2 {
+		_, err := v.Verify(signedMessage)
+		s.Require().NoError(err)
+	}
+
+	// The script is only executed once
+	s.Require().Equal([]verifier.Stage{
+		verifier.StageAddressDecode, verifier.StageSignatureDecode, verifier.StageScriptExecution,
+		verifier.StageAddressDecode, verifier.StageSignatureDecode,
+	}, observer.stageNames())
+	s.Require().Len(observer.finished, 2)
+	s.Require().False(observer.finished[0].Cached)
+	s.Require().True(observer.finished[1].Cached)
+}
+
+func (s *ObserverTestSuite) TestObserverExplain() {
+	observer := &recordingObserver{}
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithObserver(observer))
+
+	// Only the verification itself is observed, not the corrections that are tried
+	explanation := v.Explain(verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "48656c6c6f20576f726c64", Signature: simpleSignature})
+	s.Require().Error(explanation.Err)
+	s.Require().Equal(verifier.MistakeHexMessage, explanation.Fixes[0].Mistake)
+	s.Require().Len(observer.started, 1)
+	s.Require().Len(observer.finished, 1)
+}
+
+func (s *ObserverTestSuite) TestNopObserver() {
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithObserver(verifier.NopObserver{})).Verify(verifier.SignedMessage{
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World",
+		Signature: simpleSignature,
+	})
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+}
+
+// recordingObserver records every event it receives.
+type recordingObserver struct {
+	mutex    sync.Mutex
+	started  []verifier.StartEvent
+	stages   []verifier.StageEvent
+	finished []verifier.FinishEvent
+}
+
+func (o *recordingObserver) VerifyStarted(event verifier.StartEvent) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.started = append(o.started, event)
+}
+
+func (o *recordingObserver) StageCompleted(event verifier.StageEvent) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.stages = append(o.stages, event)
+}
+
+func (o *recordingObserver) VerifyFinished(event verifier.FinishEvent) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.finished = append(o.finished, event)
+}
+
+// stageNames returns the stages that completed, in order.
+func (o *recordingObserver) stageNames() []verifier.Stage {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	stages := []verifier.Stage{}
+	for _, event := range o.stages {
+		stages = append(stages, event.Stage)
+	}
+
+	return stages
+}
@@ -0,0 +1,69 @@
+package verifier
+
+// Profile groups the non-standard compatibilities that can be applied during verification.
+//
+// The zero value is the most restrictive profile: none of the compatibilities are applied and BIP-322 signatures are rejected.
+type Profile struct {
+	// Name is used to identify the profile in errors.
+	Name string
+	// TrimWhitespace retries the verification with leading and trailing whitespace removed from the message, as Electrum trims messages before signing.
+	TrimWhitespace bool
+	// AllowSMPPrefix accepts signatures that are prefixed with 'smp'.
+	AllowSMPPrefix bool
+	// AllowTrezorFlags accepts the BIP-137 (Trezor) recovery flags 35 to 42.
+	AllowTrezorFlags bool
+	// AllowLegacyForAnyAddress treats any 65-byte signature as a legacy signature, even for segwit and taproot addresses.
+	// When disabled, legacy signatures are only valid for P2PKH addresses, as specified by BIP-322.
+	AllowLegacyForAnyAddress bool
+	// AllowBIP322 accepts BIP-322 signatures.
+	AllowBIP322 bool
+}
+
+// StrictBIP322 returns a profile that only accepts signatures exactly as specified by BIP-322.
+// Legacy signatures are only accepted for P2PKH addresses and without any of the wallet specific compatibilities.
+func StrictBIP322() Profile {
+	return Profile{
+		Name:                     "strict-bip322",
+		TrimWhitespace:           false,
+		AllowSMPPrefix:           false,
+		AllowTrezorFlags:         false,
+		AllowLegacyForAnyAddress: false,
+		AllowBIP322:              true,
+	}
+}
+
+// BitcoinCore returns a profile that matches the `verifymessage` RPC of Bitcoin Core, which only supports legacy signatures for P2PKH addresses.
+func BitcoinCore() Profile {
+	return Profile{
+		Name:                     "bitcoin-core",
+		TrimWhitespace:           false,
+		AllowSMPPrefix:           false,
+		AllowTrezorFlags:         false,
+		AllowLegacyForAnyAddress: false,
+		AllowBIP322:              false,
+	}
+}
+
+// ElectrumCompatible returns a profile that accepts signatures made by Electrum-like wallets and BIP-137 (Trezor) wallets.
+func ElectrumCompatible() Profile {
+	return Profile{
+		Name:                     "electrum-compatible",
+		TrimWhitespace:           true,
+		AllowSMPPrefix:           false,
+		AllowTrezorFlags:         true,
+		AllowLegacyForAnyAddress: true,
+		AllowBIP322:              true,
+	}
+}
+
+// Permissive returns a profile that applies every compatibility, this is the profile used by Verify and VerifyWithChain.
+func Permissive() Profile {
+	return Profile{
+		Name:                     "permissive",
+		TrimWhitespace:           true,
+		AllowSMPPrefix:           true,
+		AllowTrezorFlags:         true,
+		AllowLegacyForAnyAddress: true,
+		AllowBIP322:              true,
+	}
+}
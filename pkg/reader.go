@@ -0,0 +1,88 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/bitonicnl/verify-signed-message/internal"
+)
+
+// NewMessageDigestFromReader computes both hashes of a message of the passed length, while streaming it from the reader.
+// The message is never held in memory as a whole. An error is returned when the reader does not contain exactly length bytes.
+func NewMessageDigestFromReader(message io.Reader, length uint64) (MessageDigest, error) {
+	return readMessageDigest(message, length, true, true)
+}
+
+// VerifyReader will verify a decoded signature for a decoded address and a message of the passed length that is streamed from the reader.
+// This allows verifying very large messages with bounded memory use, see VerifyDigest. The format is resolved from the signature first,
+// so only the hash that the format needs is computed and nothing is read when the signature can not be verified for the address.
+// The signature is bounded by DefaultLimits, the length of the message is not since it is never held in memory.
+func VerifyReader(address btcutil.Address, message io.Reader, length uint64, signature []byte, net *chaincfg.Params) (bool, error) {
+	handler, format, err := resolveDecoded(address, signature, net)
+	if err != nil {
+		return false, err
+	}
+
+	legacy, bip322 := requiredHashes(format)
+
+	digest, err := readMessageDigest(message, length, legacy, bip322)
+	if err != nil {
+		return false, err
+	}
+
+	return handler.Verify(address, digest, signature, format, net)
+}
+
+// readMessageDigest computes the requested hashes of a message of the passed length, while streaming it from the reader.
+// The hashes that are not requested are left empty in the digest.
+func readMessageDigest(message io.Reader, length uint64, legacy bool, bip322 bool) (MessageDigest, error) {
+	if length > math.MaxInt64 {
+		return MessageDigest{}, fmt.Errorf("message length %d is too large", length)
+	}
+
+	hashers := []io.Writer{}
+
+	var legacyHasher, bip322Hasher hash.Hash
+	if legacy {
+		legacyHasher = internal.NewMagicMessageHasher(length)
+		hashers = append(hashers, legacyHasher)
+	}
+
+	if bip322 {
+		bip322Hasher = internal.NewBIP322Hasher()
+		hashers = append(hashers, bip322Hasher)
+	}
+
+	// Feed the hashers at once, io.CopyN only uses a small fixed size buffer
+	written, err := io.CopyN(io.MultiWriter(hashers...), message, int64(length))
+	if errors.Is(err, io.EOF) {
+		return MessageDigest{}, fmt.Errorf("message is shorter than the declared length: %d instead of %d", written, length)
+	} else if err != nil {
+		return MessageDigest{}, fmt.Errorf("could not read message: %w", err)
+	}
+
+	// The legacy hash commits to the length, so any remaining data means the declared length was wrong.
+	// A reader may return no data without an error, io.ReadFull keeps reading until it gets a byte or an error.
+	if _, err := io.ReadFull(message, make([]byte, 1)); err == nil {
+		return MessageDigest{}, fmt.Errorf("message is longer than the declared length of %d", length)
+	} else if !errors.Is(err, io.EOF) {
+		return MessageDigest{}, fmt.Errorf("could not read message: %w", err)
+	}
+
+	digest := MessageDigest{Legacy: nil, BIP322: nil}
+	if legacyHasher != nil {
+		digest.Legacy = internal.SumMagicMessageHasher(legacyHasher)
+	}
+
+	if bip322Hasher != nil {
+		digest.BIP322 = bip322Hasher.Sum(nil)
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,182 @@
+package verifier_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type ReaderTestSuite struct {
+	suite.Suite
+}
+
+func TestReaderTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(ReaderTestSuite))
+}
+
+func (s *ReaderTestSuite) TestNewMessageDigestFromReader() {
+	message := []byte(strings.Repeat("VeryLongMessage!", 64))
+
+	digest, err := verifier.NewMessageDigestFromReader(iotest.OneByteReader(bytes.NewReader(message)), uint64(len(message)))
+	s.Require().NoError(err)
+	s.Require().Equal(verifier.NewMessageDigest(message), digest)
+}
+
+func (s *ReaderTestSuite) TestNewMessageDigestFromReaderIncorrect() {
+	tests := map[string]struct {
+		message       io.Reader
+		length        uint64
+		expectedError string
+	}{
+		"too short": {
+			message:       strings.NewReader("short"),
+			length:        10,
+			expectedError: "message is shorter than the declared length: 5 instead of 10",
+		},
+		"too long": {
+			message:       strings.NewReader("longer than declared"),
+			length:        6,
+			expectedError: "message is longer than the declared length of 6",
+		},
+		"too long after an empty read": {
+			message:       &emptyReadReader{reader: strings.NewReader("longer than declared"), empty: false},
+			length:        6,
+			expectedError: "message is longer than the declared length of 6",
+		},
+		"read error": {
+			message:       iotest.ErrReader(io.ErrUnexpectedEOF),
+			length:        6,
+			expectedError: "could not read message: unexpected EOF",
+		},
+		"length too large": {
+			message:       strings.NewReader(""),
+			length:        1 << 63,
+			expectedError: "message length 9223372036854775808 is too large",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			_, err := verifier.NewMessageDigestFromReader(tt.message, tt.length)
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
+
+func (s *ReaderTestSuite) TestVerifyReader() {
+	tests := map[string]verifier.SignedMessage{
+		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+		"trezor - segwit native long message": {
+			Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+			Message:   strings.Repeat("VeryLongMessage!", 64),
+			Signature: "KMb4biVeqnaMRH1jXZHaAWMaxUryI8LBgtT6NnbP7K5KGZrTOnT+BPtGw5QyrLjYPedNqQ9fARI7O32LwlK8f3E=",
+		},
+		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+		"bip-322 - native segwit - test vector #0": {
+			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			Message:   "Hello World",
+			Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			address, err := btcutil.DecodeAddress(tt.Address, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+
+			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.Signature)
+			s.Require().NoError(err)
+
+			valid, err := verifier.VerifyReader(address, strings.NewReader(tt.Message), uint64(len(tt.Message)), signatureDecoded, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+			s.True(valid)
+		})
+	}
+}
+
+// TestVerifyReaderBoundedMemory streams a large message and ensures the allocated memory does not grow with the message size.
+//
+//nolint:paralleltest // Measures allocations, which would include those of other tests when run in parallel
+func TestVerifyReaderBoundedMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("streams a 64 MiB message, which is skipped in short mode")
+	}
+
+	const length = 64 << 20 // 64 MiB
+
+	privateKey, _ := btcec.PrivKeyFromBytes([]byte("streaming message signing key!!!"))
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(privateKey.PubKey().SerializeCompressed()), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	// Sign the large message, which already streams
+	digest, err := verifier.NewMessageDigestFromReader(newPatternReader(length), length)
+	require.NoError(t, err)
+	signature := ecdsa.SignCompact(privateKey, digest.Legacy, true)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	valid, err := verifier.VerifyReader(address, newPatternReader(length), length, signature, &chaincfg.MainNetParams)
+
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// Allow for the copy buffer and the verification itself, which is far less than the message
+	require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+}
+
+// patternReader generates a deterministic message of a fixed length without holding it in memory.
+type patternReader struct {
+	remaining int
+}
+
+func newPatternReader(length int) *patternReader {
+	return &patternReader{remaining: length}
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	n := min(len(p), r.remaining)
+	for i := range n {
+		p[i] = byte((r.remaining - i) % 251)
+	}
+	r.remaining -= n
+
+	return n, nil
+}
+
+// emptyReadReader returns no data and no error on every other read, which io.Reader allows.
+type emptyReadReader struct {
+	reader io.Reader
+	empty  bool
+}
+
+func (r *emptyReadReader) Read(p []byte) (int, error) {
+	r.empty = !r.empty
+	if r.empty {
+		return 0, nil
+	}
+
+	return r.reader.Read(p)
+}
@@ -0,0 +1,33 @@
+package verifier
+
+// Format is the proof format a signature was verified as.
+type Format string
+
+const (
+	// FormatLegacy is a legacy or BIP-137 signature, which consists of 65 bytes.
+	FormatLegacy Format = "legacy"
+	// FormatBIP322Simple is a BIP-322 simple signature, which consists of a witness stack.
+	FormatBIP322Simple Format = "bip322-simple"
+	// FormatBIP322Full is a BIP-322 full signature, which consists of the complete toSign transaction.
+	FormatBIP322Full Format = "bip322-full"
+)
+
+// Result holds the details of a verification.
+type Result struct {
+	// Valid is true when the signature is valid for the address and message.
+	Valid bool
+	// Format is the proof format the signature was verified as, it is empty if the signature could not be decoded.
+	Format Format
+	// Encoding is the text encoding the signature was provided in, it is empty if the signature could not be decoded.
+	Encoding Encoding
+	// MessageTrimmed is true when the signature was only valid for the message with leading and trailing whitespace removed.
+	MessageTrimmed bool
+	// Dialects lists the wallets that probably produced the signature, ranked by confidence with the highest first.
+	// It is only set when the Verifier was created with WithDialects. It is based on heuristics and also filled for invalid signatures,
+	// it is empty if the format of the signature could not be determined.
+	Dialects []DialectMatch
+	// Trace records the execution of a BIP-322 signature, it is only set when the Verifier was created with WithBIP322Trace.
+	Trace *BIP322Trace
+	// ErrorClass is the kind of failure, ErrorClassNone for valid signatures. It is the same class that is reported to the Observer.
+	ErrorClass ErrorClass
+}
@@ -0,0 +1,176 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/bitonicnl/verify-signed-message/internal/bip322"
+)
+
+// TraceStep is the state of the script engine after executing a single opcode of a BIP-322 signature.
+type TraceStep struct {
+	// ScriptIndex is the index of the script the opcode belongs to: 0 is the signature script, 1 the public key script and 2 the redeem or witness script.
+	ScriptIndex int
+	// OpcodeIndex is the index of the opcode in its script.
+	OpcodeIndex int
+	// Opcode is the disassembly of the opcode, including the data it pushes.
+	Opcode string
+	// Stack holds the hex encoded items of the data stack after executing the opcode, the top of the stack last.
+	Stack []string
+	// AltStack holds the hex encoded items of the alternative stack after executing the opcode, the top of the stack last.
+	AltStack []string
+}
+
+// BIP322Trace records how a BIP-322 signature was verified, see WithBIP322Trace.
+type BIP322Trace struct {
+	// ToSpend is the raw hex of the toSpend transaction, which pays to the address and commits to the message.
+	ToSpend string
+	// ToSign is the raw hex of the toSign transaction, which spends toSpend with the signature.
+	ToSign string
+	// SigHash is the hex encoded hash the first signature commits to, it is empty when it can not be determined from the witness alone.
+	SigHash string
+	// SigHashType is the sighash type of the first signature.
+	SigHashType txscript.SigHashType
+	// Steps are the executed opcodes, in order.
+	Steps []TraceStep
+	// Error is the error of the script execution, it is empty if the execution succeeded.
+	Error string
+}
+
+// WithBIP322Trace enables the debug mode, which records the execution of BIP-322 signatures in Result.Trace.
+// The signature is executed a second time to record the trace, so this should not be used for regular verification.
+func WithBIP322Trace() Option {
+	return func(v *Verifier) {
+		v.trace = true
+	}
+}
+
+// String pretty prints the trace, one step per line followed by its stacks.
+func (t BIP322Trace) String() string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "toSpend: %s\n", t.ToSpend)
+	fmt.Fprintf(&builder, "toSign: %s\n", t.ToSign)
+
+	if t.SigHash == "" {
+		builder.WriteString("sighash: unknown\n")
+	} else {
+		fmt.Fprintf(&builder, "sighash: %s (type 0x%02x)\n", t.SigHash, uint32(t.SigHashType))
+	}
+
+	for i, step := range t.Steps {
+		fmt.Fprintf(&builder, "step %d [script %d, opcode %d]: %s\n", i+1, step.ScriptIndex, step.OpcodeIndex, step.Opcode)
+		fmt.Fprintf(&builder, "  stack: %s\n", formatTraceStack(step.Stack))
+		fmt.Fprintf(&builder, "  alt stack: %s\n", formatTraceStack(step.AltStack))
+	}
+
+	if t.Error == "" {
+		builder.WriteString("result: success\n")
+	} else {
+		fmt.Fprintf(&builder, "result: %s\n", t.Error)
+	}
+
+	return builder.String()
+}
+
+// formatTraceStack formats the items of a stack on a single line, empty items are shown as <empty>.
+func formatTraceStack(stack []string) string {
+	items := make([]string, len(stack))
+	for i, item := range stack {
+		items[i] = item
+		if item == "" {
+			items[i] = "<empty>"
+		}
+	}
+
+	return "[" + strings.Join(items, " ") + "]"
+}
+
+// traceBIP322 records the execution of a BIP-322 signature, the message is the message the result was verified against.
+// It returns nil for other formats, or when the transactions could not be drafted, in which case the verification already failed with that error.
+func traceBIP322(address btcutil.Address, message string, signature DecodedSignature, format Format) *BIP322Trace {
+	messageHash := [chainhash.HashSize]byte(BIP322MessageHash([]byte(message)))
+
+	var (
+		trace bip322.Trace
+		err   error
+	)
+
+	switch format {
+	case FormatBIP322Simple:
+		trace, err = bip322.TraceHash(address, messageHash, signature.Payload)
+	case FormatBIP322Full:
+		toSign, decodeErr := bip322.DecodeFull(signature.Payload)
+		if decodeErr != nil {
+			return nil
+		}
+
+		trace, err = bip322.TraceFull(address, messageHash, toSign)
+	case FormatLegacy:
+		// Legacy signatures are not executed by the script engine
+		return nil
+	default:
+		// Formats of custom handlers are verified by the handler
+		return nil
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	return newBIP322Trace(trace)
+}
+
+// newBIP322Trace converts the trace of the script engine into its hex encoded form.
+func newBIP322Trace(trace bip322.Trace) *BIP322Trace {
+	result := &BIP322Trace{
+		ToSpend:     serializeTraceTx(trace.ToSpend),
+		ToSign:      serializeTraceTx(trace.ToSign),
+		SigHash:     hex.EncodeToString(trace.SigHash),
+		SigHashType: trace.SigHashType,
+		Steps:       make([]TraceStep, len(trace.Steps)),
+		Error:       "",
+	}
+
+	for i, step := range trace.Steps {
+		result.Steps[i] = TraceStep{
+			ScriptIndex: step.ScriptIndex,
+			OpcodeIndex: step.OpcodeIndex,
+			Opcode:      step.Opcode,
+			Stack:       encodeTraceStack(step.Stack),
+			AltStack:    encodeTraceStack(step.AltStack),
+		}
+	}
+
+	if trace.Err != nil {
+		result.Error = trace.Err.Error()
+	}
+
+	return result
+}
+
+// serializeTraceTx returns the raw hex of a transaction, including its witness data.
+func serializeTraceTx(tx *wire.MsgTx) string {
+	var buffer bytes.Buffer
+	if err := tx.Serialize(&buffer); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(buffer.Bytes())
+}
+
+// encodeTraceStack hex encodes the items of a stack.
+func encodeTraceStack(stack [][]byte) []string {
+	encoded := make([]string, len(stack))
+	for i, item := range stack {
+		encoded[i] = hex.EncodeToString(item)
+	}
+
+	return encoded
+}
@@ -0,0 +1,145 @@
+package verifier_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type TraceTestSuite struct {
+	suite.Suite
+}
+
+func TestTraceTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(TraceTestSuite))
+}
+
+func (s *TraceTestSuite) TestTrace() {
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithBIP322Trace())
+
+	result, err := v.Verify(verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature})
+	s.Require().NoError(err)
+	s.Require().True(result.Valid)
+	s.Require().NotNil(result.Trace)
+
+	trace := result.Trace
+	s.Require().Empty(trace.Error)
+	s.Require().Equal(txscript.SigHashAll, trace.SigHashType)
+	s.Require().Len(trace.SigHash, 64)
+	s.Require().Len(trace.Steps, 7)
+	s.Require().Equal(verifier.TraceStep{ScriptIndex: 2, OpcodeIndex: 4, Opcode: "OP_CHECKSIG", Stack: []string{"01"}, AltStack: []string{}}, trace.Steps[6])
+
+	// The transactions can be replayed, toSign spends toSpend and carries the signature as its witness
+	toSpend, toSign := s.decodeTx(trace.ToSpend), s.decodeTx(trace.ToSign)
+	s.Require().Equal(toSpend.TxHash(), toSign.TxIn[0].PreviousOutPoint.Hash)
+
+	witness, err := base64.StdEncoding.DecodeString(simpleSignature)
+	s.Require().NoError(err)
+
+	var expected bytes.Buffer
+	s.Require().NoError(wire.WriteVarInt(&expected, 0, uint64(len(toSign.TxIn[0].Witness))))
+	for _, item := range toSign.TxIn[0].Witness {
+		s.Require().NoError(wire.WriteVarBytes(&expected, 0, item))
+	}
+	s.Require().Equal(witness, expected.Bytes())
+
+	// The pretty printed trace lists every step with its stacks
+	text := trace.String()
+	s.Require().True(strings.HasPrefix(text, "toSpend: "+trace.ToSpend+"\ntoSign: "+trace.ToSign+"\nsighash: "+trace.SigHash+" (type 0x01)\n"))
+	s.Require().Contains(text, "step 1 [script 1, opcode 0]: OP_0\n  stack: [<empty>]\n  alt stack: []\n")
+	s.Require().Contains(text, "step 7 [script 2, opcode 4]: OP_CHECKSIG\n  stack: [01]\n  alt stack: []\n")
+	s.Require().True(strings.HasSuffix(text, "result: success\n"))
+}
+
+func (s *TraceTestSuite) TestTraceFailed() {
+	v := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithBIP322Trace())
+
+	result, err := v.Verify(verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World - This should fail", Signature: simpleSignature})
+	s.Require().EqualError(err, "script execution failed: signature not empty on failed checksig")
+	s.Require().NotNil(result.Trace)
+	s.Require().Equal(err.Error(), result.Trace.Error)
+	s.Require().Empty(result.Trace.Steps[len(result.Trace.Steps)-1].Stack)
+	s.Require().True(strings.HasSuffix(result.Trace.String(), "result: script execution failed: signature not empty on failed checksig\n"))
+
+	// Full signatures are traced as well
+	result, err = v.Verify(verifier.SignedMessage{Address: addressP2WSH, Message: "Hello World", Signature: fullSignatureP2WSH})
+	s.Require().NoError(err)
+	s.Require().NotNil(result.Trace)
+	s.Require().Empty(result.Trace.Error)
+	s.Require().Equal(fullSignatureP2WSH, s.reencode(result.Trace.ToSign))
+}
+
+func (s *TraceTestSuite) TestTraceNotRecorded() {
+	bip322Message := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature}
+
+	tests := map[string]struct {
+		verifier      *verifier.Verifier
+		signedMessage verifier.SignedMessage
+	}{
+		"disabled": {
+			verifier:      verifier.NewVerifier(&chaincfg.MainNetParams),
+			signedMessage: bip322Message,
+		},
+		"legacy": {
+			verifier:      verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithBIP322Trace()),
+			signedMessage: verifier.SignedMessage{Address: "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", Message: "test message", Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA="},
+		},
+		"limit exceeded": {
+			verifier:      verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithBIP322Trace(), verifier.WithLimits(verifier.Limits{MaxWitnessItems: 1})),
+			signedMessage: bip322Message,
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, _ := tt.verifier.Verify(tt.signedMessage)
+			s.Require().Nil(result.Trace)
+		})
+	}
+}
+
+func (s *TraceTestSuite) TestTraceCached() {
+	cache := verifier.NewMemoryCache(10, time.Hour, time.Hour)
+	signedMessage := verifier.SignedMessage{Address: "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", Message: "Hello World", Signature: simpleSignature}
+
+	// The trace is recorded for cached results as well, but it is never stored in the cache
+	_, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache)).Verify(signedMessage)
+	s.Require().NoError(err)
+
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithCache(cache), verifier.WithBIP322Trace()).Verify(signedMessage)
+	s.Require().NoError(err)
+	s.Require().NotNil(result.Trace)
+	s.Require().Equal(verifier.CacheStats{Hits: 1, Misses: 1, Evictions: 0, Entries: 1}, cache.Stats())
+}
+
+// decodeTx decodes the raw hex of a transaction.
+func (s *TraceTestSuite) decodeTx(raw string) *wire.MsgTx {
+	decoded, err := hex.DecodeString(raw)
+	s.Require().NoError(err)
+
+	var tx wire.MsgTx
+	s.Require().NoError(tx.Deserialize(bytes.NewReader(decoded)))
+
+	return &tx
+}
+
+// reencode encodes the raw hex of a transaction as base64, the encoding of full signatures.
+func (s *TraceTestSuite) reencode(raw string) string {
+	decoded, err := hex.DecodeString(raw)
+	s.Require().NoError(err)
+
+	return base64.StdEncoding.EncodeToString(decoded)
+}
@@ -0,0 +1,206 @@
+package verifier
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Verifier verifies signed messages on a single network. It is safe for concurrent use and meant to be reused.
+type Verifier struct {
+	net      *chaincfg.Params
+	profile  Profile
+	decoders *DecoderRegistry
+	handlers *AddressHandlerRegistry
+	cache    Cache
+	limits   Limits
+	trace    bool
+	dialects bool
+	observer Observer
+}
+
+// Option configures a Verifier.
+type Option func(*Verifier)
+
+// WithProfile sets the profile that decides which compatibilities are applied, the default is Permissive.
+func WithProfile(profile Profile) Option {
+	return func(v *Verifier) {
+		v.profile = profile
+	}
+}
+
+// WithDecoders sets the registry of decoders that take signatures out of their envelope, the default is DefaultDecoders.
+// The registry should not be modified after the Verifier has been created.
+func WithDecoders(decoders *DecoderRegistry) Option {
+	return func(v *Verifier) {
+		v.decoders = decoders
+	}
+}
+
+// WithAddressHandlers sets the registry of handlers that verify signatures for each address type, the default is DefaultAddressHandlers.
+// The registry should not be modified after the Verifier has been created.
+func WithAddressHandlers(handlers *AddressHandlerRegistry) Option {
+	return func(v *Verifier) {
+		v.handlers = handlers
+	}
+}
+
+// WithCache caches the outcomes of verifications, keyed by the network, the profile and the normalized inputs, see CacheKey.
+// Signatures that can not be decoded are not cached. A cache should only be shared between verifiers that use the same registries.
+func WithCache(cache Cache) Option {
+	return func(v *Verifier) {
+		v.cache = cache
+	}
+}
+
+// NewVerifier creates a Verifier for the passed network.
+func NewVerifier(net *chaincfg.Params, options ...Option) *Verifier {
+	v := &Verifier{net: net, profile: Permissive(), decoders: DefaultDecoders(), handlers: DefaultAddressHandlers(), cache: nil, limits: DefaultLimits(), trace: false, dialects: false, observer: NopObserver{}}
+	for _, option := range options {
+		option(v)
+	}
+
+	return v
+}
+
+// Verify will verify a SignedMessage and return the details of the verification.
+func (v *Verifier) Verify(signedMessage SignedMessage) (Result, error) {
+	started := time.Now()
+	v.observer.VerifyStarted(StartEvent{Network: v.net.Name})
+
+	event := FinishEvent{Network: v.net.Name, AddressType: "", Format: "", Valid: false, Cached: false, Duration: 0, ErrorClass: ErrorClassNone, Err: nil}
+	result, err := v.verify(signedMessage, &event)
+
+	result.ErrorClass = event.ErrorClass
+	event.Format, event.Valid, event.Duration, event.Err = result.Format, result.Valid, time.Since(started), err
+	v.observer.VerifyFinished(event)
+
+	return result, err
+}
+
+// verify verifies a SignedMessage, it fills the details of the verification that are only known along the way in the event.
+func (v *Verifier) verify(signedMessage SignedMessage, event *FinishEvent) (Result, error) {
+	result := Result{Valid: false, Format: "", Encoding: "", MessageTrimmed: false, Dialects: []DialectMatch{}, Trace: nil, ErrorClass: ErrorClassNone}
+
+	// Reject oversized input before doing any work
+	if err := v.limits.checkInput(signedMessage); err != nil {
+		event.ErrorClass = ErrorClassLimitExceeded
+
+		return result, err
+	}
+
+	// Decode the address and ensure it is valid for the network
+	started := time.Now()
+	address, err := decodeAddress(signedMessage.Address, v.net)
+	v.observeStage(StageAddressDecode, "", started, err)
+
+	if err != nil {
+		event.ErrorClass = ErrorClassAddress
+
+		return result, err
+	}
+
+	event.AddressType = addressType(address)
+
+	// Decode the signature
+	started = time.Now()
+	signature, err := v.decoders.decode(signedMessage.Signature, v.profile)
+	v.observeStage(StageSignatureDecode, "", started, err)
+
+	if err != nil {
+		event.ErrorClass = ErrorClassSignature
+
+		return result, err
+	}
+
+	result, event.Cached, err = v.verifyCached(address, signedMessage.Message, signature)
+	event.ErrorClass = classifyVerification(result, err)
+
+	// Oversized signatures are never fingerprinted or executed, not even to record a trace
+	if errors.Is(err, ErrLimitExceeded) {
+		return result, err
+	}
+
+	if v.dialects && result.Format != "" {
+		result.Dialects = fingerprint(address, signature, result.Format, result.MessageTrimmed)
+	}
+
+	if v.trace {
+		message := signedMessage.Message
+		if result.MessageTrimmed {
+			message = strings.TrimSpace(message)
+		}
+
+		result.Trace = traceBIP322(address, message, signature, result.Format)
+	}
+
+	return result, err
+}
+
+// verifyCached verifies a decoded signature, using the cache when the Verifier has one. The boolean is true when the outcome was cached.
+func (v *Verifier) verifyCached(address btcutil.Address, message string, signature DecodedSignature) (Result, bool, error) {
+	if v.cache == nil {
+		result, err := v.verifySignature(address, message, signature)
+
+		return result, false, err
+	}
+
+	key := newCacheKey(v.net, v.profile, v.limits, address, message, signature)
+	if entry, found := v.cache.Get(key); found {
+		return entry.Result, true, entry.Err
+	}
+
+	result, err := v.verifySignature(address, message, signature)
+	v.cache.Set(key, CacheEntry{Result: result, Err: err})
+
+	return result, false, err
+}
+
+// verifySignature verifies a decoded signature for a decoded address, retrying with a trimmed message when the profile allows it.
+// The address and signature are decoded once, only the verification against the message is repeated for the trimmed message.
+func (v *Verifier) verifySignature(address btcutil.Address, message string, signature DecodedSignature) (Result, error) {
+	result := Result{Valid: false, Format: "", Encoding: signature.Encoding, MessageTrimmed: false, Dialects: []DialectMatch{}, Trace: nil, ErrorClass: ErrorClassNone}
+
+	// Errors up to here do not depend on the message, so retrying with a trimmed message could never succeed
+	handler, format, err := resolveFormat(v.handlers, address, signature, v.profile)
+	if err != nil {
+		return result, err
+	}
+
+	result.Format = format
+
+	if err := v.limits.checkSignature(address, signature.Payload, format); err != nil {
+		return result, err
+	}
+
+	// Check if message contains spaces that can be trimmed, if so run the verification with the trimmed message
+	// This is required because Electrum trims messages before signing
+	if trimmedMessage := strings.TrimSpace(message); v.profile.TrimWhitespace && len(message) != len(trimmedMessage) {
+		// We only care about this return if it's valid
+		started := time.Now()
+		valid, err := v.verifyObserved(handler, address, []byte(trimmedMessage), signature.Payload, format)
+		v.observeStage(StageNormalizationRetry, format, started, err)
+
+		if err == nil && valid {
+			result.Valid, result.MessageTrimmed = true, true
+
+			return result, nil
+		}
+	}
+
+	result.Valid, err = v.verifyObserved(handler, address, []byte(message), signature.Payload, format)
+
+	return result, err
+}
+
+// verifyObserved verifies a signature against the message, reporting it as the stage of the format.
+func (v *Verifier) verifyObserved(handler AddressHandler, address btcutil.Address, message []byte, signatureDecoded []byte, format Format) (bool, error) {
+	started := time.Now()
+	valid, err := verifyMessage(handler, address, message, signatureDecoded, format, v.net)
+	v.observeStage(formatStage(format), format, started, err)
+
+	return valid, err
+}
@@ -0,0 +1,142 @@
+package verifier_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+type VerifierTestSuite struct {
+	suite.Suite
+}
+
+func TestVerifierTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(VerifierTestSuite))
+}
+
+func (s *VerifierTestSuite) TestVerify() {
+	tests := map[string]struct {
+		signedMessage  verifier.SignedMessage
+		expectedResult verifier.Result
+	}{
+		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
+		"generic - legacy - compressed": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+				Message:   "test message",
+				Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			},
+			expectedResult: verifier.Result{Valid: true, Format: verifier.FormatLegacy, Encoding: verifier.EncodingBase64, MessageTrimmed: false, Dialects: []verifier.DialectMatch{}, ErrorClass: verifier.ErrorClassNone},
+		},
+		// Based on the test above
+		"generic - legacy - compressed - untrimmed - base64url": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+				Message:   "  test message  ",
+				Signature: "IFqUo4_sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			},
+			expectedResult: verifier.Result{Valid: true, Format: verifier.FormatLegacy, Encoding: verifier.EncodingBase64URL, MessageTrimmed: true, Dialects: []verifier.DialectMatch{}, ErrorClass: verifier.ErrorClassNone},
+		},
+		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+		"bip-322 - native segwit - test vector #0 - smp prefixed": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+				Message:   "Hello World",
+				Signature: "smpAkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+			},
+			expectedResult: verifier.Result{Valid: true, Format: verifier.FormatBIP322Simple, Encoding: verifier.EncodingSMP, MessageTrimmed: false, Dialects: []verifier.DialectMatch{}, ErrorClass: verifier.ErrorClassNone},
+		},
+		// Single key taproot bip-322 signature (created with the buidl-python library)
+		// Taken from: https://github.com/luke-jr/bitcoin/blob/9ab7b8ada61a5f558c92c3eb9fd3cd3625d8cc09/src/test/util_tests.cpp#L1754
+		"buidl-python - taproot - hex": {
+			signedMessage: verifier.SignedMessage{
+				Address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+				Message:   "Hello World",
+				Signature: s.hex("AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="),
+			},
+			expectedResult: verifier.Result{Valid: true, Format: verifier.FormatBIP322Simple, Encoding: verifier.EncodingHex, MessageTrimmed: false, Dialects: []verifier.DialectMatch{}, ErrorClass: verifier.ErrorClassNone},
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			result, err := verifier.NewVerifier(&chaincfg.MainNetParams).Verify(tt.signedMessage)
+			s.Require().NoError(err)
+			s.Require().Equal(tt.expectedResult, result)
+		})
+	}
+}
+
+func (s *VerifierTestSuite) TestVerifyIncorrect() {
+	// Taken from https://github.com/luke-jr/bitcoin/blob/9ab7b8ada61a5f558c92c3eb9fd3cd3625d8cc09/src/test/util_tests.cpp#L1765
+	result, err := verifier.NewVerifier(&chaincfg.MainNetParams, verifier.WithProfile(verifier.StrictBIP322())).Verify(verifier.SignedMessage{
+		Address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+		Message:   "Hello World - This should fail",
+		Signature: "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==",
+	})
+	s.Require().EqualError(err, "script execution failed: ")
+	s.Require().Equal(verifier.Result{Valid: false, Format: verifier.FormatBIP322Simple, Encoding: verifier.EncodingBase64, MessageTrimmed: false, Dialects: []verifier.DialectMatch{}, ErrorClass: verifier.ErrorClassInvalid}, result)
+}
+
+// hex converts a base64 encoded signature to hex.
+func (s *VerifierTestSuite) hex(signature string) string {
+	// Mark as helper
+	s.T().Helper()
+
+	decoded, _, err := verifier.DecodeSignature(signature)
+	s.Require().NoError(err)
+
+	return hex.EncodeToString(decoded)
+}
+
+func (s *VerifierTestSuite) TestVerifyAddressHints() {
+	tests := map[string]struct {
+		address       string
+		expectedError string
+	}{
+		"typo": {
+			address:       "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0q",
+			expectedError: "could not decode address: invalid checksum (expected (bech32=vgkx0l, bech32m=vgkx0le5x22a), got vgkx0q): character 'q' at position 42 is probably wrong",
+		},
+		"prefix typo": {
+			address:       "bd1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			expectedError: "could not decode address: decoded address is of unknown format: prefix 'bd' should probably be 'bc'",
+		},
+		"bech32 for witness version 1": {
+			address:       "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5snuhnwn",
+			expectedError: "could not decode address: invalid checksum expected bech32m encoding for address with witness version 1: witness version 1 requires a bech32m checksum, but bech32 is used",
+		},
+	}
+
+	v := verifier.NewVerifier(&chaincfg.MainNetParams)
+	for name, tt := range tests {
+		s.Run(name, func() {
+			_, err := v.Verify(verifier.SignedMessage{Address: tt.address, Message: "Hello World", Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA="})
+			s.Require().EqualError(err, tt.expectedError)
+		})
+	}
+}
+
+func FuzzVerifier(f *testing.F) {
+	f.Add("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", "Hello World", simpleSignature)
+	f.Add("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l", "Hello World", fullSignaturePSBT)
+	f.Add("1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5", "  test message  ", "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=")
+	f.Add(addressP2WSH, "Hello World", fullSignatureP2WSH)
+
+	v := verifier.NewVerifier(&chaincfg.MainNetParams)
+
+	f.Fuzz(func(t *testing.T, address string, message string, signature string) {
+		result, err := v.Verify(verifier.SignedMessage{Address: address, Message: message, Signature: signature})
+		if result.Valid {
+			require.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package verifiertest
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Breakage is a deliberate defect of a signature, which makes its verification with the Permissive profile fail with verifier.ErrorClassInvalid.
+// Stricter profiles can reject the format of the signature before the defect is noticed.
+type Breakage string
+
+const (
+	// BreakageWrongMessage signs another message than the fixture holds.
+	BreakageWrongMessage Breakage = "wrong-message"
+	// BreakageWrongFlag replaces the recovery flag of a legacy signature with a flag of a family that does not match the address type.
+	BreakageWrongFlag Breakage = "wrong-flag"
+	// BreakageTruncatedWitness removes the last item from the witness of a BIP-322 signature, which usually is the public key.
+	BreakageTruncatedWitness Breakage = "truncated-witness"
+	// BreakageHighS replaces the ECDSA signature in a BIP-322 signature with its high S twin, which is not allowed by the standard script verification flags.
+	BreakageHighS Breakage = "high-s"
+)
+
+// Breakages returns the breakages that apply to the signatures of the scheme for the address type.
+func (s Scheme) Breakages(addressType AddressType) []Breakage {
+	switch {
+	case s.Format() == verifier.FormatLegacy:
+		return []Breakage{BreakageWrongMessage, BreakageWrongFlag}
+	case addressType == AddressTypeP2PKHUncompressed, addressType == AddressTypeP2PKH:
+		// There is no witness to truncate, the signature is in the signature script
+		return []Breakage{BreakageWrongMessage, BreakageHighS}
+	case addressType == AddressTypeP2TR:
+		// Schnorr signatures do not have a high S twin
+		return []Breakage{BreakageWrongMessage, BreakageTruncatedWitness}
+	default:
+		return []Breakage{BreakageWrongMessage, BreakageTruncatedWitness, BreakageHighS}
+	}
+}
+
+// wrongMessage returns the message that is signed instead of the message of a fixture with BreakageWrongMessage.
+func wrongMessage(message string) string {
+	return "Not " + message
+}
+
+// wrongFamily returns a recovery flag family that is not accepted for the address type, which is used for BreakageWrongFlag.
+// Uncompressed flags are not accepted for segwit addresses and BIP-137 (Trezor) flags are not accepted for P2TR addresses.
+func wrongFamily(family flags.Family, addressType AddressType) flags.Family {
+	switch {
+	case family == flags.FamilyUncompressed:
+		return flags.FamilyCompressed
+	case addressType == AddressTypeP2TR, family == flags.FamilyTrezorP2SHAndP2WPKH:
+		return flags.FamilyTrezorP2WPKH
+	default:
+		return flags.FamilyUncompressed
+	}
+}
+
+// breakTransaction applies the breakage to the signed toSign transaction of a BIP-322 signature.
+func breakTransaction(toSign *wire.MsgTx, breakage Breakage) error {
+	input := toSign.TxIn[0]
+
+	switch {
+	case breakage == BreakageTruncatedWitness:
+		input.Witness = input.Witness[:len(input.Witness)-1]
+	case breakage == BreakageHighS && len(input.Witness) > 0:
+		signature, err := highS(input.Witness[0])
+		if err != nil {
+			return err
+		}
+
+		input.Witness[0] = signature
+	case breakage == BreakageHighS:
+		pushes, err := txscript.PushedData(input.SignatureScript)
+		if err != nil {
+			return fmt.Errorf("could not parse signature script: %w", err)
+		}
+
+		if pushes[0], err = highS(pushes[0]); err != nil {
+			return err
+		}
+
+		builder := txscript.NewScriptBuilder()
+		for _, push := range pushes {
+			builder.AddData(push)
+		}
+
+		if input.SignatureScript, err = builder.Script(); err != nil {
+			return fmt.Errorf("could not build signature script: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// highS returns the high S twin of a DER encoded signature that is followed by its sighash type, the twin is equally valid for the same public key.
+// It is encoded manually, as ecdsa.Signature.Serialize always encodes the low S value.
+func highS(signature []byte) ([]byte, error) {
+	parsed, err := ecdsa.ParseDERSignature(signature[:len(signature)-1])
+	if err != nil {
+		return nil, fmt.Errorf("could not parse signature: %w", err)
+	}
+
+	r, s := parsed.R(), parsed.S()
+	s.Negate()
+
+	rBytes, sBytes := derInteger(r), derInteger(s)
+	encoded := make([]byte, 0, 7+len(rBytes)+len(sBytes))
+	encoded = append(encoded, 0x30, byte(4+len(rBytes)+len(sBytes)), 0x02, byte(len(rBytes)))
+	encoded = append(encoded, rBytes...)
+	encoded = append(encoded, 0x02, byte(len(sBytes)))
+	encoded = append(encoded, sBytes...)
+
+	return append(encoded, signature[len(signature)-1]), nil
+}
+
+// derInteger encodes a scalar as the minimal big endian value of a DER integer, which is padded with a zero byte when it would be negative.
+func derInteger(value btcec.ModNScalar) []byte {
+	valueBytes := value.Bytes()
+	trimmed := bytes.TrimLeft(valueBytes[:], "\x00")
+	if len(trimmed) == 0 || trimmed[0]&0x80 != 0 {
+		return append([]byte{0x00}, trimmed...)
+	}
+
+	return trimmed
+}
@@ -0,0 +1,6 @@
+// Package verifiertest generates signed messages for tests of code that is built on this library, so no real wallet signatures are needed.
+//
+// A Generator derives its keys from a seed, so the fixtures are the same for every run with the same seed and message.
+// It signs for every supported combination of network, address type and Scheme, and creates broken variants that are expected to be invalid, see Breakage.
+// The keys are public knowledge to anyone with the seed, so they should never be used for anything other than tests.
+package verifiertest
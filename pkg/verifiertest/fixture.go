@@ -0,0 +1,100 @@
+package verifiertest
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// AddressType is a kind of single key address the Generator signs for.
+type AddressType string
+
+const (
+	// AddressTypeP2PKHUncompressed is a P2PKH address of the uncompressed public key.
+	AddressTypeP2PKHUncompressed AddressType = "p2pkh-uncompressed"
+	// AddressTypeP2PKH is a P2PKH address of the compressed public key.
+	AddressTypeP2PKH AddressType = "p2pkh"
+	// AddressTypeP2SHP2WPKH is a P2WPKH address nested in a P2SH address.
+	AddressTypeP2SHP2WPKH AddressType = "p2sh-p2wpkh"
+	// AddressTypeP2WPKH is a native segwit P2WPKH address.
+	AddressTypeP2WPKH AddressType = "p2wpkh"
+	// AddressTypeP2WSH is a P2WSH address of the witness script '<public key> OP_CHECKSIG'.
+	AddressTypeP2WSH AddressType = "p2wsh"
+	// AddressTypeP2TR is a taproot address of the public key without a script path, as specified by BIP-86.
+	AddressTypeP2TR AddressType = "p2tr"
+)
+
+// Scheme is the way a signature is created, which is the recovery flag dialect of legacy signatures or the kind of BIP-322 signature.
+type Scheme string
+
+const (
+	// SchemeLegacy is a legacy signature for a P2PKH address, as created by Bitcoin Core.
+	SchemeLegacy Scheme = "legacy"
+	// SchemeElectrum is a legacy signature with the compressed recovery flags for a segwit address, as created by Electrum.
+	// UniSat uses the same recovery flags for taproot addresses.
+	SchemeElectrum Scheme = "electrum"
+	// SchemeTrezor is a legacy signature with the recovery flags of BIP-137 for a segwit address, as created by Trezor.
+	SchemeTrezor Scheme = "trezor"
+	// SchemeBIP322Simple is a BIP-322 simple signature.
+	SchemeBIP322Simple Scheme = "bip322-simple"
+	// SchemeBIP322Full is a BIP-322 full signature.
+	SchemeBIP322Full Scheme = "bip322-full"
+)
+
+// Schemes returns every scheme the Generator signs with.
+func Schemes() []Scheme {
+	return []Scheme{SchemeLegacy, SchemeElectrum, SchemeTrezor, SchemeBIP322Simple, SchemeBIP322Full}
+}
+
+// AddressTypes returns the address types the scheme can sign for.
+func (s Scheme) AddressTypes() []AddressType {
+	switch s {
+	case SchemeLegacy:
+		return []AddressType{AddressTypeP2PKHUncompressed, AddressTypeP2PKH}
+	case SchemeElectrum:
+		return []AddressType{AddressTypeP2SHP2WPKH, AddressTypeP2WPKH, AddressTypeP2TR}
+	case SchemeTrezor:
+		return []AddressType{AddressTypeP2SHP2WPKH, AddressTypeP2WPKH}
+	case SchemeBIP322Simple:
+		return []AddressType{AddressTypeP2WPKH, AddressTypeP2TR}
+	case SchemeBIP322Full:
+		return []AddressType{AddressTypeP2PKHUncompressed, AddressTypeP2PKH, AddressTypeP2SHP2WPKH, AddressTypeP2WPKH, AddressTypeP2WSH, AddressTypeP2TR}
+	default:
+		return []AddressType{}
+	}
+}
+
+// Format returns the format the signatures of the scheme are verified as.
+func (s Scheme) Format() verifier.Format {
+	switch s {
+	case SchemeBIP322Simple:
+		return verifier.FormatBIP322Simple
+	case SchemeBIP322Full:
+		return verifier.FormatBIP322Full
+	default:
+		return verifier.FormatLegacy
+	}
+}
+
+// Fixture is a generated signed message, together with the outcome that is expected of its verification.
+type Fixture struct {
+	// Name uniquely identifies the fixture, for example 'mainnet - p2wpkh - electrum - wrong-flag'.
+	Name string
+	// Network of the address.
+	Network *chaincfg.Params
+	// AddressType of the address.
+	AddressType AddressType
+	// Scheme the signature was created with.
+	Scheme Scheme
+	// Breakage that was applied to the signature, it is empty for valid fixtures.
+	Breakage Breakage
+	// SignedMessage holds the address, message and base64 encoded signature.
+	SignedMessage verifier.SignedMessage
+	// Format the signature is verified as.
+	Format verifier.Format
+}
+
+// Valid returns true when the signature of the fixture is expected to be valid.
+func (f Fixture) Valid() bool {
+	return f.Breakage == ""
+}
@@ -0,0 +1,144 @@
+package verifiertest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+	"github.com/bitonicnl/verify-signed-message/internal/signer"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// Generator creates fixtures with keys that are derived from a seed.
+type Generator struct {
+	seed []byte
+}
+
+// NewGenerator returns a Generator that derives its keys from the seed, every address type has its own key.
+func NewGenerator(seed []byte) *Generator {
+	return &Generator{seed: append([]byte{}, seed...)}
+}
+
+// PrivateKey returns the private key of the address type, which is the SHA-256 hash of the seed followed by the address type.
+func (g *Generator) PrivateKey(addressType AddressType) *btcec.PrivateKey {
+	hash := sha256.Sum256(append(append([]byte{}, g.seed...), addressType...))
+	privateKey, _ := btcec.PrivKeyFromBytes(hash[:])
+
+	return privateKey
+}
+
+// Address returns the address of the address type on the network.
+func (g *Generator) Address(addressType AddressType, net *chaincfg.Params) (btcutil.Address, error) {
+	return signer.Address(g.PrivateKey(addressType).PubKey(), signer.AddressType(addressType), net)
+}
+
+// Sign signs the message for the address type on the network with the scheme, the result is a valid fixture.
+func (g *Generator) Sign(net *chaincfg.Params, addressType AddressType, scheme Scheme, message string) (Fixture, error) {
+	return g.Break(net, addressType, scheme, message, "")
+}
+
+// Break signs the message like Sign, but applies the breakage to the signature. The result is a fixture that is expected to be invalid.
+// Not every breakage applies to every scheme and address type, see Scheme.Breakages.
+func (g *Generator) Break(net *chaincfg.Params, addressType AddressType, scheme Scheme, message string, breakage Breakage) (Fixture, error) {
+	if !lo.Contains(scheme.AddressTypes(), addressType) {
+		return Fixture{}, fmt.Errorf("scheme '%s' does not support address type '%s'", scheme, addressType)
+	}
+
+	if breakage != "" && !lo.Contains(scheme.Breakages(addressType), breakage) {
+		return Fixture{}, fmt.Errorf("breakage '%s' does not apply to scheme '%s' with address type '%s'", breakage, scheme, addressType)
+	}
+
+	address, err := g.Address(addressType, net)
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	// The signature is made over another message, the fixture holds the original
+	signedMessage := message
+	if breakage == BreakageWrongMessage {
+		signedMessage = wrongMessage(message)
+	}
+
+	var signature []byte
+	switch scheme {
+	case SchemeLegacy, SchemeElectrum, SchemeTrezor:
+		// The scheme supports the address type, so there always is a family
+		family, _ := signer.LegacyFamily(signer.AddressType(addressType), scheme == SchemeTrezor)
+
+		signature = signer.Legacy(g.PrivateKey(addressType), verifier.LegacyMessageHash([]byte(signedMessage)), addressType != AddressTypeP2PKHUncompressed, family.BaseFlag())
+		if breakage == BreakageWrongFlag {
+			signature[0] = byte(wrongFamily(family, addressType).BaseFlag() + flags.GetKeyID(int(signature[0])))
+		}
+	case SchemeBIP322Simple, SchemeBIP322Full:
+		signature, err = g.bip322(net, addressType, scheme, signedMessage, breakage)
+	}
+
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	return Fixture{
+		Name:          fixtureName(net, addressType, scheme, breakage),
+		Network:       net,
+		AddressType:   addressType,
+		Scheme:        scheme,
+		Breakage:      breakage,
+		SignedMessage: verifier.SignedMessage{Address: address.EncodeAddress(), Message: message, Signature: base64.StdEncoding.EncodeToString(signature)},
+		Format:        scheme.Format(),
+	}, nil
+}
+
+// Fixtures returns a valid fixture of the message for every combination of network, scheme and address type, each followed by its broken variants.
+func (g *Generator) Fixtures(message string) ([]Fixture, error) {
+	fixtures := []Fixture{}
+	for _, net := range verifier.Networks() {
+		for _, scheme := range Schemes() {
+			for _, addressType := range scheme.AddressTypes() {
+				for _, breakage := range append([]Breakage{""}, scheme.Breakages(addressType)...) {
+					fixture, err := g.Break(net, addressType, scheme, message, breakage)
+					if err != nil {
+						return nil, err
+					}
+
+					fixtures = append(fixtures, fixture)
+				}
+			}
+		}
+	}
+
+	return fixtures, nil
+}
+
+// bip322 creates a BIP-322 simple or full signature, the breakages are applied to the transaction before it is encoded.
+func (g *Generator) bip322(net *chaincfg.Params, addressType AddressType, scheme Scheme, message string, breakage Breakage) ([]byte, error) {
+	toSign, err := signer.Full(g.PrivateKey(addressType), signer.AddressType(addressType), [32]byte(verifier.BIP322MessageHash([]byte(message))), net)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := breakTransaction(toSign, breakage); err != nil {
+		return nil, err
+	}
+
+	if scheme == SchemeBIP322Simple {
+		return signer.EncodeWitness(toSign.TxIn[0].Witness)
+	}
+
+	return signer.EncodeFull(toSign)
+}
+
+// fixtureName returns the name of a fixture, which consists of its network, address type, scheme and breakage.
+func fixtureName(net *chaincfg.Params, addressType AddressType, scheme Scheme, breakage Breakage) string {
+	name := fmt.Sprintf("%s - %s - %s", net.Name, addressType, scheme)
+	if breakage != "" {
+		name += " - " + string(breakage)
+	}
+
+	return name
+}
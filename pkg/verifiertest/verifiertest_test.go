@@ -0,0 +1,179 @@
+package verifiertest_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
+	"github.com/bitonicnl/verify-signed-message/internal/signer"
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/verifiertest"
+)
+
+type VerifierTestTestSuite struct {
+	suite.Suite
+}
+
+func TestVerifierTestTestSuite(t *testing.T) {
+	// Run everything in parallel
+	t.Parallel()
+
+	suite.Run(t, new(VerifierTestTestSuite))
+}
+
+func (s *VerifierTestTestSuite) TestFixtures() {
+	generator := verifiertest.NewGenerator([]byte("verifiertest"))
+	fixtures, err := generator.Fixtures("Hello World")
+	s.Require().NoError(err)
+
+	// 15 valid fixtures with 34 broken variants for every network
+	s.Require().Len(fixtures, 6*(15+34))
+	s.Require().Len(lo.UniqBy(fixtures, func(fixture verifiertest.Fixture) string { return fixture.Name }), len(fixtures))
+	s.Require().Len(lo.Filter(fixtures, func(fixture verifiertest.Fixture, _ int) bool { return fixture.Valid() }), 6*15)
+
+	for _, fixture := range fixtures {
+		s.Run(fixture.Name, func() {
+			result, err := verifier.NewVerifier(fixture.Network).Verify(fixture.SignedMessage)
+			s.Require().Equal(fixture.Format, result.Format)
+			s.Require().Equal(lo.Ternary(fixture.Valid(), verifier.ErrorClassNone, verifier.ErrorClassInvalid), result.ErrorClass)
+
+			if fixture.Valid() {
+				s.Require().NoError(err)
+				s.Require().True(result.Valid)
+
+				return
+			}
+
+			s.Require().EqualError(err, s.expectedError(generator, fixture))
+			s.Require().False(result.Valid)
+		})
+	}
+}
+
+func (s *VerifierTestTestSuite) TestDeterministic() {
+	fixture, err := verifiertest.NewGenerator([]byte("seed")).Sign(&chaincfg.MainNetParams, verifiertest.AddressTypeP2WPKH, verifiertest.SchemeBIP322Simple, "Hello World")
+	s.Require().NoError(err)
+	s.Require().Equal(verifiertest.Fixture{
+		Name:        "mainnet - p2wpkh - bip322-simple",
+		Network:     &chaincfg.MainNetParams,
+		AddressType: verifiertest.AddressTypeP2WPKH,
+		Scheme:      verifiertest.SchemeBIP322Simple,
+		Breakage:    "",
+		SignedMessage: verifier.SignedMessage{
+			Address:   fixture.SignedMessage.Address,
+			Message:   "Hello World",
+			Signature: fixture.SignedMessage.Signature,
+		},
+		Format: verifier.FormatBIP322Simple,
+	}, fixture)
+
+	// The same seed results in the same fixture, another seed in another key
+	again, err := verifiertest.NewGenerator([]byte("seed")).Sign(&chaincfg.MainNetParams, verifiertest.AddressTypeP2WPKH, verifiertest.SchemeBIP322Simple, "Hello World")
+	s.Require().NoError(err)
+	s.Require().Equal(fixture, again)
+
+	other, err := verifiertest.NewGenerator([]byte("other")).Sign(&chaincfg.MainNetParams, verifiertest.AddressTypeP2WPKH, verifiertest.SchemeBIP322Simple, "Hello World")
+	s.Require().NoError(err)
+	s.Require().NotEqual(fixture.SignedMessage.Address, other.SignedMessage.Address)
+
+	address, err := verifiertest.NewGenerator([]byte("seed")).Address(verifiertest.AddressTypeP2WPKH, &chaincfg.MainNetParams)
+	s.Require().NoError(err)
+	s.Require().Equal(fixture.SignedMessage.Address, address.EncodeAddress())
+}
+
+func (s *VerifierTestTestSuite) TestBreak() {
+	generator := verifiertest.NewGenerator([]byte("seed"))
+
+	fixture, err := generator.Break(&chaincfg.TestNet3Params, verifiertest.AddressTypeP2WPKH, verifiertest.SchemeTrezor, "Hello World", verifiertest.BreakageWrongFlag)
+	s.Require().NoError(err)
+	s.Require().Equal("testnet3 - p2wpkh - trezor - wrong-flag", fixture.Name)
+	s.Require().False(fixture.Valid())
+
+	_, err = generator.Break(&chaincfg.MainNetParams, verifiertest.AddressTypeP2WSH, verifiertest.SchemeBIP322Simple, "Hello World", "")
+	s.Require().EqualError(err, "scheme 'bip322-simple' does not support address type 'p2wsh'")
+
+	_, err = generator.Break(&chaincfg.MainNetParams, verifiertest.AddressTypeP2TR, verifiertest.SchemeBIP322Full, "Hello World", verifiertest.BreakageHighS)
+	s.Require().EqualError(err, "breakage 'high-s' does not apply to scheme 'bip322-full' with address type 'p2tr'")
+}
+
+// expectedError returns the error the verification of a broken fixture fails with, which is not part of the contract of the package.
+func (s *VerifierTestTestSuite) expectedError(generator *verifiertest.Generator, fixture verifiertest.Fixture) string {
+	signature, err := base64.StdEncoding.DecodeString(fixture.SignedMessage.Signature)
+	s.Require().NoError(err)
+
+	switch {
+	case fixture.Format == verifier.FormatLegacy && fixture.Breakage == verifiertest.BreakageWrongMessage:
+		// The signature recovers another public key, which results in another address
+		compact := append([]byte{}, signature...)
+		compact[0] = byte(flags.Minimal(int(signature[0])))
+
+		publicKey, _, err := ecdsa.RecoverCompact(compact, verifier.LegacyMessageHash([]byte(fixture.SignedMessage.Message)))
+		if err != nil {
+			return "could not recover pubkey: " + err.Error()
+		}
+
+		return s.addressMismatch(publicKey, fixture.AddressType, fixture)
+	case fixture.Format == verifier.FormatLegacy:
+		return s.wrongFlagError(generator, fixture)
+	default:
+		return bip322Error(fixture)
+	}
+}
+
+// wrongFlagError returns the error the verification of a fixture with BreakageWrongFlag fails with.
+func (s *VerifierTestTestSuite) wrongFlagError(generator *verifiertest.Generator, fixture verifiertest.Fixture) string {
+	publicKey := generator.PrivateKey(fixture.AddressType).PubKey()
+
+	switch {
+	case fixture.AddressType == verifiertest.AddressTypeP2PKHUncompressed:
+		return s.addressMismatch(publicKey, verifiertest.AddressTypeP2PKH, fixture)
+	case fixture.AddressType == verifiertest.AddressTypeP2PKH:
+		return s.addressMismatch(publicKey, verifiertest.AddressTypeP2PKHUncompressed, fixture)
+	case fixture.AddressType == verifiertest.AddressTypeP2TR:
+		return "cannot use P2TR for recovery flag 'BIP137 (Trezor) P2WPKH'"
+	case fixture.AddressType == verifiertest.AddressTypeP2SHP2WPKH && fixture.Scheme == verifiertest.SchemeTrezor:
+		return "cannot use P2SH for recovery flag 'BIP137 (Trezor) P2WPKH'"
+	case fixture.AddressType == verifiertest.AddressTypeP2SHP2WPKH:
+		return "cannot use P2SH for recovery flag 'P2PKH uncompressed'"
+	default:
+		return "cannot use P2WPKH for recovery flag 'P2PKH uncompressed'"
+	}
+}
+
+// bip322Error returns the error the verification of a broken BIP-322 fixture fails with.
+// The toSpend transaction commits to the message, so a full signature of another message spends another transaction.
+func bip322Error(fixture verifiertest.Fixture) string {
+	switch {
+	case fixture.Breakage == verifiertest.BreakageWrongMessage && fixture.Scheme == verifiertest.SchemeBIP322Full:
+		return "invalid toSign transaction format: first input does not spend the toSpend transaction"
+	case fixture.Breakage == verifiertest.BreakageWrongMessage && fixture.AddressType == verifiertest.AddressTypeP2TR:
+		// The script engine does not describe a failed schnorr signature check
+		return "script execution failed: "
+	case fixture.Breakage == verifiertest.BreakageWrongMessage:
+		return "script execution failed: signature not empty on failed checksig"
+	case fixture.Breakage == verifiertest.BreakageHighS:
+		return "script execution failed: signature is not canonical due to unnecessarily high S value"
+	case fixture.AddressType == verifiertest.AddressTypeP2TR:
+		return "script execution failed: witness program empty passed empty witness"
+	case fixture.AddressType == verifiertest.AddressTypeP2WSH:
+		// The last item of the witness is the witness script, so the signature is taken for the script
+		return "script execution failed: witness program hash mismatch"
+	default:
+		return "script execution failed: should have exactly two items in witness, instead have 1"
+	}
+}
+
+// addressMismatch returns the error of a legacy signature that recovers the public key of another address.
+func (s *VerifierTestTestSuite) addressMismatch(publicKey *btcec.PublicKey, addressType verifiertest.AddressType, fixture verifiertest.Fixture) string {
+	generated, err := signer.Address(publicKey, signer.AddressType(addressType), fixture.Network)
+	s.Require().NoError(err)
+
+	return fmt.Sprintf("generated address '%s' does not match expected address '%s'", generated.EncodeAddress(), fixture.SignedMessage.Address)
+}
@@ -1,15 +1,15 @@
 package verifier
 
 import (
-	"encoding/base64"
 	"fmt"
-	"strings"
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
 
 	"github.com/bitonicnl/verify-signed-message/internal/bip322"
 	"github.com/bitonicnl/verify-signed-message/internal/generic"
+	"github.com/bitonicnl/verify-signed-message/internal/generic/flags"
 )
 
 // Verify will verify a SignedMessage based on the recovery flag on Bitcoin main network.
@@ -19,47 +19,155 @@ func Verify(sig SignedMessage) (bool, error) {
 
 // VerifyWithChain will verify a SignedMessage based on the recovery flag on the passed network.
 // Supported address types are P2PKH, P2WKH, NP2WKH (P2WPKH), P2TR.
+//
+// All compatibilities are applied, see Permissive.
 func VerifyWithChain(signedMessage SignedMessage, net *chaincfg.Params) (bool, error) {
-	// Check if message contains spaces that can be trimmed, if so run the verification with the trimmed message
-	// This is required because Electrum trims messages before signing
-	if trimmedMessage := strings.TrimSpace(signedMessage.Message); len(signedMessage.Message) != len(trimmedMessage) {
-		// We only care about this return if it's valid
-		if verified, err := Verify(SignedMessage{Message: trimmedMessage, Address: signedMessage.Address, Signature: signedMessage.Signature}); err == nil && verified {
-			return true, nil
-		}
-	}
+	return VerifyWithProfile(signedMessage, net, Permissive())
+}
+
+// VerifyWithProfile will verify a SignedMessage based on the recovery flag on the passed network.
+// Only the compatibilities that are enabled in the passed Profile are applied.
+func VerifyWithProfile(signedMessage SignedMessage, net *chaincfg.Params, profile Profile) (bool, error) {
+	result, err := NewVerifier(net, WithProfile(profile)).Verify(signedMessage)
+
+	return result.Valid, err
+}
 
-	// Decode the address
-	address, err := btcutil.DecodeAddress(signedMessage.Address, net)
+// VerifyRaw will verify a decoded signature for a decoded address and a message of arbitrary bytes on the passed network.
+// The compatibilities of Permissive that operate on decoded values are applied, the message is never trimmed.
+// The signature is bounded by DefaultLimits, the message is not since the caller already holds it.
+//
+// The message and signature are never modified, so it is safe to call VerifyRaw concurrently on shared buffers.
+func VerifyRaw(address btcutil.Address, message []byte, signature []byte, net *chaincfg.Params) (bool, error) {
+	handler, format, err := resolveDecoded(address, signature, net)
 	if err != nil {
-		return false, fmt.Errorf("could not decode address: %w", err)
+		return false, err
 	}
 
+	return verifyMessage(handler, address, message, signature, format, net)
+}
+
+// resolveDecoded resolves the handler and format of a decoded signature for the functions that verify decoded values.
+// The address has to be valid for the network and the signature has to be within DefaultLimits.
+func resolveDecoded(address btcutil.Address, signature []byte, net *chaincfg.Params) (AddressHandler, Format, error) {
 	// Ensure the address is valid for the passed network
 	if !address.IsForNet(net) {
-		return false, fmt.Errorf("address '%s' is not valid for network '%s'", signedMessage.Address, net.Name)
+		return nil, "", fmt.Errorf("address '%s' is not valid for network '%s'", address.EncodeAddress(), net.Name)
 	}
 
-	// Grab the signature from the message
-	signature := signedMessage.Signature
+	handler, format, err := resolveFormat(DefaultAddressHandlers(), address, DecodedSignature{Payload: signature, Format: "", Encoding: ""}, Permissive())
+	if err != nil {
+		return nil, "", err
+	}
 
-	// Decode the signature
-	signatureDecoded, err := base64.StdEncoding.DecodeString(signature)
+	if err := DefaultLimits().checkDecoded(address, signature, format); err != nil {
+		return nil, "", err
+	}
 
-	// Edge-case for SMP signed messages
-	if err != nil && strings.HasPrefix(signature, "smp") {
-		signatureDecoded, err = base64.StdEncoding.DecodeString(signedMessage.Signature[3:])
+	return handler, format, nil
+}
+
+// resolveFormat looks up the handler of the address and decides the format of the signature.
+// Neither depends on the message, so they are resolved once even when multiple variants of the message are verified.
+func resolveFormat(handlers *AddressHandlerRegistry, address btcutil.Address, signature DecodedSignature, profile Profile) (AddressHandler, Format, error) {
+	handler, err := handlers.handler(address)
+	if err != nil {
+		return nil, "", err
 	}
 
+	format, err := detectFormat(address, handler.Formats(), signature.Payload, signature.Format, profile)
 	if err != nil {
-		return false, fmt.Errorf("could not decode signature: %w", err)
+		return nil, "", err
 	}
 
-	// Handle generic/BIP-137 signature. For P2PKH address, assume the signature is also a legacy signature
-	if _, ok := address.(*btcutil.AddressPubKeyHash); ok || len(signatureDecoded) == generic.ExpectedSignatureLength {
-		return generic.Verify(address, signedMessage.Message, signatureDecoded, net)
+	return handler, format, nil
+}
+
+// verifyMessage verifies a signature of a resolved format against the message, which is the only step that depends on the message.
+func verifyMessage(handler AddressHandler, address btcutil.Address, message []byte, signatureDecoded []byte, format Format, net *chaincfg.Params) (bool, error) {
+	// Only compute the hash that is needed for the format
+	digest := MessageDigest{Legacy: nil, BIP322: nil}
+	legacy, bip322 := requiredHashes(format)
+	if legacy {
+		digest.Legacy = LegacyMessageHash(message)
+	}
+	if bip322 {
+		digest.BIP322 = BIP322MessageHash(message)
 	}
 
-	// Otherwise, try and verify it as BIP-322
-	return bip322.Verify(address, signedMessage.Message, signatureDecoded)
+	return handler.Verify(address, digest, signatureDecoded, format, net)
+}
+
+// requiredHashes reports which hashes of the message are needed to verify a signature of the format, formats of custom handlers get both.
+func requiredHashes(format Format) (bool, bool) {
+	legacy := format != FormatBIP322Simple && format != FormatBIP322Full
+	bip322 := format != FormatLegacy
+
+	return legacy, bip322
+}
+
+// detectFormat decides which format the signature should be verified as, a format declared by the envelope of the signature takes precedence.
+// Otherwise, the format is detected from the signature and the formats supported by the handler of the address, as documented on AddressHandler.
+// An error is returned when the format is not allowed by the profile.
+func detectFormat(address btcutil.Address, formats []Format, signatureDecoded []byte, declared Format, profile Profile) (Format, error) {
+	if declared == "" {
+		declared = detectUndeclaredFormat(formats, signatureDecoded)
+	}
+
+	switch declared {
+	case FormatLegacy:
+		if _, isP2PKH := address.(*btcutil.AddressPubKeyHash); !isP2PKH && !profile.AllowLegacyForAnyAddress {
+			return "", fmt.Errorf("legacy signatures are only allowed for P2PKH addresses by profile '%s'", profile.Name)
+		}
+
+		// BIP-137 (Trezor) recovery flags are not part of the legacy specification
+		if !profile.AllowTrezorFlags && len(signatureDecoded) == generic.ExpectedSignatureLength && lo.Contains[int](flags.Trezor(), int(signatureDecoded[0])) {
+			return "", fmt.Errorf("recovery flag %d is not allowed by profile '%s'", signatureDecoded[0], profile.Name)
+		}
+
+		return FormatLegacy, nil
+	case FormatBIP322Simple, FormatBIP322Full:
+		if !profile.AllowBIP322 {
+			return "", fmt.Errorf("BIP-322 signatures are not allowed by profile '%s'", profile.Name)
+		}
+
+		return declared, nil
+	default:
+		// Formats of custom handlers are not restricted by profiles
+		if !lo.Contains(formats, declared) {
+			return "", fmt.Errorf("unknown signature format '%s'", declared)
+		}
+
+		return declared, nil
+	}
+}
+
+// detectUndeclaredFormat detects the format of a signature that was not declared by its envelope.
+func detectUndeclaredFormat(formats []Format, signatureDecoded []byte) Format {
+	hasLegacyLength := len(signatureDecoded) == generic.ExpectedSignatureLength
+
+	// A full signature is a transaction, which is never mistaken for a legacy signature
+	detected := FormatBIP322Simple
+	if !hasLegacyLength && lo.Contains(formats, FormatBIP322Full) && isFullSignature(signatureDecoded) {
+		detected = FormatBIP322Full
+	} else if hasLegacyLength {
+		detected = FormatLegacy
+	}
+
+	if !lo.Contains(formats, detected) {
+		return formats[0]
+	}
+
+	return detected
+}
+
+// isFullSignature reports whether the signature is a BIP-322 full signature (a transaction) rather than a simple signature (a witness stack).
+func isFullSignature(signatureDecoded []byte) bool {
+	if _, trailing, err := bip322.SimpleSigToWitnessWithTrailing(signatureDecoded); err == nil && len(trailing) == 0 {
+		return false
+	}
+
+	_, err := bip322.DecodeFull(signatureDecoded)
+
+	return err == nil
 }
@@ -0,0 +1,81 @@
+package verifier_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+)
+
+// benchmarkMessages returns the signed messages that are benchmarked, one for each common path through the verification.
+func benchmarkMessages() map[string]verifier.SignedMessage {
+	return map[string]verifier.SignedMessage{
+		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
+		"legacy": {
+			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			Message:   "test message",
+			Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+		},
+		"legacy - untrimmed": {
+			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			Message:   "  test message  ",
+			Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+		},
+		"legacy - untrimmed - malformed": {
+			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			Message:   "  test message  ",
+			Signature: "INVALID",
+		},
+		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+		"bip-137 - native segwit": {
+			Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+			Message:   "This is an example of a signed message.",
+			Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
+		},
+		// BIP-322 test vectors - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+		"bip-322 simple - native segwit": {
+			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			Message:   "Hello World",
+			Signature: simpleSignature,
+		},
+		"bip-322 simple - taproot": {
+			Address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+			Message:   "Hello World",
+			Signature: "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==",
+		},
+		"bip-322 full - native segwit": {
+			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			Message:   "Hello World",
+			Signature: fullSignature,
+		},
+	}
+}
+
+// BenchmarkVerifyWithChain benchmarks the package level function, which creates a Verifier for every call.
+func BenchmarkVerifyWithChain(b *testing.B) {
+	for name, signedMessage := range benchmarkMessages() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for b.Loop() {
+				_, _ = verifier.VerifyWithChain(signedMessage, &chaincfg.MainNetParams)
+			}
+		})
+	}
+}
+
+// BenchmarkVerifier benchmarks a Verifier that is reused, as recommended for verifying many messages.
+func BenchmarkVerifier(b *testing.B) {
+	v := verifier.NewVerifier(&chaincfg.MainNetParams)
+
+	for name, signedMessage := range benchmarkMessages() {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for b.Loop() {
+				_, _ = v.Verify(signedMessage)
+			}
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package verifier_test
 
 import (
+	"encoding/base64"
+	"sync"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/suite"
 
 	verifier "github.com/bitonicnl/verify-signed-message/pkg"
+	"github.com/bitonicnl/verify-signed-message/pkg/conformance"
 )
 
 type VerifyTestSuite struct {
@@ -78,62 +83,210 @@ func (s *VerifyTestSuite) TestVerifyIncorrect() {
 	}
 }
 
-func (s *VerifyTestSuite) TestVerifyWithChainTestnet() {
-	tests := map[string]verifier.SignedMessage{
-		"electrum - segwit native": {
-			Address:   "tb1qr97cuq4kvq7plfetmxnl6kls46xaka78n2288z",
-			Message:   "The outage comes at a time when bitcoin has been fast approaching new highs not seen since June 26, 2019.",
-			Signature: "H/bSByRH7BW1YydfZlEx9x/nt4EAx/4A691CFlK1URbPEU5tJnTIu4emuzkgZFwC0ptvKuCnyBThnyLDCqPqT10=",
-		},
-		"sparrow - bip-322 - segwit native": {
-			Address:   "tb1qnzwefk7wzphlc4xeawf8p4yqtcwzdgsvukwma8",
-			Message:   "The outage comes at a time when bitcoin has been fast approaching new highs not seen since June 26, 2019.",
-			Signature: "AkcwRAIgLvNWZneiHQUgulpYhIFarxws7a+k/QUTlbEFgdr2bOwCIG4Za9UKDJmc7V0eoyt/rCKe1wUr3F3WqHKeoSbMaFd6ASEDElXeZo3eLtCBIF2hvhxGdJzZonHbew9M1RXYsZZX+rg=",
-		},
+func (s *VerifyTestSuite) TestVerifyWithChain() {
+	vectors, err := conformance.Vectors()
+	s.Require().NoError(err)
+
+	// The valid vectors, except the ones that are not supported
+	for _, vector := range lo.Filter(vectors, func(vector conformance.Vector, _ int) bool {
+		return vector.Expected == conformance.OutcomeValid && !lo.Contains(conformance.LibraryUnsupported(), vector.Name)
+	}) {
+		s.Run(vector.Name, func() {
+			net, err := vector.Params()
+			s.Require().NoError(err)
+
+			valid, err := verifier.VerifyWithChain(verifier.SignedMessage{Address: vector.Address, Message: vector.Message, Signature: vector.Signature}, net)
+			s.Require().NoError(err)
+			s.True(valid)
+		})
+	}
+}
+
+func (s *VerifyTestSuite) TestVerifyWithProfile() {
+	legacy := verifier.SignedMessage{
+		Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		Message:   "test message",
+		Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+	}
+	legacyUntrimmed := verifier.SignedMessage{
+		Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+		Message:   "  test message  ",
+		Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+	}
+	electrumSegwit := verifier.SignedMessage{
+		Address:   "bc1qsdjne3y6ljndzvg9z9qrhje8k7p2m5yas704hn",
+		Message:   "Integer can be encoded depending on the represented value to save space. Variable length integers always precede an array/vector of a type of data that may vary in length. Longer numbers are encoded in little endian. If you're reading the Satoshi client code (BitcoinQT) it refers to this encoding as a \"CompactSize\". Modern Bitcoin Core also has the VARINT macro which implements an even more compact integer for the purpose of local storage (which is incompatible with \"CompactSize\" described here). VARINT is not a part of the protocol.",
+		Signature: "H3TkHAXCKRfyDowCra5YRDF/Vkk2HQCel/pgEgTj9LYaWpnviSRcuYtv/CZk7NTyHsJnYP56bqbvuU3PejwLCnA=",
+	}
+	// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+	trezorSegwit := verifier.SignedMessage{
+		Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+		Message:   "This is an example of a signed message.",
+		Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
+	}
+	// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
+	bip322Segwit := verifier.SignedMessage{
+		Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		Message:   "Hello World",
+		Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	}
+	bip322SegwitSMP := verifier.SignedMessage{
+		Address:   bip322Segwit.Address,
+		Message:   bip322Segwit.Message,
+		Signature: "smp" + bip322Segwit.Signature,
+	}
+
+	tests := map[string]struct {
+		signedMessage verifier.SignedMessage
+		profile       verifier.Profile
+		expectedError string
+	}{
+		"strict - legacy":                     {signedMessage: legacy, profile: verifier.StrictBIP322()},
+		"strict - legacy - untrimmed":         {signedMessage: legacyUntrimmed, profile: verifier.StrictBIP322(), expectedError: "generated address '1CJuhHnUQVGuDeSrn2vcGDy7w8ExoGkqy' does not match expected address '1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5'"},
+		"strict - legacy - segwit":            {signedMessage: electrumSegwit, profile: verifier.StrictBIP322(), expectedError: "legacy signatures are only allowed for P2PKH addresses by profile 'strict-bip322'"},
+		"strict - bip-322":                    {signedMessage: bip322Segwit, profile: verifier.StrictBIP322()},
+		"strict - bip-322 - smp prefixed":     {signedMessage: bip322SegwitSMP, profile: verifier.StrictBIP322(), expectedError: "could not decode signature: illegal base64 data at input byte 147"},
+		"bitcoin core - legacy":               {signedMessage: legacy, profile: verifier.BitcoinCore()},
+		"bitcoin core - legacy - segwit":      {signedMessage: electrumSegwit, profile: verifier.BitcoinCore(), expectedError: "legacy signatures are only allowed for P2PKH addresses by profile 'bitcoin-core'"},
+		"bitcoin core - bip-322":              {signedMessage: bip322Segwit, profile: verifier.BitcoinCore(), expectedError: "BIP-322 signatures are not allowed by profile 'bitcoin-core'"},
+		"electrum - legacy - untrimmed":       {signedMessage: legacyUntrimmed, profile: verifier.ElectrumCompatible()},
+		"electrum - legacy - segwit":          {signedMessage: electrumSegwit, profile: verifier.ElectrumCompatible()},
+		"electrum - trezor - segwit":          {signedMessage: trezorSegwit, profile: verifier.ElectrumCompatible()},
+		"electrum - bip-322 - smp prefixed":   {signedMessage: bip322SegwitSMP, profile: verifier.ElectrumCompatible(), expectedError: "could not decode signature: illegal base64 data at input byte 147"},
+		"permissive - bip-322 - smp prefixed": {signedMessage: bip322SegwitSMP, profile: verifier.Permissive()},
+		"custom - trezor - segwit":            {signedMessage: trezorSegwit, profile: verifier.Profile{Name: "custom", AllowLegacyForAnyAddress: true}, expectedError: "recovery flag 40 is not allowed by profile 'custom'"},
 	}
 
 	for name, tt := range tests {
 		s.Run(name, func() {
-			valid, err := verifier.VerifyWithChain(tt, &chaincfg.TestNet3Params)
+			valid, err := verifier.VerifyWithProfile(tt.signedMessage, &chaincfg.MainNetParams, tt.profile)
+			if tt.expectedError != "" {
+				s.Require().EqualError(err, tt.expectedError)
+				s.False(valid)
+
+				return
+			}
+
 			s.Require().NoError(err)
 			s.True(valid)
 		})
 	}
 }
 
-func (s *VerifyTestSuite) TestVerify() {
-	tests := map[string]verifier.SignedMessage{
+func (s *VerifyTestSuite) TestVerifyRaw() {
+	tests := map[string]struct {
+		address       string
+		message       []byte
+		signature     string
+		expectedError string
+	}{
 		// Taken from https://github.com/btclib-org/btclib/blob/v2022.7.20/tests/ecc/test_bms.py
 		"generic - legacy - compressed": {
-			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
-			Message:   "test message",
-			Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			message:   []byte("test message"),
+			signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
 		},
-		// Based on the test above
+		// Based on the test above, the message is never trimmed
 		"generic - legacy - compressed - untrimmed": {
-			Address:   "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
-			Message:   "  test message  ",
-			Signature: "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			address:       "1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5",
+			message:       []byte("  test message  "),
+			signature:     "IFqUo4/sxBEFkfK8mZeeN56V13BqOc0D90oPBChF3gTqMXtNSCTN79UxC33kZ8Mi0cHy4zYCnQfCxTyLpMVXKeA=",
+			expectedError: "generated address '1CJuhHnUQVGuDeSrn2vcGDy7w8ExoGkqy' does not match expected address '1DAag8qiPLHh6hMFVu9qJQm9ro1HtwuyK5'",
+		},
+		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+		"trezor - segwit native": {
+			address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+			message:   []byte("This is an example of a signed message."),
+			signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
 		},
 		// BIP-322 test vector #0 - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
 		"bip-322 - native segwit - test vector #0": {
-			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
-			Message:   "Hello World",
-			Signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+			address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+			message:   []byte("Hello World"),
+			signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
 		},
-		// BIP-322 test vector #0 with SMP prefixed - https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#user-content-Test_vectors
-		"bip-322 - native segwit - test vector #0 - smp prefixed": {
-			Address:   "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
-			Message:   "Hello World",
-			Signature: "smpAkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+		"address - wrong network": {
+			address:       "tb1qnzwefk7wzphlc4xeawf8p4yqtcwzdgsvukwma8",
+			message:       []byte("The outage comes at a time when bitcoin has been fast approaching new highs not seen since June 26, 2019."),
+			signature:     "AUEUpr/X2GrTv1+LUytXEAv+FDADgWkFppbx87/xz8DNEVXSunSDo1/asR9DbeAVgK3Ao4B1cAxEz3pW7wEQGmLvAQ==",
+			expectedError: "address 'tb1qnzwefk7wzphlc4xeawf8p4yqtcwzdgsvukwma8' is not valid for network 'mainnet'",
 		},
 	}
 
-	for i := range tests {
-		s.Run(i, func() {
-			valid, err := verifier.Verify(tests[i])
+	for name, tt := range tests {
+		s.Run(name, func() {
+			address, err := btcutil.DecodeAddress(tt.address, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+
+			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.signature)
+			s.Require().NoError(err)
+
+			valid, err := verifier.VerifyRaw(address, tt.message, signatureDecoded, &chaincfg.MainNetParams)
+			if tt.expectedError != "" {
+				s.Require().EqualError(err, tt.expectedError)
+				s.False(valid)
+
+				return
+			}
+
 			s.Require().NoError(err)
 			s.True(valid)
 		})
 	}
 }
+
+// TestVerifyRawConcurrent shares the same buffers between goroutines, run with `-race` to detect any modification of the inputs.
+func (s *VerifyTestSuite) TestVerifyRawConcurrent() {
+	tests := map[string]verifier.SignedMessage{
+		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+		"trezor - segwit native": {
+			Address:   "bc1qannfxke2tfd4l7vhepehpvt05y83v3qsf6nfkk",
+			Message:   "This is an example of a signed message.",
+			Signature: "KLVddgDZ6afipJFV3fPP2455bCB/qrgzAQ+kH7eCiIm8R89iNIp6qgkjwIMqWJ+rVB6PEutU+3EckOIwfw9msZQ=",
+		},
+		// Taken from https://github.com/trezor/trezor-firmware/blob/core/v2.3.4/tests/device_tests/test_msg_signmessage.py
+		"trezor - segwit": {
+			Address:   "3L6TyTisPBmrDAj6RoKmDzNnj4eQi54gD2",
+			Message:   "This is an example of a signed message.",
+			Signature: "I3RN5FFvrFwUCAgBVmRRajL+rZTeiXdc7H4k28JP4TMHWsCTAcTMjhl76ktkgWYdW46b8Z2Le4o4Ls21PC7gdQ0=",
+		},
+		// Single key taproot bip-322 signature (created with the buidl-python library)
+		// Taken from: https://github.com/luke-jr/bitcoin/blob/9ab7b8ada61a5f558c92c3eb9fd3cd3625d8cc09/src/test/util_tests.cpp#L1754
+		"buidl-python - taproot": {
+			Address:   "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3",
+			Message:   "Hello World",
+			Signature: "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==",
+		},
+	}
+
+	for name, tt := range tests {
+		s.Run(name, func() {
+			address, err := btcutil.DecodeAddress(tt.Address, &chaincfg.MainNetParams)
+			s.Require().NoError(err)
+
+			// Shared buffers, including copies to compare against afterwards
+			message := []byte(tt.Message)
+			signatureDecoded, err := base64.StdEncoding.DecodeString(tt.Signature)
+			s.Require().NoError(err)
+			messageCopy := append([]byte{}, message...)
+			signatureCopy := append([]byte{}, signatureDecoded...)
+
+			const workers = 16
+			errs := make([]error, workers)
+			wg := sync.WaitGroup{}
+			for i := range workers {
+				wg.Go(func() {
+					_, errs[i] = verifier.VerifyRaw(address, message, signatureDecoded, &chaincfg.MainNetParams)
+				})
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				s.Require().NoError(err)
+			}
+
+			s.Require().Equal(messageCopy, message)
+			s.Require().Equal(signatureCopy, signatureDecoded)
+		})
+	}
+}